@@ -0,0 +1,292 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContextIssueType represents the type of a context issue
+type ContextIssueType string
+
+const (
+	ContextIssueTypeUnknownAttribute ContextIssueType = "unknown_attribute" // attribute is not defined
+	ContextIssueTypeInvalidType      ContextIssueType = "invalid_type"      // value cannot be coerced to the attribute's type
+)
+
+// ContextIssue represents a problem found in context, against attribute definitions
+type ContextIssue struct {
+	Type      ContextIssueType `json:"type"`
+	Attribute AttributeKey     `json:"attribute"` // dot-separated for object properties, like "user.plan"
+	Expected  AttributeType    `json:"expected,omitempty"`
+	Value     interface{}      `json:"value,omitempty"`
+	Message   string           `json:"message"`
+}
+
+// ContextValidationResult represents the result of validating context
+type ContextValidationResult struct {
+	Valid   bool           `json:"valid"`
+	Context Context        `json:"context"` // coerced, without rejected attributes in strict mode
+	Issues  []ContextIssue `json:"issues,omitempty"`
+}
+
+// ContextValidatorOptions contains options for creating a context validator
+type ContextValidatorOptions struct {
+	Attributes []Attribute
+
+	// Strict leaves unknown and mistyped attributes out of the validated context
+	Strict bool
+
+	// TimeZone is used for dates without a time zone (defaults to UTC)
+	TimeZone *time.Location
+}
+
+// ContextValidator validates and coerces context against attribute definitions
+type ContextValidator struct {
+	attributes map[AttributeKey]Attribute
+	strict     bool
+	location   *time.Location
+}
+
+// NewContextValidator creates a new context validator, ignoring attributes without a key
+func NewContextValidator(options ContextValidatorOptions) *ContextValidator {
+	attributes := make(map[AttributeKey]Attribute, len(options.Attributes))
+	for _, attribute := range options.Attributes {
+		if attribute.Key != nil && *attribute.Key != "" {
+			attributes[*attribute.Key] = attribute
+		}
+	}
+
+	return &ContextValidator{
+		attributes: attributes,
+		strict:     options.Strict,
+		location:   options.TimeZone,
+	}
+}
+
+// GetAttribute returns the definition of an attribute, or nil if not defined
+func (v *ContextValidator) GetAttribute(attributeKey AttributeKey) *Attribute {
+	if attribute, exists := v.attributes[attributeKey]; exists {
+		return &attribute
+	}
+	return nil
+}
+
+// Validate checks context against attribute definitions, coercing values to their attribute's type.
+//
+// Unknown and mistyped attributes are reported as issues. They are kept as they are,
+// unless the validator is strict, in which case they are left out of the returned context.
+func (v *ContextValidator) Validate(context Context) ContextValidationResult {
+	result := ContextValidationResult{
+		Valid:   true,
+		Context: make(Context, len(context)),
+	}
+
+	keys := make([]string, 0, len(context))
+	for key := range context {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := context[key]
+
+		attribute, exists := v.attributes[key]
+		if !exists {
+			result.Issues = append(result.Issues, ContextIssue{
+				Type:      ContextIssueTypeUnknownAttribute,
+				Attribute: key,
+				Value:     value,
+				Message:   fmt.Sprintf("attribute %q is not defined", key),
+			})
+			if !v.strict {
+				result.Context[key] = value
+			}
+			continue
+		}
+
+		coerced, issues := v.coerce(key, value, attribute.Type, attribute.Properties)
+		result.Issues = append(result.Issues, issues...)
+
+		if len(issues) == 0 {
+			result.Context[key] = coerced
+		} else if !v.strict {
+			result.Context[key] = value
+		}
+	}
+
+	result.Valid = len(result.Issues) == 0
+
+	return result
+}
+
+// coerce converts a value to the given attribute type, returning issues if it cannot
+func (v *ContextValidator) coerce(path string, value interface{}, attributeType AttributeType, properties map[AttributeKey]AttributeProperty) (interface{}, []ContextIssue) {
+	// missing values are not checked
+	if value == nil {
+		return nil, nil
+	}
+
+	if attributeType == AttributeTypeObject && len(properties) > 0 {
+		return v.coerceObject(path, value, properties)
+	}
+
+	coerced, ok := coerceAttributeValue(value, attributeType, v.location)
+	if !ok {
+		return nil, []ContextIssue{{
+			Type:      ContextIssueTypeInvalidType,
+			Attribute: path,
+			Expected:  attributeType,
+			Value:     value,
+			Message:   fmt.Sprintf("attribute %q is expected to be of type %s, got %T", path, attributeType, value),
+		}}
+	}
+
+	return coerced, nil
+}
+
+// coerceObject coerces properties of an object attribute
+func (v *ContextValidator) coerceObject(path string, value interface{}, properties map[AttributeKey]AttributeProperty) (interface{}, []ContextIssue) {
+	object, ok := toMap(value)
+	if !ok {
+		return nil, []ContextIssue{{
+			Type:      ContextIssueTypeInvalidType,
+			Attribute: path,
+			Expected:  AttributeTypeObject,
+			Value:     value,
+			Message:   fmt.Sprintf("attribute %q is expected to be of type %s, got %T", path, AttributeTypeObject, value),
+		}}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var issues []ContextIssue
+	coercedObject := make(map[string]interface{}, len(object))
+
+	for _, key := range keys {
+		propertyPath := path + "." + key
+
+		property, exists := properties[key]
+		if !exists {
+			issues = append(issues, ContextIssue{
+				Type:      ContextIssueTypeUnknownAttribute,
+				Attribute: propertyPath,
+				Value:     object[key],
+				Message:   fmt.Sprintf("attribute %q is not defined", propertyPath),
+			})
+			continue
+		}
+
+		coerced, propertyIssues := v.coerce(propertyPath, object[key], property.Type, nil)
+		issues = append(issues, propertyIssues...)
+		coercedObject[key] = coerced
+	}
+
+	return coercedObject, issues
+}
+
+// coerceAttributeValue converts a value to an attribute type, like "42" to integer or ISO strings to dates
+func coerceAttributeValue(value interface{}, attributeType AttributeType, location *time.Location) (interface{}, bool) {
+	switch attributeType {
+	case AttributeTypeString:
+		s, ok := value.(string)
+		return s, ok
+	case AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, true
+			}
+		}
+		return nil, false
+	case AttributeTypeInteger:
+		if s, ok := value.(string); ok {
+			value = json.Number(strings.TrimSpace(s))
+		}
+		if n, ok := toNumber(value); ok && n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return GetValueByType(n, string(AttributeTypeInteger)), true
+		}
+		return nil, false
+	case AttributeTypeDouble:
+		if s, ok := value.(string); ok {
+			value = json.Number(strings.TrimSpace(s))
+		}
+		if n, ok := toNumber(value); ok {
+			return n, true
+		}
+		return nil, false
+	case AttributeTypeDate:
+		if date, err := ParseDate(value, location); err == nil {
+			return date, true
+		}
+		return nil, false
+	case AttributeTypeSemver:
+		// accepting versions the semver comparison operators do, like "1.2.3.4"
+		if s, ok := value.(string); ok {
+			if _, err := ValidateAndParse(s); err == nil {
+				return s, true
+			}
+		}
+		return nil, false
+	case AttributeTypeObject:
+		return toMap(value)
+	case AttributeTypeArray:
+		return toSlice(value)
+	}
+
+	// unknown types are not coerced
+	return value, true
+}
+
+// ParseAttributes parses attribute definitions exported from a Featurevisor project,
+// either as a list of attributes with keys, or as an object keyed by attribute keys
+func ParseAttributes(data []byte) ([]Attribute, error) {
+	var list []Attribute
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+
+	var byKey map[AttributeKey]Attribute
+	if err := json.Unmarshal(data, &byKey); err != nil {
+		return nil, fmt.Errorf("failed to parse attributes: %w", err)
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]Attribute, 0, len(byKey))
+	for _, key := range keys {
+		attribute := byKey[key]
+		if attribute.Key == nil {
+			attributeKey := key
+			attribute.Key = &attributeKey
+		}
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+// LoadAttributesFromFile reads attribute definitions exported from a Featurevisor project
+func LoadAttributesFromFile(filePath string) ([]Attribute, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attributes file: %w", err)
+	}
+
+	return ParseAttributes(data)
+}
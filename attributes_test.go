@@ -0,0 +1,180 @@
+package featurevisor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const attributesTestJSON = `{
+	"userId": {"type": "string"},
+	"country": {"type": "string"},
+	"age": {"type": "integer"},
+	"score": {"type": "double"},
+	"beta": {"type": "boolean"},
+	"signedUpAt": {"type": "date"},
+	"version": {"type": "semver"},
+	"tags": {"type": "array"},
+	"device": {"type": "object", "properties": {"os": {"type": "string"}, "width": {"type": "integer"}}}
+}`
+
+func TestParseAttributes(t *testing.T) {
+	fromObject := newTestAttributes(t, attributesTestJSON)
+	if len(fromObject) != 9 || fromObject[0].Key == nil || *fromObject[0].Key != "age" {
+		t.Fatalf("expected attributes sorted by key, got %v", fromObject)
+	}
+
+	fromList, err := ParseAttributes([]byte(`[{"key": "country", "type": "string"}]`))
+	if err != nil || len(fromList) != 1 || *fromList[0].Key != "country" {
+		t.Fatalf("expected attributes from list, got %v, %v", fromList, err)
+	}
+
+	if _, err := ParseAttributes([]byte(`"invalid"`)); err == nil {
+		t.Error("expected error for invalid attributes")
+	}
+
+	filePath := filepath.Join(t.TempDir(), "attributes.json")
+	if err := os.WriteFile(filePath, []byte(`[{"key": "country", "type": "string"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fromFile, err := LoadAttributesFromFile(filePath)
+	if err != nil || len(fromFile) != 1 {
+		t.Fatalf("expected attributes from file, got %v, %v", fromFile, err)
+	}
+}
+
+func TestContextValidator(t *testing.T) {
+	validator := NewContextValidator(ContextValidatorOptions{Attributes: newTestAttributes(t, attributesTestJSON)})
+
+	result := validator.Validate(Context{
+		"userId":     "123",
+		"age":        "42",
+		"score":      "4.5",
+		"beta":       "true",
+		"signedUpAt": "2024-01-02T03:04:05Z",
+		"version":    "1.2.3",
+		"tags":       []string{"a"},
+		"device":     map[string]interface{}{"os": "ios", "width": float64(1920)},
+	})
+
+	if !result.Valid {
+		t.Fatalf("expected context to be valid, got issues %v", result.Issues)
+	}
+
+	expected := Context{
+		"userId":     "123",
+		"age":        42,
+		"score":      4.5,
+		"beta":       true,
+		"signedUpAt": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"version":    "1.2.3",
+		"tags":       []interface{}{"a"},
+		"device":     map[string]interface{}{"os": "ios", "width": 1920},
+	}
+	if !reflect.DeepEqual(result.Context, expected) {
+		t.Errorf("expected coerced context %v, got %v", expected, result.Context)
+	}
+
+	invalid := Context{
+		"countyr": "nl",
+		"age":     "forty",
+		"device":  map[string]interface{}{"os": "ios", "height": 1080},
+	}
+
+	result = validator.Validate(invalid)
+	if result.Valid || len(result.Issues) != 3 {
+		t.Fatalf("expected 3 issues, got %v", result.Issues)
+	}
+
+	issues := map[string]ContextIssueType{}
+	for _, issue := range result.Issues {
+		issues[issue.Attribute] = issue.Type
+	}
+	if issues["countyr"] != ContextIssueTypeUnknownAttribute ||
+		issues["age"] != ContextIssueTypeInvalidType ||
+		issues["device.height"] != ContextIssueTypeUnknownAttribute {
+		t.Errorf("unexpected issues %v", result.Issues)
+	}
+
+	// kept as they are when not strict
+	if result.Context["countyr"] != "nl" || result.Context["age"] != "forty" {
+		t.Errorf("expected invalid attributes to be kept, got %v", result.Context)
+	}
+
+	// rejected in strict mode
+	strict := NewContextValidator(ContextValidatorOptions{Attributes: newTestAttributes(t, attributesTestJSON), Strict: true})
+	result = strict.Validate(invalid)
+	if len(result.Context) != 0 {
+		t.Errorf("expected invalid attributes to be rejected, got %v", result.Context)
+	}
+
+	// semver attributes accept versions the comparison operators do
+	result = strict.Validate(Context{"version": "1.2.3.4"})
+	if !result.Valid || result.Context["version"] != "1.2.3.4" {
+		t.Errorf("expected 1.2.3.4 to be a valid semver attribute, got %v", result.Issues)
+	}
+}
+
+func TestInstanceWithAttributes(t *testing.T) {
+	datafile := DatafileContent{
+		SchemaVersion: "2",
+		Revision:      "1",
+		Segments: map[SegmentKey]Segment{
+			"adults": {Conditions: `[{"attribute":"age","operator":"greaterThanOrEquals","value":18}]`},
+		},
+		Features: map[FeatureKey]Feature{
+			"test": {
+				BucketBy: "userId",
+				Traffic:  []Traffic{{Key: "1", Segments: "adults", Percentage: 100000}},
+			},
+		},
+	}
+
+	warnings := []LogDetails{}
+	handler := LogHandler(func(level LogLevel, message LogMessage, details LogDetails) {
+		if level == LogLevelWarn || level == LogLevelError {
+			warnings = append(warnings, details)
+		}
+	})
+	level := LogLevelWarn
+
+	instance := CreateInstance(Options{
+		Datafile:   datafile,
+		Attributes: newTestAttributes(t, attributesTestJSON),
+		Logger:     NewLogger(CreateLoggerOptions{Level: &level, Handler: &handler}),
+	})
+
+	// coerced from string
+	if !instance.IsEnabled("test", Context{"userId": "1", "age": "42"}) {
+		t.Error("expected feature to be enabled with age coerced to integer")
+	}
+
+	// unknown attribute is warned once
+	instance.IsEnabled("test", Context{"userId": "1", "agee": 42})
+	instance.IsEnabled("test", Context{"userId": "1", "agee": 42})
+	if len(warnings) != 1 || warnings[0]["attribute"] != "agee" {
+		t.Errorf("expected one warning for unknown attribute, got %v", warnings)
+	}
+
+	strictInstance := CreateInstance(Options{
+		Datafile:         datafile,
+		Attributes:       newTestAttributes(t, attributesTestJSON),
+		StrictAttributes: true,
+		LogLevel:         &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	strictInstance.SetContext(Context{"userId": "1", "age": 42, "countyr": "nl"})
+	if _, exists := strictInstance.GetContext(nil)["countyr"]; exists {
+		t.Error("expected unknown attribute to be rejected in strict mode")
+	}
+	if !strictInstance.IsEnabled("test") {
+		t.Error("expected feature to be enabled with valid attributes kept")
+	}
+
+	child := strictInstance.Spawn(Context{"age": "ten"})
+	if child.GetContext(nil)["age"] != 42 {
+		t.Errorf("expected mistyped child attribute to be rejected, got %v", child.GetContext(nil)["age"])
+	}
+}
@@ -0,0 +1,98 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// expected values are what JavaScript's String(value) gives, as used when joining bucket keys
+func TestToStringParity(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    interface{}
+		expected string
+	}{
+		{"integral float", float64(100), "100"},
+		{"fraction", 1.5, "1.5"},
+		{"other fraction", 2.4, "2.4"},
+		{"negative fraction", -0.25, "-0.25"},
+		{"full precision", 0.30000000000000004, "0.30000000000000004"},
+		{"max safe integer", float64(9007199254740991), "9007199254740991"},
+		{"large integral float", 123456789012345680000.0, "123456789012345680000"},
+		{"exponent from 1e21", 1e21, "1e+21"},
+		{"exponent with fraction", 1.5e300, "1.5e+300"},
+		{"small decimal", 0.000001, "0.000001"},
+		{"exponent below 1e-6", 1e-7, "1e-7"},
+		{"negative small exponent", -1.5e-10, "-1.5e-10"},
+		{"negative zero", math.Copysign(0, -1), "0"},
+		{"NaN", math.NaN(), "NaN"},
+		{"infinity", math.Inf(1), "Infinity"},
+		{"negative infinity", math.Inf(-1), "-Infinity"},
+		{"float32", float32(1.1), "1.1"},
+		{"json number", json.Number("1.50"), "1.5"},
+		{"int64", int64(-42), "-42"},
+		{"uint8", uint8(7), "7"},
+		{"true", true, "true"},
+		{"false", false, "false"},
+		{"array", []interface{}{1.5, "a", true}, "1.5,a,true"},
+		{"nested array", []interface{}{float64(1), []interface{}{float64(2), float64(3)}}, "1,2,3"},
+		{"array with nil", []interface{}{"a", nil, "b"}, "a,,b"},
+		{"string slice", []string{"x", "y"}, "x,y"},
+		{"empty array", []interface{}{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := toString(tt.input); result != tt.expected {
+				t.Errorf("toString(%v) = %q; want %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToStringObjects(t *testing.T) {
+	first := map[string]interface{}{"b": 2.5, "a": []interface{}{"x", true}, "c": map[string]interface{}{"z": nil}}
+	second := Context{"c": map[string]interface{}{"z": nil}, "a": []string{"x"}, "b": 2.5}
+
+	if result := toString(first); result != `{"a":["x",true],"b":2.5,"c":{"z":null}}` {
+		t.Errorf("unexpected object string %q", result)
+	}
+
+	if toString(first) == toString(second) {
+		t.Error("expected different objects to have different strings")
+	}
+}
+
+func TestGetBucketKeyParity(t *testing.T) {
+	logger := NewLogger(CreateLoggerOptions{Level: &[]LogLevel{LogLevelFatal}[0]})
+
+	tests := []struct {
+		name     string
+		bucketBy BucketBy
+		context  Context
+		expected string
+	}{
+		{"fractional values do not collide", "score", Context{"score": 1.5}, "1.5.feature"},
+		{"other fractional value", "score", Context{"score": 2.4}, "2.4.feature"},
+		{"and with numbers and booleans", []string{"userId", "beta"}, Context{"userId": float64(123), "beta": true}, "123.true.feature"},
+		{"array", "teams", Context{"teams": []interface{}{"a", "b"}}, "a,b.feature"},
+		{"nested attribute", "user.id", Context{"user": map[string]interface{}{"id": 0.5}}, "0.5.feature"},
+		{"or with first available", OrBucketBy{Or: []string{"userId", "deviceId"}}, Context{"deviceId": 1e21}, "1e+21.feature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucketKey := GetBucketKey(GetBucketKeyOptions{
+				FeatureKey: "feature",
+				BucketBy:   tt.bucketBy,
+				Context:    tt.context,
+				Logger:     logger,
+			})
+
+			if bucketKey != tt.expected {
+				t.Errorf("GetBucketKey() = %q; want %q", bucketKey, tt.expected)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package featurevisor
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 )
 
@@ -36,6 +39,62 @@ func GetBucketedNumber(bucketKey string) BucketValue {
 	return int(ratio * float64(MAX_BUCKETED_NUMBER))
 }
 
+// GetAllocationFromWeights converts variation weights, in percentages, into allocation ranges
+// spread over the rule's percentage of the 0-100000 bucket space.
+//
+// Variations are allocated in their order in the feature, skipping those without a positive weight.
+// Weights keep their full precision, and are scaled to 100% if they add up to something else.
+// Range boundaries are rounded half up like JavaScript's Math.round, and each range starts where the previous one ends.
+func GetAllocationFromWeights(variations []Variation, weights map[string]Weight, percentage Percentage) []Allocation {
+	totalWeight := 0.0
+	for _, variation := range variations {
+		if weight := weights[variation.Value]; weight > 0 {
+			totalWeight += weight
+		}
+	}
+
+	allocation := []Allocation{}
+	if totalWeight <= 0 || math.IsInf(totalWeight, 0) {
+		return allocation
+	}
+
+	cumulativeWeight := 0.0
+	start := 0
+
+	for _, variation := range variations {
+		weight := weights[variation.Value]
+		if !(weight > 0) {
+			continue
+		}
+
+		cumulativeWeight += weight
+		end := int(math.Floor(cumulativeWeight*float64(percentage)/totalWeight + 0.5))
+
+		allocation = append(allocation, Allocation{
+			Variation: variation.Value,
+			Range:     Range{start, end},
+		})
+
+		start = end
+	}
+
+	return allocation
+}
+
+// getWeightedTraffic returns traffic with its allocation from variation weights, if it has weights but no allocation.
+//
+// Allocation written by the builder is used as it is, since it can keep ranges of earlier revisions.
+func getWeightedTraffic(traffic *Traffic, variations []Variation) *Traffic {
+	if len(traffic.VariationWeights) == 0 || len(traffic.Allocation) > 0 {
+		return traffic
+	}
+
+	weightedTraffic := *traffic
+	weightedTraffic.Allocation = GetAllocationFromWeights(variations, traffic.VariationWeights, traffic.Percentage)
+
+	return &weightedTraffic
+}
+
 // GetBucketKey returns a bucket key based on the feature key, bucket by configuration, and context
 func GetBucketKey(options GetBucketKeyOptions) BucketKey {
 	featureKey := options.FeatureKey
@@ -43,53 +102,8 @@ func GetBucketKey(options GetBucketKeyOptions) BucketKey {
 	context := options.Context
 	logger := options.Logger
 
-	var bucketType string
-	var attributeKeys []string
-
-	// Determine bucket type and extract attribute keys
-	switch b := bucketBy.(type) {
-	case string:
-		bucketType = "plain"
-		attributeKeys = []string{b}
-	case []string:
-		bucketType = "and"
-		attributeKeys = b
-	case OrBucketBy:
-		bucketType = "or"
-		attributeKeys = b.Or
-	case map[string]interface{}:
-		// Handle JSON unmarshaled bucketBy
-		if orValue, exists := b["or"]; exists {
-			bucketType = "or"
-			if orArray, ok := orValue.([]string); ok {
-				attributeKeys = orArray
-			} else if orArray, ok := orValue.([]interface{}); ok {
-				attributeKeys = make([]string, len(orArray))
-				for i, v := range orArray {
-					if str, ok := v.(string); ok {
-						attributeKeys[i] = str
-					}
-				}
-			}
-		} else {
-			// This is a plain string case that was unmarshaled as map
-			bucketType = "plain"
-			// Try to extract the single key
-			for key := range b {
-				attributeKeys = []string{key}
-				break
-			}
-		}
-	case []interface{}:
-		// Handle JSON unmarshaled array
-		bucketType = "and"
-		attributeKeys = make([]string, len(b))
-		for i, v := range b {
-			if str, ok := v.(string); ok {
-				attributeKeys[i] = str
-			}
-		}
-	default:
+	bucketType, attributeKeys, ok := parseBucketBy(bucketBy)
+	if !ok {
 		logger.Error("invalid bucketBy", LogDetails{
 			"featureKey": featureKey,
 			"bucketBy":   bucketBy,
@@ -131,22 +145,46 @@ func GetBucketKey(options GetBucketKeyOptions) BucketKey {
 	return result
 }
 
-// toString converts a value to string representation
+// toString converts a value to a string for bucket keys, the way JavaScript does when joining them:
+// numbers with full precision, arrays as their items joined with commas, and nil as an empty string.
+//
+// Objects are encoded as JSON with sorted keys, instead of "[object Object]" for all of them in JavaScript.
 func toString(value interface{}) string {
 	switch v := value.(type) {
+	case nil:
+		return ""
 	case string:
 		return v
-	case int:
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
 		return fmt.Sprintf("%d", v)
+	case float32:
+		// shortest digits of the float32, as it would be written in JSON
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+		return formatNumber(f)
 	case float64:
-		return fmt.Sprintf("%.0f", v)
-	case bool:
-		if v {
-			return "true"
+		return formatNumber(v)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return formatNumber(f)
+		}
+		return v.String()
+	}
+
+	if items, ok := toSlice(value); ok {
+		itemStrings := make([]string, len(items))
+		for i, item := range items {
+			itemStrings[i] = toString(item)
 		}
-		return "false"
-	default:
-		// For other types, try to convert to string
-		return fmt.Sprintf("%v", v)
+		return strings.Join(itemStrings, ",")
 	}
+
+	if object, ok := toMap(value); ok {
+		if bytes, err := json.Marshal(object); err == nil {
+			return string(bytes)
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
 }
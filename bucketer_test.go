@@ -1,6 +1,7 @@
 package featurevisor
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -239,7 +240,7 @@ func TestToString(t *testing.T) {
 		{
 			name:     "float64",
 			input:    123.456,
-			expected: "123",
+			expected: "123.456",
 		},
 		{
 			name:     "bool true",
@@ -254,7 +255,7 @@ func TestToString(t *testing.T) {
 		{
 			name:     "nil",
 			input:    nil,
-			expected: "<nil>",
+			expected: "",
 		},
 	}
 
@@ -267,3 +268,84 @@ func TestToString(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAllocationFromWeights(t *testing.T) {
+	variations := []Variation{{Value: "a"}, {Value: "b"}, {Value: "c"}}
+
+	tests := []struct {
+		name       string
+		weights    map[string]Weight
+		percentage Percentage
+		expected   []Allocation
+	}{
+		{
+			name:       "fractional weights",
+			weights:    map[string]Weight{"a": 33.34, "b": 33.33, "c": 33.33},
+			percentage: 100000,
+			expected: []Allocation{
+				{Variation: "a", Range: Range{0, 33340}},
+				{Variation: "b", Range: Range{33340, 66670}},
+				{Variation: "c", Range: Range{66670, 100000}},
+			},
+		},
+		{
+			name:       "weights below 1",
+			weights:    map[string]Weight{"a": 0.5, "b": 99.5},
+			percentage: 100000,
+			expected: []Allocation{
+				{Variation: "a", Range: Range{0, 500}},
+				{Variation: "b", Range: Range{500, 100000}},
+			},
+		},
+		{
+			name:       "rounded half up",
+			weights:    map[string]Weight{"a": 12.3455, "c": 87.6545},
+			percentage: 100000,
+			expected: []Allocation{
+				{Variation: "a", Range: Range{0, 12346}},
+				{Variation: "c", Range: Range{12346, 100000}},
+			},
+		},
+		{
+			name:       "partial rollout",
+			weights:    map[string]Weight{"a": 50, "b": 50},
+			percentage: 50000,
+			expected: []Allocation{
+				{Variation: "a", Range: Range{0, 25000}},
+				{Variation: "b", Range: Range{25000, 50000}},
+			},
+		},
+		{
+			name:       "not adding up to 100",
+			weights:    map[string]Weight{"a": 1, "b": 3},
+			percentage: 100000,
+			expected: []Allocation{
+				{Variation: "a", Range: Range{0, 25000}},
+				{Variation: "b", Range: Range{25000, 100000}},
+			},
+		},
+		{
+			name:       "unknown and non-positive weights",
+			weights:    map[string]Weight{"a": 0, "b": -10, "x": 50, "c": 100},
+			percentage: 100000,
+			expected: []Allocation{
+				{Variation: "c", Range: Range{0, 100000}},
+			},
+		},
+		{
+			name:       "no weights",
+			weights:    map[string]Weight{},
+			percentage: 100000,
+			expected:   []Allocation{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := GetAllocationFromWeights(variations, tt.weights, tt.percentage)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package featurevisor
+
+// CatalogVariation describes a variation of a feature
+type CatalogVariation struct {
+	Value       VariationValue `json:"value"`
+	Description string         `json:"description,omitempty"`
+	Weight      *Weight        `json:"weight,omitempty"`
+}
+
+// CatalogVariable describes the schema of a variable
+type CatalogVariable struct {
+	Key          VariableKey   `json:"key"`
+	Type         VariableType  `json:"type"`
+	DefaultValue VariableValue `json:"defaultValue"`
+	Deprecated   bool          `json:"deprecated,omitempty"`
+	Description  string        `json:"description,omitempty"`
+}
+
+// CatalogRequired describes a feature required by another, optionally with a variation
+type CatalogRequired struct {
+	FeatureKey FeatureKey      `json:"featureKey"`
+	Variation  *VariationValue `json:"variation,omitempty"`
+}
+
+// CatalogFeature describes a feature without its targeting details
+type CatalogFeature struct {
+	Key         FeatureKey         `json:"key"`
+	Deprecated  bool               `json:"deprecated,omitempty"`
+	BucketBy    BucketBy           `json:"bucketBy"`
+	Variations  []CatalogVariation `json:"variations"`
+	Variables   []CatalogVariable  `json:"variables"` // sorted by key
+	Required    []CatalogRequired  `json:"required"`
+	RuleKeys    []RuleKey          `json:"ruleKeys"`
+	SegmentKeys []SegmentKey       `json:"segmentKeys"` // segments used in rules, force entries and variable overrides, sorted
+}
+
+// Catalog describes all features and segments of a datafile
+type Catalog struct {
+	SchemaVersion string           `json:"schemaVersion"`
+	Revision      string           `json:"revision"`
+	Features      []CatalogFeature `json:"features"`    // sorted by key
+	SegmentKeys   []SegmentKey     `json:"segmentKeys"` // sorted
+}
+
+// GetFeature returns a feature from the catalog, or nil if not found
+func (c *Catalog) GetFeature(featureKey FeatureKey) *CatalogFeature {
+	for i := range c.Features {
+		if c.Features[i].Key == featureKey {
+			return &c.Features[i]
+		}
+	}
+	return nil
+}
+
+// GetCatalog describes all features and segments of the datafile
+func (d *DatafileReader) GetCatalog() Catalog {
+	graph := d.DependencyGraph()
+
+	catalog := Catalog{
+		SchemaVersion: d.schemaVersion,
+		Revision:      d.revision,
+		Features:      make([]CatalogFeature, 0, len(graph.Features)),
+		SegmentKeys:   graph.Segments,
+	}
+
+	for _, featureKey := range graph.Features {
+		catalog.Features = append(catalog.Features, d.getCatalogFeature(featureKey, graph))
+	}
+
+	return catalog
+}
+
+// getCatalogFeature describes a feature, with segment keys taken from the dependency graph
+func (d *DatafileReader) getCatalogFeature(featureKey FeatureKey, graph *DependencyGraph) CatalogFeature {
+	feature := d.GetFeature(featureKey)
+
+	catalogFeature := CatalogFeature{
+		Key:         featureKey,
+		Deprecated:  feature.Deprecated != nil && *feature.Deprecated,
+		BucketBy:    feature.BucketBy,
+		Variations:  make([]CatalogVariation, 0, len(feature.Variations)),
+		Variables:   make([]CatalogVariable, 0, len(feature.VariablesSchema)),
+		Required:    make([]CatalogRequired, 0, len(feature.Required)),
+		RuleKeys:    make([]RuleKey, 0, len(feature.Traffic)),
+		SegmentKeys: graph.GetSegments(featureKey),
+	}
+
+	for _, variation := range feature.Variations {
+		catalogVariation := CatalogVariation{
+			Value:  variation.Value,
+			Weight: variation.Weight,
+		}
+		if variation.Description != nil {
+			catalogVariation.Description = *variation.Description
+		}
+		catalogFeature.Variations = append(catalogFeature.Variations, catalogVariation)
+	}
+
+	for _, variableKey := range sortedKeys(feature.VariablesSchema) {
+		variableSchema := feature.VariablesSchema[variableKey]
+
+		catalogVariable := CatalogVariable{
+			Key:          variableKey,
+			Type:         variableSchema.Type,
+			DefaultValue: variableSchema.DefaultValue,
+			Deprecated:   variableSchema.Deprecated != nil && *variableSchema.Deprecated,
+		}
+		if variableSchema.Description != nil {
+			catalogVariable.Description = *variableSchema.Description
+		}
+		catalogFeature.Variables = append(catalogFeature.Variables, catalogVariable)
+	}
+
+	for _, edge := range graph.GetRequired(featureKey) {
+		catalogFeature.Required = append(catalogFeature.Required, CatalogRequired{
+			FeatureKey: edge.RequiredKey,
+			Variation:  edge.Variation,
+		})
+	}
+
+	for _, traffic := range feature.Traffic {
+		catalogFeature.RuleKeys = append(catalogFeature.RuleKeys, traffic.Key)
+	}
+
+	return catalogFeature
+}
+
+// GetCatalogFeature describes a feature, or returns nil if not found
+func (d *DatafileReader) GetCatalogFeature(featureKey FeatureKey) *CatalogFeature {
+	if d.GetFeature(featureKey) == nil {
+		return nil
+	}
+
+	catalogFeature := d.getCatalogFeature(featureKey, d.DependencyGraph())
+	return &catalogFeature
+}
+
+// GetCatalog describes all features and segments of the current datafile
+func (i *Featurevisor) GetCatalog() Catalog {
+	return i.datafileReader.GetCatalog()
+}
+
+// GetCatalogFeature describes a feature of the current datafile, or returns nil if not found
+func (i *Featurevisor) GetCatalogFeature(featureKey string) *CatalogFeature {
+	return i.datafileReader.GetCatalogFeature(FeatureKey(featureKey))
+}
+
+// GetFeatureKeys returns keys of all features, sorted
+func (i *Featurevisor) GetFeatureKeys() []FeatureKey {
+	return sortedKeys(i.datafileReader.features)
+}
+
+// GetVariableKeys returns keys of all variables of a feature, sorted
+func (i *Featurevisor) GetVariableKeys(featureKey string) []VariableKey {
+	feature := i.datafileReader.GetFeature(FeatureKey(featureKey))
+	if feature == nil {
+		return []VariableKey{}
+	}
+
+	return sortedKeys(feature.VariablesSchema)
+}
+
+// GetSegmentKeys returns keys of all segments, sorted
+func (i *Featurevisor) GetSegmentKeys() []SegmentKey {
+	return i.datafileReader.GetSegmentKeys()
+}
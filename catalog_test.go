@@ -0,0 +1,135 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var catalogTestDatafile = testDatafile{
+	revision: "5",
+	segments: `{
+		"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]},
+		"qa": {"conditions": [{"attribute": "qa", "operator": "equals", "value": true}]}
+	}`,
+	features: `{
+		"base": {
+			"bucketBy": "userId",
+			"deprecated": true,
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"checkout": {
+			"bucketBy": ["userId", "deviceId"],
+			"required": [{"key": "base", "variation": "on"}],
+			"force": [{"segments": ["qa"], "enabled": true}],
+			"variablesSchema": {
+				"title": {"type": "string", "defaultValue": "Checkout", "description": "Page title"},
+				"limit": {"type": "integer", "defaultValue": 3, "deprecated": true}
+			},
+			"variations": [
+				{"value": "control", "weight": 50},
+				{"value": "treatment", "weight": 50, "description": "New flow"}
+			],
+			"traffic": [
+				{"key": "nl", "segments": "netherlands", "percentage": 100000},
+				{"key": "everyone", "segments": "*", "percentage": 0}
+			]
+		}
+	}`,
+}
+
+func TestGetCatalog(t *testing.T) {
+	instance := newTestInstance(t, catalogTestDatafile, Options{})
+
+	catalog := instance.GetCatalog()
+
+	if catalog.SchemaVersion != "2" || catalog.Revision != "5" {
+		t.Errorf("unexpected datafile details %q %q", catalog.SchemaVersion, catalog.Revision)
+	}
+	if !reflect.DeepEqual(catalog.SegmentKeys, []SegmentKey{"netherlands", "qa"}) {
+		t.Errorf("unexpected segment keys %v", catalog.SegmentKeys)
+	}
+	if len(catalog.Features) != 2 || catalog.Features[0].Key != "base" || catalog.Features[1].Key != "checkout" {
+		t.Fatalf("expected features sorted by key, got %+v", catalog.Features)
+	}
+
+	base := catalog.GetFeature("base")
+	if base == nil || !base.Deprecated || base.BucketBy != "userId" {
+		t.Errorf("unexpected base feature %+v", base)
+	}
+
+	checkout := catalog.GetFeature("checkout")
+	if checkout == nil {
+		t.Fatal("expected checkout feature in catalog")
+	}
+	if checkout.Deprecated {
+		t.Error("expected checkout not to be deprecated")
+	}
+	if bucketBy, ok := checkout.BucketBy.([]interface{}); !ok || len(bucketBy) != 2 {
+		t.Errorf("unexpected bucketBy %v", checkout.BucketBy)
+	}
+
+	if len(checkout.Variations) != 2 || checkout.Variations[1].Value != "treatment" || checkout.Variations[1].Description != "New flow" {
+		t.Errorf("unexpected variations %+v", checkout.Variations)
+	}
+	if checkout.Variations[0].Weight == nil || *checkout.Variations[0].Weight != 50 {
+		t.Errorf("expected weight of control, got %+v", checkout.Variations[0])
+	}
+
+	if len(checkout.Variables) != 2 {
+		t.Fatalf("unexpected variables %+v", checkout.Variables)
+	}
+	limit, title := checkout.Variables[0], checkout.Variables[1]
+	if limit.Key != "limit" || limit.Type != "integer" || !limit.Deprecated {
+		t.Errorf("unexpected limit variable %+v", limit)
+	}
+	if title.Key != "title" || title.DefaultValue != "Checkout" || title.Description != "Page title" || title.Deprecated {
+		t.Errorf("unexpected title variable %+v", title)
+	}
+
+	if len(checkout.Required) != 1 || checkout.Required[0].FeatureKey != "base" || checkout.Required[0].Variation == nil || *checkout.Required[0].Variation != "on" {
+		t.Errorf("unexpected required %+v", checkout.Required)
+	}
+	if !reflect.DeepEqual(checkout.RuleKeys, []RuleKey{"nl", "everyone"}) {
+		t.Errorf("unexpected rule keys %v", checkout.RuleKeys)
+	}
+	if !reflect.DeepEqual(checkout.SegmentKeys, []SegmentKey{"netherlands", "qa"}) {
+		t.Errorf("unexpected segment keys %v", checkout.SegmentKeys)
+	}
+
+	if catalog.GetFeature("unknown") != nil {
+		t.Error("expected nil for unknown feature")
+	}
+
+	if _, err := json.Marshal(catalog); err != nil {
+		t.Errorf("failed to serialize catalog: %v", err)
+	}
+}
+
+func TestGetCatalogFeature(t *testing.T) {
+	instance := newTestInstance(t, catalogTestDatafile, Options{})
+
+	if feature := instance.GetCatalogFeature("checkout"); feature == nil || len(feature.Variables) != 2 {
+		t.Errorf("unexpected checkout feature %+v", feature)
+	}
+	if feature := instance.GetCatalogFeature("unknown"); feature != nil {
+		t.Errorf("expected nil for unknown feature, got %+v", feature)
+	}
+}
+
+func TestGetFeatureAndVariableKeys(t *testing.T) {
+	instance := newTestInstance(t, catalogTestDatafile, Options{})
+
+	if keys := instance.GetFeatureKeys(); !reflect.DeepEqual(keys, []FeatureKey{"base", "checkout"}) {
+		t.Errorf("unexpected feature keys %v", keys)
+	}
+	if keys := instance.GetVariableKeys("checkout"); !reflect.DeepEqual(keys, []VariableKey{"limit", "title"}) {
+		t.Errorf("unexpected variable keys %v", keys)
+	}
+	if keys := instance.GetVariableKeys("unknown"); len(keys) != 0 {
+		t.Errorf("expected no variable keys for unknown feature, got %v", keys)
+	}
+	if keys := instance.GetSegmentKeys(); !reflect.DeepEqual(keys, []SegmentKey{"netherlands", "qa"}) {
+		t.Errorf("unexpected segment keys %v", keys)
+	}
+}
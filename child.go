@@ -1,29 +1,42 @@
 package featurevisor
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 // ChildOptions contains options for creating a child instance
 type ChildOptions struct {
-	Parent  *Featurevisor
-	Context Context
-	Sticky  *StickyFeatures
+	Parent      *Featurevisor
+	Context     Context
+	Sticky      *StickyFeatures
+	StickyStore *StickyStoreOptions
 }
 
 // FeaturevisorChild represents a child Featurevisor instance
 type FeaturevisorChild struct {
-	parent  *Featurevisor
-	context Context
-	sticky  *StickyFeatures
-	emitter *Emitter
+	parent      *Featurevisor
+	context     Context
+	sticky      *StickyFeatures
+	stickyStore *StickyStoreOptions
+	emitter     *Emitter
+
+	// listeners added to the parent on behalf of the child, removed on Close
+	parentListeners      map[int]Unsubscribe
+	nextParentListenerID int
+	parentListenersMu    sync.Mutex
 }
 
 // NewFeaturevisorChild creates a new child instance
 func NewFeaturevisorChild(options ChildOptions) *FeaturevisorChild {
 	return &FeaturevisorChild{
-		parent:  options.Parent,
-		context: options.Context,
-		sticky:  options.Sticky,
-		emitter: NewEmitter(),
+		parent:      options.Parent,
+		context:     options.Context,
+		sticky:      options.Sticky,
+		stickyStore: options.StickyStore,
+		emitter:     NewEmitter(),
+
+		parentListeners: map[int]Unsubscribe{},
 	}
 }
 
@@ -38,11 +51,46 @@ func (c *FeaturevisorChild) On(eventName EventName, callback EventCallback) Unsu
 
 // Close closes child instance listeners
 func (c *FeaturevisorChild) Close() {
+	c.parentListenersMu.Lock()
+	parentListeners := c.parentListeners
+	c.parentListeners = map[int]Unsubscribe{}
+	c.parentListenersMu.Unlock()
+
+	for _, unsubscribe := range parentListeners {
+		unsubscribe()
+	}
+
 	c.emitter.ClearAll()
 }
 
-// SetContext sets the context
-func (c *FeaturevisorChild) SetContext(context Context, replace ...bool) {
+// onParent adds a listener to the parent, which is removed when unsubscribing or closing the child
+func (c *FeaturevisorChild) onParent(eventName EventName, callback EventCallback) Unsubscribe {
+	unsubscribe := c.parent.On(eventName, callback)
+
+	c.parentListenersMu.Lock()
+	id := c.nextParentListenerID
+	c.nextParentListenerID++
+	c.parentListeners[id] = unsubscribe
+	c.parentListenersMu.Unlock()
+
+	return func() {
+		c.parentListenersMu.Lock()
+		delete(c.parentListeners, id)
+		c.parentListenersMu.Unlock()
+
+		unsubscribe()
+	}
+}
+
+// SetContext sets the context, given as Context, a map or a tagged struct (see ContextFrom)
+func (c *FeaturevisorChild) SetContext(value interface{}, replace ...bool) {
+	context, err := ContextFrom(value)
+	if err != nil {
+		c.parent.logger.Error("could not set context", LogDetails{"error": err})
+		return
+	}
+	context = c.parent.validateContext(context)
+
 	replaceValue := false
 	if len(replace) > 0 {
 		replaceValue = replace[0]
@@ -128,129 +176,146 @@ func (c *FeaturevisorChild) getEvaluationDependencies(context Context, options O
 		sticky = c.sticky
 	}
 
+	stickyStore := c.parent.stickyStore
+	if options.StickyStore != nil {
+		stickyStore = options.StickyStore
+	} else if c.stickyStore != nil {
+		stickyStore = c.stickyStore
+	}
+
 	return EvaluateDependencies{
-		Context:               c.GetContext(context),
+		Context:               c.GetContext(c.parent.validateContext(context)),
 		Logger:                c.parent.logger,
 		HooksManager:          c.parent.hooksManager,
 		DatafileReader:        c.parent.datafileReader,
+		Overrides:             c.parent.overridesManager,
 		Sticky:                sticky,
+		StickyStore:           stickyStore,
 		DefaultVariationValue: options.DefaultVariationValue,
 		DefaultVariableValue:  options.DefaultVariableValue,
+		MissingBucketBy:       c.parent.missingBucketBy,
+		Strict:                c.parent.strict,
+		notReady:              !c.parent.ready,
 	}
 }
 
 // EvaluateFlag evaluates a feature flag
-func (c *FeaturevisorChild) EvaluateFlag(featureKey string, context Context, options OverrideOptions) Evaluation {
+func (c *FeaturevisorChild) EvaluateFlag(featureKey string, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:       EvaluationTypeFlag,
 			FeatureKey: FeatureKey(featureKey),
 		},
-		EvaluateDependencies: c.getEvaluationDependencies(context, options),
+		EvaluateDependencies: c.getEvaluationDependencies(toContext(context, c.parent.logger), options),
 	})
 }
 
 // IsEnabled checks if a feature is enabled
 func (c *FeaturevisorChild) IsEnabled(featureKey string, args ...interface{}) bool {
+	enabled, _ := c.IsEnabledE(featureKey, args...)
+	return enabled
+}
+
+// IsEnabledE checks if a feature is enabled, with an error if it could not be evaluated
+func (c *FeaturevisorChild) IsEnabledE(featureKey string, args ...interface{}) (enabled bool, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			c.parent.logger.Error("isEnabled", LogDetails{
 				"featureKey": featureKey,
 				"error":      r,
 			})
+			enabled, err = false, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, c.parent.logger)
 
 	evaluation := c.EvaluateFlag(featureKey, contextValue, optionsValue)
+	err = getEvaluationError(evaluation, c.parent.ready)
+	if argsErr != nil {
+		err = argsErr
+	}
 
 	if evaluation.Enabled != nil {
-		return *evaluation.Enabled
+		return *evaluation.Enabled, err
 	}
 
-	return false
+	return false, err
 }
 
 // EvaluateVariation evaluates a feature variation
-func (c *FeaturevisorChild) EvaluateVariation(featureKey string, context Context, options OverrideOptions) Evaluation {
+func (c *FeaturevisorChild) EvaluateVariation(featureKey string, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:       EvaluationTypeVariation,
 			FeatureKey: FeatureKey(featureKey),
 		},
-		EvaluateDependencies: c.getEvaluationDependencies(context, options),
+		EvaluateDependencies: c.getEvaluationDependencies(toContext(context, c.parent.logger), options),
 	})
 }
 
 // GetVariation gets a feature variation
 func (c *FeaturevisorChild) GetVariation(featureKey string, args ...interface{}) *string {
+	variation, _ := c.GetVariationE(featureKey, args...)
+	return variation
+}
+
+// GetVariationE gets a feature variation, with an error if it could not be evaluated
+func (c *FeaturevisorChild) GetVariationE(featureKey string, args ...interface{}) (variation *string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			c.parent.logger.Error("getVariation", LogDetails{
 				"featureKey": featureKey,
 				"error":      r,
 			})
+			variation, err = nil, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, c.parent.logger)
 
 	evaluation := c.EvaluateVariation(featureKey, contextValue, optionsValue)
+	err = getEvaluationError(evaluation, c.parent.ready)
+	if argsErr != nil {
+		err = argsErr
+	}
 
 	if evaluation.VariationValue != nil {
 		// VariationValue is already a string type alias
 		variationValue := string(*evaluation.VariationValue)
-		return &variationValue
+		return &variationValue, err
 	}
 
 	if evaluation.Variation != nil {
 		// Variation.Value is already a VariationValue (string)
 		variationValue := string(evaluation.Variation.Value)
-		return &variationValue
+		return &variationValue, err
 	}
 
-	return nil
+	return nil, err
 }
 
 // EvaluateVariable evaluates a feature variable
-func (c *FeaturevisorChild) EvaluateVariable(featureKey string, variableKey VariableKey, context Context, options OverrideOptions) Evaluation {
+func (c *FeaturevisorChild) EvaluateVariable(featureKey string, variableKey VariableKey, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:        EvaluationTypeVariable,
 			FeatureKey:  FeatureKey(featureKey),
 			VariableKey: &variableKey,
 		},
-		EvaluateDependencies: c.getEvaluationDependencies(context, options),
+		EvaluateDependencies: c.getEvaluationDependencies(toContext(context, c.parent.logger), options),
 	})
 }
 
 // GetVariable gets a feature variable
 func (c *FeaturevisorChild) GetVariable(featureKey string, variableKey string, args ...interface{}) VariableValue {
+	value, _ := c.GetVariableE(featureKey, variableKey, args...)
+	return value
+}
+
+// GetVariableE gets a feature variable, with an error if it could not be evaluated.
+// The value is the same as GetVariable returns, like the default value for invalid ones.
+func (c *FeaturevisorChild) GetVariableE(featureKey string, variableKey string, args ...interface{}) (value VariableValue, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			c.parent.logger.Error("getVariable", LogDetails{
@@ -258,115 +323,91 @@ func (c *FeaturevisorChild) GetVariable(featureKey string, variableKey string, a
 				"variableKey": variableKey,
 				"error":       r,
 			})
+			value, err = nil, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, c.parent.logger)
 
 	evaluation := c.EvaluateVariable(featureKey, VariableKey(variableKey), contextValue, optionsValue)
-
-	if evaluation.VariableValue != nil {
-		return evaluation.VariableValue
+	err = getEvaluationError(evaluation, c.parent.ready)
+	if argsErr != nil {
+		err = argsErr
 	}
 
-	return nil
+	return toVariableValue(evaluation, err, variableKey, c.parent.logger)
 }
 
 // GetVariableBoolean gets a boolean variable
 func (c *FeaturevisorChild) GetVariableBoolean(featureKey string, variableKey string, args ...interface{}) *bool {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "boolean")
-	if boolValue, ok := typedValue.(bool); ok {
-		return &boolValue
-	}
+	value, _ := c.GetVariableBooleanE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableBooleanE gets a boolean variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableBooleanE(featureKey string, variableKey string, args ...interface{}) (*bool, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[bool](value, err, variableKey, VariableTypeBoolean)
 }
 
 // GetVariableString gets a string variable
 func (c *FeaturevisorChild) GetVariableString(featureKey string, variableKey string, args ...interface{}) *string {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "string")
-	if stringValue, ok := typedValue.(string); ok {
-		return &stringValue
-	}
+	value, _ := c.GetVariableStringE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableStringE gets a string variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableStringE(featureKey string, variableKey string, args ...interface{}) (*string, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[string](value, err, variableKey, VariableTypeString)
 }
 
 // GetVariableInteger gets an integer variable
 func (c *FeaturevisorChild) GetVariableInteger(featureKey string, variableKey string, args ...interface{}) *int {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "integer")
-	if intValue, ok := typedValue.(int); ok {
-		return &intValue
-	}
+	value, _ := c.GetVariableIntegerE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableIntegerE gets an integer variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableIntegerE(featureKey string, variableKey string, args ...interface{}) (*int, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[int](value, err, variableKey, VariableTypeInteger)
 }
 
 // GetVariableDouble gets a double variable
 func (c *FeaturevisorChild) GetVariableDouble(featureKey string, variableKey string, args ...interface{}) *float64 {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "double")
-	if floatValue, ok := typedValue.(float64); ok {
-		return &floatValue
-	}
+	value, _ := c.GetVariableDoubleE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableDoubleE gets a double variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableDoubleE(featureKey string, variableKey string, args ...interface{}) (*float64, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[float64](value, err, variableKey, VariableTypeDouble)
 }
 
 // GetVariableArray gets an array variable
 func (c *FeaturevisorChild) GetVariableArray(featureKey string, variableKey string, args ...interface{}) []string {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
+	value, _ := c.GetVariableArrayE(featureKey, variableKey, args...)
+	return value
+}
 
-	return ToTypedArray[string](GetValueByType(value, "array"))
+// GetVariableArrayE gets an array variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableArrayE(featureKey string, variableKey string, args ...interface{}) ([]string, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toArrayVariable(value, err, variableKey)
 }
 
 // GetVariableObject gets an object variable
 func (c *FeaturevisorChild) GetVariableObject(featureKey string, variableKey string, args ...interface{}) map[string]interface{} {
-	value := c.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := ToTypedObject[map[string]interface{}](GetValueByType(value, "object"))
-	if typedValue == nil {
-		return nil
-	}
+	value, _ := c.GetVariableObjectE(featureKey, variableKey, args...)
+	return value
+}
 
-	return *typedValue
+// GetVariableObjectE gets an object variable, with an error if it could not be evaluated, or is not of the type
+func (c *FeaturevisorChild) GetVariableObjectE(featureKey string, variableKey string, args ...interface{}) (map[string]interface{}, error) {
+	value, err := c.GetVariableE(featureKey, variableKey, args...)
+	return toObjectVariable(value, err, variableKey)
 }
 
 // GetVariableJSON gets a JSON variable
@@ -379,6 +420,11 @@ func (c *FeaturevisorChild) GetVariableJSON(featureKey string, variableKey strin
 	return value
 }
 
+// GetVariableJSONE gets a JSON variable, with an error if it could not be evaluated, or parsed
+func (c *FeaturevisorChild) GetVariableJSONE(featureKey string, variableKey string, args ...interface{}) (interface{}, error) {
+	return c.GetVariableE(featureKey, variableKey, args...)
+}
+
 // GetVariableArrayInto decodes an array variable into the provided pointer output.
 // Supported argument order (after featureKey, variableKey): out OR context, out OR context, options, out.
 func (c *FeaturevisorChild) GetVariableArrayInto(featureKey string, variableKey string, args ...interface{}) error {
@@ -422,8 +468,9 @@ func (c *FeaturevisorChild) GetVariableObjectInto(featureKey string, variableKey
 }
 
 // GetAllEvaluations gets all evaluations for features
-func (c *FeaturevisorChild) GetAllEvaluations(context Context, featureKeys []string, options OverrideOptions) EvaluatedFeatures {
+func (c *FeaturevisorChild) GetAllEvaluations(context interface{}, featureKeys []string, options OverrideOptions) EvaluatedFeatures {
 	result := EvaluatedFeatures{}
+	contextValue := toContext(context, c.parent.logger)
 
 	keys := featureKeys
 	if len(keys) == 0 {
@@ -438,12 +485,12 @@ func (c *FeaturevisorChild) GetAllEvaluations(context Context, featureKeys []str
 	for _, featureKey := range keys {
 		// isEnabled
 		evaluatedFeature := EvaluatedFeature{
-			Enabled: c.IsEnabled(featureKey, context, options),
+			Enabled: c.IsEnabled(featureKey, contextValue, options),
 		}
 
 		// variation
 		if c.parent.datafileReader.HasVariations(FeatureKey(featureKey)) {
-			variation := c.GetVariation(featureKey, context, options)
+			variation := c.GetVariation(featureKey, contextValue, options)
 			if variation != nil {
 				evaluatedFeature.Variation = variation
 			}
@@ -457,7 +504,7 @@ func (c *FeaturevisorChild) GetAllEvaluations(context Context, featureKeys []str
 				evaluatedFeature.Variables[variableKey] = c.GetVariable(
 					featureKey,
 					string(variableKey),
-					context,
+					contextValue,
 					options,
 				)
 			}
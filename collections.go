@@ -0,0 +1,143 @@
+package featurevisor
+
+import (
+	"reflect"
+	"sort"
+)
+
+// lookupKey finds a key in any map with string keys, with isMap being false if value is not such a map
+func lookupKey(value interface{}, key string) (result interface{}, exists bool, isMap bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		result, exists = m[key]
+		return result, exists, true
+	case Context:
+		result, exists = m[key]
+		return result, exists, true
+	case map[string]string:
+		var s string
+		s, exists = m[key]
+		if !exists {
+			return nil, false, true
+		}
+		return s, true, true
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false, false
+	}
+
+	item := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+	if !item.IsValid() {
+		return nil, false, true
+	}
+
+	return item.Interface(), true, true
+}
+
+// toSlice converts any slice or array to []interface{}
+func toSlice(value interface{}) ([]interface{}, bool) {
+	switch s := value.(type) {
+	case []interface{}:
+		return s, true
+	case []string:
+		items := make([]interface{}, len(s))
+		for i, item := range s {
+			items[i] = item
+		}
+		return items, true
+	case nil:
+		return nil, false
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+
+	return items, true
+}
+
+// toMap converts any map with string keys to map[string]interface{}
+func toMap(value interface{}) (map[string]interface{}, bool) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, true
+	case Context:
+		return m, true
+	case nil:
+		return nil, false
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	result := make(map[string]interface{}, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		result[iter.Key().String()] = iter.Value().Interface()
+	}
+
+	return result, true
+}
+
+// collectionsEqual compares slices, arrays and maps deeply, with ok being false if either value is not a collection
+func collectionsEqual(a, b interface{}) (equal bool, ok bool) {
+	if aItems, isSlice := toSlice(a); isSlice {
+		bItems, isSlice := toSlice(b)
+		if !isSlice || len(aItems) != len(bItems) {
+			return false, true
+		}
+
+		for i := range aItems {
+			if !valuesEqual(aItems[i], bItems[i]) {
+				return false, true
+			}
+		}
+
+		return true, true
+	}
+
+	if aMap, isMap := toMap(a); isMap {
+		bMap, isMap := toMap(b)
+		if !isMap || len(aMap) != len(bMap) {
+			return false, true
+		}
+
+		for key, aValue := range aMap {
+			bValue, exists := bMap[key]
+			if !exists || !valuesEqual(aValue, bValue) {
+				return false, true
+			}
+		}
+
+		return true, true
+	}
+
+	if _, isSlice := toSlice(b); isSlice {
+		return false, true
+	}
+	if _, isMap := toMap(b); isMap {
+		return false, true
+	}
+
+	return false, false
+}
+
+// sortedKeys returns the keys of a map with string keys in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,137 @@
+package featurevisor
+
+import (
+	"testing"
+)
+
+type customAttributes map[string]string
+
+func TestGetValueFromContextWithNativeMaps(t *testing.T) {
+	context := Context{
+		"user": map[string]string{
+			"country": "nl",
+		},
+		"device": map[string]map[string]int{
+			"screen": {"width": 1920},
+		},
+		"custom": customAttributes{"plan": "pro"},
+		"ids":    map[int]string{1: "a"},
+	}
+
+	tests := []struct {
+		path     string
+		expected interface{}
+		exists   bool
+	}{
+		{"user.country", "nl", true},
+		{"user.city", nil, false},
+		{"device.screen.width", 1920, true},
+		{"custom.plan", "pro", true},
+		{"ids.1", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := GetValueFromContext(context, tt.path); got != tt.expected {
+			t.Errorf("GetValueFromContext(%q) = %v, expected %v", tt.path, got, tt.expected)
+		}
+		if got := PathExists(context, tt.path); got != tt.exists {
+			t.Errorf("PathExists(%q) = %v, expected %v", tt.path, got, tt.exists)
+		}
+	}
+}
+
+func TestConditionIsMatchedWithNativeCollections(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition PlainCondition
+		context   Context
+		expected  bool
+	}{
+		{
+			name:      "nested map[string]string with equals",
+			condition: PlainCondition{Attribute: "user.country", Operator: OperatorEquals, Value: conditionValue("nl")},
+			context:   Context{"user": map[string]string{"country": "nl"}},
+			expected:  true,
+		},
+		{
+			name:      "in with []string context",
+			condition: PlainCondition{Attribute: "tags", Operator: OperatorIn, Value: conditionValue([]interface{}{"beta"})},
+			context:   Context{"tags": []string{"alpha", "beta"}},
+			expected:  true,
+		},
+		{
+			name:      "in with []string condition value",
+			condition: PlainCondition{Attribute: "country", Operator: OperatorIn, Value: conditionValue([]string{"nl", "de"})},
+			context:   Context{"country": "de"},
+			expected:  true,
+		},
+		{
+			name:      "notIn with [2]int condition value",
+			condition: PlainCondition{Attribute: "age", Operator: OperatorNotIn, Value: conditionValue([2]int{18, 21})},
+			context:   Context{"age": 30},
+			expected:  true,
+		},
+		{
+			name:      "includes with []int context",
+			condition: PlainCondition{Attribute: "ids", Operator: OperatorIncludes, Value: conditionValue(float64(2))},
+			context:   Context{"ids": []int{1, 2, 3}},
+			expected:  true,
+		},
+		{
+			name:      "notIncludes with []int context",
+			condition: PlainCondition{Attribute: "ids", Operator: OperatorNotIncludes, Value: conditionValue(float64(4))},
+			context:   Context{"ids": []int{1, 2, 3}},
+			expected:  true,
+		},
+		{
+			name:      "includes with bool item",
+			condition: PlainCondition{Attribute: "flags", Operator: OperatorIncludes, Value: conditionValue(true)},
+			context:   Context{"flags": []interface{}{false, true}},
+			expected:  true,
+		},
+		{
+			name:      "includes with object item",
+			condition: PlainCondition{Attribute: "items", Operator: OperatorIncludes, Value: conditionValue(map[string]interface{}{"id": float64(1)})},
+			context:   Context{"items": []map[string]int{{"id": 1}}},
+			expected:  true,
+		},
+		{
+			name:      "equals compares arrays deeply",
+			condition: PlainCondition{Attribute: "tags", Operator: OperatorEquals, Value: conditionValue([]interface{}{"a", "b"})},
+			context:   Context{"tags": []string{"a", "b"}},
+			expected:  true,
+		},
+		{
+			name:      "equals with arrays in different order",
+			condition: PlainCondition{Attribute: "tags", Operator: OperatorEquals, Value: conditionValue([]interface{}{"a", "b"})},
+			context:   Context{"tags": []string{"b", "a"}},
+			expected:  false,
+		},
+		{
+			name:      "equals compares objects deeply",
+			condition: PlainCondition{Attribute: "size", Operator: OperatorEquals, Value: conditionValue(map[string]interface{}{"w": float64(1), "h": float64(2)})},
+			context:   Context{"size": map[string]int{"w": 1, "h": 2}},
+			expected:  true,
+		},
+		{
+			name:      "notEquals compares objects deeply",
+			condition: PlainCondition{Attribute: "size", Operator: OperatorNotEquals, Value: conditionValue(map[string]interface{}{"w": float64(1)})},
+			context:   Context{"size": map[string]int{"w": 1, "h": 2}},
+			expected:  true,
+		},
+		{
+			name:      "equals between array and string",
+			condition: PlainCondition{Attribute: "tags", Operator: OperatorEquals, Value: conditionValue("a")},
+			context:   Context{"tags": []string{"a"}},
+			expected:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ConditionIsMatched(tt.condition, tt.context, nil); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
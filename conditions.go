@@ -6,7 +6,7 @@ import (
 	"time"
 )
 
-// GetRegex is a function type for getting regex patterns
+// GetRegex is a function type for getting regex patterns, returning nil for invalid ones
 type GetRegex func(regexString string, regexFlags string) *regexp.Regexp
 
 // PathExists checks if a path exists in a context object
@@ -24,20 +24,18 @@ func PathExists(obj map[string]interface{}, path string) bool {
 			return false
 		}
 
-		if mapValue, ok := current.(map[string]interface{}); ok {
-			if _, exists := mapValue[part]; !exists {
-				return false
-			}
-			current = mapValue[part]
-		} else {
+		value, exists, isMap := lookupKey(current, part)
+		if !isMap || !exists {
 			return false
 		}
+		current = value
 	}
 
 	return true
 }
 
-// GetValueFromContext extracts a value from a context object using a dot-separated path
+// GetValueFromContext extracts a value from a context object using a dot-separated path,
+// descending through any maps with string keys
 func GetValueFromContext(obj map[string]interface{}, path string) interface{} {
 	if !strings.Contains(path, ".") {
 		return obj[path]
@@ -51,22 +49,61 @@ func GetValueFromContext(obj map[string]interface{}, path string) interface{} {
 			return nil
 		}
 
-		if mapValue, ok := current.(map[string]interface{}); ok {
-			current = mapValue[part]
-		} else {
+		value, _, isMap := lookupKey(current, part)
+		if !isMap {
 			return nil
 		}
+		current = value
 	}
 
 	return current
 }
 
-// ConditionIsMatched checks if a condition is matched given a context
+// conditionOptions contains settings affecting how conditions are matched
+type conditionOptions struct {
+	// strictSemver compares versions by SemVer 2.0 precedence
+	strictSemver bool
+
+	// location is used for dates without a time zone (UTC if nil)
+	location *time.Location
+
+	// logger receives details about values that could not be compared, if set
+	logger *Logger
+}
+
+func (o conditionOptions) debug(message LogMessage, details LogDetails) {
+	if o.logger != nil {
+		o.logger.Debug(message, details)
+	}
+}
+
+// ConditionIsMatched checks if a condition is matched given a context, using globally registered operators for unknown ones
 func ConditionIsMatched(
 	condition PlainCondition,
 	context Context,
 	getRegex GetRegex,
 ) bool {
+	// custom operators
+	if !IsBuiltInOperator(condition.Operator) {
+		matched, _ := matchCustomOperator(condition, context, getRegex, nil)
+		return matched
+	}
+
+	return conditionIsMatched(condition, context, getRegex, conditionOptions{})
+}
+
+// conditionIsMatched checks if a condition with a built-in operator is matched given a context
+func conditionIsMatched(
+	condition PlainCondition,
+	context Context,
+	getRegex GetRegex,
+	options conditionOptions,
+) bool {
+	compareVersions := CompareVersions
+	if options.strictSemver {
+		compareVersions = CompareVersionsStrict
+	}
+
 	contextValueFromPath := GetValueFromContext(context, string(condition.Attribute))
 
 	// Handle nil values
@@ -83,48 +120,30 @@ func ConditionIsMatched(
 
 	// equals / notEquals
 	if condition.Operator == OperatorEquals {
-		return contextValueFromPath == value
+		return valuesEqual(contextValueFromPath, value)
 	} else if condition.Operator == OperatorNotEquals {
-		return contextValueFromPath != value
+		return !valuesEqual(contextValueFromPath, value)
 	}
 
 	// before / after (date comparisons)
 	if condition.Operator == OperatorBefore || condition.Operator == OperatorAfter {
-		var dateInContext time.Time
-		var dateInCondition time.Time
-		var err error
-
-		// Parse context value
-		switch v := contextValueFromPath.(type) {
-		case time.Time:
-			dateInContext = v
-		case string:
-			dateInContext, err = time.Parse(time.RFC3339, v)
-			if err != nil {
-				// Try other common formats
-				dateInContext, err = time.Parse("2006-01-02", v)
-				if err != nil {
-					return false
-				}
-			}
-		default:
+		dateInContext, err := ParseDate(contextValueFromPath, options.location)
+		if err != nil {
+			options.debug("could not parse date in context", LogDetails{
+				"attribute": condition.Attribute,
+				"value":     contextValueFromPath,
+				"error":     err,
+			})
 			return false
 		}
 
-		// Parse condition value
-		switch v := value.(type) {
-		case time.Time:
-			dateInCondition = v
-		case string:
-			dateInCondition, err = time.Parse(time.RFC3339, v)
-			if err != nil {
-				// Try other common formats
-				dateInCondition, err = time.Parse("2006-01-02", v)
-				if err != nil {
-					return false
-				}
-			}
-		default:
+		dateInCondition, err := ParseDate(value, options.location)
+		if err != nil {
+			options.debug("could not parse date in condition", LogDetails{
+				"attribute": condition.Attribute,
+				"value":     value,
+				"error":     err,
+			})
 			return false
 		}
 
@@ -136,7 +155,7 @@ func ConditionIsMatched(
 	}
 
 	// in / notIn (where condition value is an array)
-	if valueArray, ok := value.([]interface{}); ok {
+	if valueArray, ok := toSlice(value); ok {
 		if contextValueFromPath == nil {
 			if condition.Operator == OperatorIn {
 				return false
@@ -151,12 +170,12 @@ func ConditionIsMatched(
 		}
 
 		// Handle case where context value is also an array
-		if contextArray, ok := contextValueFromPath.([]interface{}); ok {
+		if contextArray, ok := toSlice(contextValueFromPath); ok {
 			// For arrays in context, check if any element from context array is in condition array
 			if condition.Operator == OperatorIn {
 				for _, contextItem := range contextArray {
 					for _, conditionItem := range valueArray {
-						if contextItem == conditionItem {
+						if valuesEqual(contextItem, conditionItem) {
 							return true
 						}
 					}
@@ -171,13 +190,17 @@ func ConditionIsMatched(
 			// Context value is a single value
 			valueInContext := contextValueFromPath
 
-			// Only handle in/notIn for string, numeric, or null context values (like PHP implementation)
-			switch valueInContext.(type) {
-			case string, int, float64, bool:
+			// Only handle in/notIn for string, numeric, or null context values (like PHP implementation).
+			// Numbers are compared by valuesEqual as they are, keeping integers exact.
+			_, isString := valueInContext.(string)
+			_, isBool := valueInContext.(bool)
+
+			switch {
+			case isString || isBool || isNumber(valueInContext):
 				if condition.Operator == OperatorIn {
 					// Check if context value is in the condition's array
 					for _, item := range valueArray {
-						if item == valueInContext {
+						if valuesEqual(item, valueInContext) {
 							return true
 						}
 					}
@@ -189,7 +212,7 @@ func ConditionIsMatched(
 					}
 					// Check if context value is NOT in the condition's array
 					for _, item := range valueArray {
-						if item == valueInContext {
+						if valuesEqual(item, valueInContext) {
 							return false
 						}
 					}
@@ -215,101 +238,54 @@ func ConditionIsMatched(
 			case OperatorEndsWith:
 				return strings.HasSuffix(contextValueStr, valueStr)
 			case OperatorSemverEquals:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result == 0
 			case OperatorSemverNotEquals:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result != 0
 			case OperatorSemverGreaterThan:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result == 1
 			case OperatorSemverGreaterThanOrEquals:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result >= 0
 			case OperatorSemverLessThan:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result == -1
 			case OperatorSemverLessThanOrEquals:
-				result, err := CompareVersions(contextValueStr, valueStr)
+				result, err := compareVersions(contextValueStr, valueStr)
 				return err == nil && result <= 0
+			case OperatorSemverSatisfies:
+				return semverSatisfies(contextValueStr, valueStr, options.strictSemver)
 			case OperatorMatches:
 				regexFlags := ""
 				if condition.RegexFlags != nil {
 					regexFlags = *condition.RegexFlags
 				}
 				regex := getRegex(valueStr, regexFlags)
-				return regex.MatchString(contextValueStr)
+				return regex != nil && regex.MatchString(contextValueStr)
 			case OperatorNotMatches:
 				regexFlags := ""
 				if condition.RegexFlags != nil {
 					regexFlags = *condition.RegexFlags
 				}
 				regex := getRegex(valueStr, regexFlags)
-				return !regex.MatchString(contextValueStr)
+				return regex != nil && !regex.MatchString(contextValueStr)
 			}
 		}
 	}
 
-	// Numeric operations
-	if contextValueNum, ok := contextValueFromPath.(float64); ok {
-		if valueNum, ok := value.(float64); ok {
-			switch condition.Operator {
-			case OperatorGreaterThan:
-				return contextValueNum > valueNum
-			case OperatorGreaterThanOrEquals:
-				return contextValueNum >= valueNum
-			case OperatorLessThan:
-				return contextValueNum < valueNum
-			case OperatorLessThanOrEquals:
-				return contextValueNum <= valueNum
-			}
-		}
-	}
-
-	// Handle integer types
-	if contextValueInt, ok := contextValueFromPath.(int); ok {
-		if valueInt, ok := value.(int); ok {
-			switch condition.Operator {
-			case OperatorGreaterThan:
-				return contextValueInt > valueInt
-			case OperatorGreaterThanOrEquals:
-				return contextValueInt >= valueInt
-			case OperatorLessThan:
-				return contextValueInt < valueInt
-			case OperatorLessThanOrEquals:
-				return contextValueInt <= valueInt
-			}
-		}
-	}
-
-	// Handle mixed numeric types
-	if contextValueFloat, ok := contextValueFromPath.(float64); ok {
-		if valueInt, ok := value.(int); ok {
-			switch condition.Operator {
-			case OperatorGreaterThan:
-				return contextValueFloat > float64(valueInt)
-			case OperatorGreaterThanOrEquals:
-				return contextValueFloat >= float64(valueInt)
-			case OperatorLessThan:
-				return contextValueFloat < float64(valueInt)
-			case OperatorLessThanOrEquals:
-				return contextValueFloat <= float64(valueInt)
-			}
-		}
-	}
-
-	if contextValueInt, ok := contextValueFromPath.(int); ok {
-		if valueFloat, ok := value.(float64); ok {
-			switch condition.Operator {
-			case OperatorGreaterThan:
-				return float64(contextValueInt) > valueFloat
-			case OperatorGreaterThanOrEquals:
-				return float64(contextValueInt) >= valueFloat
-			case OperatorLessThan:
-				return float64(contextValueInt) < valueFloat
-			case OperatorLessThanOrEquals:
-				return float64(contextValueInt) <= valueFloat
-			}
+	// Numeric operations, for numbers of any Go type
+	if result, ok := compareNumbers(contextValueFromPath, value); ok {
+		switch condition.Operator {
+		case OperatorGreaterThan:
+			return result > 0
+		case OperatorGreaterThanOrEquals:
+			return result >= 0
+		case OperatorLessThan:
+			return result < 0
+		case OperatorLessThanOrEquals:
+			return result <= 0
 		}
 	}
 
@@ -320,25 +296,23 @@ func ConditionIsMatched(
 		return contextValueFromPath == nil
 	}
 
-	// includes / notIncludes (where context value is an array)
-	if contextValueArray, ok := contextValueFromPath.([]interface{}); ok {
-		if valueStr, ok := value.(string); ok {
-			switch condition.Operator {
-			case OperatorIncludes:
-				for _, item := range contextValueArray {
-					if itemStr, ok := item.(string); ok && itemStr == valueStr {
-						return true
-					}
+	// includes / notIncludes (where context value is a slice or array)
+	if contextValueArray, ok := toSlice(contextValueFromPath); ok {
+		switch condition.Operator {
+		case OperatorIncludes:
+			for _, item := range contextValueArray {
+				if valuesEqual(item, value) {
+					return true
 				}
-				return false
-			case OperatorNotIncludes:
-				for _, item := range contextValueArray {
-					if itemStr, ok := item.(string); ok && itemStr == valueStr {
-						return false
-					}
+			}
+			return false
+		case OperatorNotIncludes:
+			for _, item := range contextValueArray {
+				if valuesEqual(item, value) {
+					return false
 				}
-				return true
 			}
+			return true
 		}
 	}
 
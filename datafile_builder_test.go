@@ -0,0 +1,77 @@
+package featurevisor
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testDatafile builds datafiles for tests from the JSON of their parts
+type testDatafile struct {
+	revision string
+	segments string
+	features string
+	schemas  string
+}
+
+// build parses the datafile, failing the test if it is not valid
+func (d testDatafile) build(t testing.TB) DatafileContent {
+	t.Helper()
+
+	revision := d.revision
+	if revision == "" {
+		revision = "1"
+	}
+
+	segments := d.segments
+	if segments == "" {
+		segments = "{}"
+	}
+
+	features := d.features
+	if features == "" {
+		features = "{}"
+	}
+
+	schemas := ""
+	if d.schemas != "" {
+		schemas = fmt.Sprintf(`, "schemas": %s`, d.schemas)
+	}
+
+	var datafile DatafileContent
+	if err := datafile.FromJSON(fmt.Sprintf(`{"schemaVersion": "2", "revision": %q, "segments": %s, "features": %s%s}`, revision, segments, features, schemas)); err != nil {
+		t.Fatalf("Failed to parse datafile JSON: %v", err)
+	}
+
+	return datafile
+}
+
+// newTestInstance creates an instance with the datafile, with logs silenced unless a logger or log level is given
+func newTestInstance(t testing.TB, datafile testDatafile, options Options) *Featurevisor {
+	t.Helper()
+
+	options.Datafile = datafile.build(t)
+	if options.Logger == nil && options.LogLevel == nil {
+		options.LogLevel = &[]LogLevel{LogLevelFatal}[0]
+	}
+
+	return CreateInstance(options)
+}
+
+// newTestAttributes parses attributes, failing the test if they are not valid
+func newTestAttributes(t testing.TB, attributes string) []Attribute {
+	t.Helper()
+
+	parsed, err := ParseAttributes([]byte(attributes))
+	if err != nil {
+		t.Fatalf("Failed to parse attributes JSON: %v", err)
+	}
+
+	return parsed
+}
+
+// newTestLogger creates a logger passing warnings and above to the handler
+func newTestLogger(handler LogHandler) *Logger {
+	level := LogLevelWarn
+
+	return NewLogger(CreateLoggerOptions{Level: &level, Handler: &handler})
+}
@@ -0,0 +1,531 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DiffType represents how something changed between two datafiles
+type DiffType string
+
+const (
+	DiffTypeAdded   DiffType = "added"
+	DiffTypeRemoved DiffType = "removed"
+	DiffTypeChanged DiffType = "changed"
+)
+
+// ValueDiff represents a single changed value, identified by its path
+type ValueDiff struct {
+	Path   string      `json:"path"`
+	Type   DiffType    `json:"type"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// TrafficDiff represents a changed traffic rule, matched by its key
+type TrafficDiff struct {
+	RuleKey          RuleKey     `json:"ruleKey"`
+	Type             DiffType    `json:"type"`
+	PercentageBefore *Percentage `json:"percentageBefore,omitempty"`
+	PercentageAfter  *Percentage `json:"percentageAfter,omitempty"`
+	Changes          []ValueDiff `json:"changes,omitempty"`
+
+	// IndexBefore and IndexAfter are positions of the rule, set for added and removed rules,
+	// and for rules moved relative to the other rules existing in both datafiles
+	IndexBefore *int `json:"indexBefore,omitempty"`
+	IndexAfter  *int `json:"indexAfter,omitempty"`
+}
+
+// FeatureDiff represents everything that changed in a single feature
+type FeatureDiff struct {
+	FeatureKey FeatureKey    `json:"featureKey"`
+	Type       DiffType      `json:"type"`
+	Traffic    []TrafficDiff `json:"traffic,omitempty"`
+	Variations []ValueDiff   `json:"variations,omitempty"` // by variation value
+	Variables  []ValueDiff   `json:"variables,omitempty"`  // by variable key
+	Force      []ValueDiff   `json:"force,omitempty"`      // by force index
+	Required   []ValueDiff   `json:"required,omitempty"`   // by required feature key
+	Other      []ValueDiff   `json:"other,omitempty"`      // bucketBy, ranges, deprecated, etc.
+
+	// Segments lists changed segments this feature uses, even if the feature itself is unchanged
+	Segments []SegmentKey `json:"segments,omitempty"`
+}
+
+// SegmentDiff represents a changed segment and the features affected by it
+type SegmentDiff struct {
+	SegmentKey SegmentKey   `json:"segmentKey"`
+	Type       DiffType     `json:"type"`
+	Conditions []ValueDiff  `json:"conditions,omitempty"`
+	Other      []ValueDiff  `json:"other,omitempty"` // archived, description
+	Features   []FeatureKey `json:"features,omitempty"`
+}
+
+// DatafileDiff represents the differences between two datafiles
+type DatafileDiff struct {
+	PreviousRevision string        `json:"previousRevision"`
+	Revision         string        `json:"revision"`
+	Features         []FeatureDiff `json:"features"`
+	Segments         []SegmentDiff `json:"segments"`
+}
+
+// DiffDatafiles returns the differences between two datafiles
+func DiffDatafiles(previousDatafile DatafileContent, newDatafile DatafileContent) *DatafileDiff {
+	return diffDatafiles(
+		previousDatafile.Revision,
+		previousDatafile.Features,
+		previousDatafile.Segments,
+		newDatafile.Revision,
+		newDatafile.Features,
+		newDatafile.Segments,
+	)
+}
+
+// diffDatafileReaders returns the differences between the content of two datafile readers, either of which can be nil
+func diffDatafileReaders(previousDatafileReader *DatafileReader, newDatafileReader *DatafileReader) *DatafileDiff {
+	if previousDatafileReader == nil {
+		previousDatafileReader = &DatafileReader{}
+	}
+	if newDatafileReader == nil {
+		newDatafileReader = &DatafileReader{}
+	}
+
+	return diffDatafiles(
+		previousDatafileReader.revision,
+		previousDatafileReader.features,
+		previousDatafileReader.segments,
+		newDatafileReader.revision,
+		newDatafileReader.features,
+		newDatafileReader.segments,
+	)
+}
+
+func diffDatafiles(
+	previousRevision string,
+	previousFeatures map[FeatureKey]Feature,
+	previousSegments map[SegmentKey]Segment,
+	newRevision string,
+	newFeatures map[FeatureKey]Feature,
+	newSegments map[SegmentKey]Segment,
+) *DatafileDiff {
+	diff := &DatafileDiff{
+		PreviousRevision: previousRevision,
+		Revision:         newRevision,
+		Features:         []FeatureDiff{},
+		Segments:         []SegmentDiff{},
+	}
+
+	// segments
+	previousGraph := NewDependencyGraph(previousFeatures, previousSegments)
+	newGraph := NewDependencyGraph(newFeatures, newSegments)
+
+	for _, segmentKey := range unionKeys(previousSegments, newSegments) {
+		previousSegment, inPrevious := previousSegments[segmentKey]
+		newSegment, inNew := newSegments[segmentKey]
+
+		segmentDiff := SegmentDiff{SegmentKey: segmentKey}
+
+		switch {
+		case !inPrevious:
+			segmentDiff.Type = DiffTypeAdded
+		case !inNew:
+			segmentDiff.Type = DiffTypeRemoved
+		default:
+			segmentDiff.Type = DiffTypeChanged
+			segmentDiff.Conditions = diffConditions(previousSegment.Conditions, newSegment.Conditions)
+			segmentDiff.Other = appendValueDiff(segmentDiff.Other, "archived", previousSegment.Archived, newSegment.Archived)
+			segmentDiff.Other = appendValueDiff(segmentDiff.Other, "description", previousSegment.Description, newSegment.Description)
+
+			if len(segmentDiff.Conditions) == 0 && len(segmentDiff.Other) == 0 {
+				continue
+			}
+		}
+
+		// features using the segment before or after the change
+		affected := map[FeatureKey]bool{}
+		for _, featureKey := range previousGraph.GetFeaturesUsingSegment(segmentKey) {
+			affected[featureKey] = true
+		}
+		for _, featureKey := range newGraph.GetFeaturesUsingSegment(segmentKey) {
+			affected[featureKey] = true
+		}
+		segmentDiff.Features = sortedKeys(affected)
+
+		diff.Segments = append(diff.Segments, segmentDiff)
+	}
+
+	// propagate segment changes to features using them, ignoring changes that do not affect evaluation
+	segmentsByFeature := map[FeatureKey][]SegmentKey{}
+	for _, segmentDiff := range diff.Segments {
+		if len(segmentDiff.Conditions) == 0 && segmentDiff.Type == DiffTypeChanged {
+			continue
+		}
+
+		for _, featureKey := range segmentDiff.Features {
+			segmentsByFeature[featureKey] = append(segmentsByFeature[featureKey], segmentDiff.SegmentKey)
+		}
+	}
+
+	// features
+	for _, featureKey := range unionKeys(previousFeatures, newFeatures) {
+		previousFeature, inPrevious := previousFeatures[featureKey]
+		newFeature, inNew := newFeatures[featureKey]
+
+		var featureDiff FeatureDiff
+
+		switch {
+		case !inPrevious:
+			featureDiff = FeatureDiff{FeatureKey: featureKey, Type: DiffTypeAdded}
+		case !inNew:
+			featureDiff = FeatureDiff{FeatureKey: featureKey, Type: DiffTypeRemoved}
+		default:
+			featureDiff = diffFeatures(featureKey, previousFeature, newFeature)
+		}
+
+		featureDiff.Segments = segmentsByFeature[featureKey]
+
+		if featureDiff.Type == DiffTypeChanged && !featureDiff.HasChanges() {
+			continue
+		}
+
+		diff.Features = append(diff.Features, featureDiff)
+	}
+
+	return diff
+}
+
+// HasChanges tells whether anything in the feature, or in segments it uses, changed
+func (d FeatureDiff) HasChanges() bool {
+	return d.Type != DiffTypeChanged ||
+		len(d.Traffic) > 0 ||
+		len(d.Variations) > 0 ||
+		len(d.Variables) > 0 ||
+		len(d.Force) > 0 ||
+		len(d.Required) > 0 ||
+		len(d.Other) > 0 ||
+		len(d.Segments) > 0
+}
+
+// HasChanges tells whether anything changed between the two datafiles, ignoring revision
+func (d *DatafileDiff) HasChanges() bool {
+	return len(d.Features) > 0 || len(d.Segments) > 0
+}
+
+// String returns a short summary of the diff, used when logging
+func (d *DatafileDiff) String() string {
+	return fmt.Sprintf("%d feature(s), %d segment(s) changed", len(d.Features), len(d.Segments))
+}
+
+// GetFeatureKeys returns the keys of features of the given diff types, or all affected features if none given
+func (d *DatafileDiff) GetFeatureKeys(types ...DiffType) []FeatureKey {
+	keys := []FeatureKey{}
+	for _, featureDiff := range d.Features {
+		if len(types) > 0 && !containsDiffType(types, featureDiff.Type) {
+			continue
+		}
+		keys = append(keys, featureDiff.FeatureKey)
+	}
+	return keys
+}
+
+// GetFeature returns the diff of a single feature, or nil if it did not change
+func (d *DatafileDiff) GetFeature(featureKey FeatureKey) *FeatureDiff {
+	for i := range d.Features {
+		if d.Features[i].FeatureKey == featureKey {
+			return &d.Features[i]
+		}
+	}
+	return nil
+}
+
+// GetSegment returns the diff of a single segment, or nil if it did not change
+func (d *DatafileDiff) GetSegment(segmentKey SegmentKey) *SegmentDiff {
+	for i := range d.Segments {
+		if d.Segments[i].SegmentKey == segmentKey {
+			return &d.Segments[i]
+		}
+	}
+	return nil
+}
+
+func diffFeatures(featureKey FeatureKey, previousFeature Feature, newFeature Feature) FeatureDiff {
+	featureDiff := FeatureDiff{
+		FeatureKey: featureKey,
+		Type:       DiffTypeChanged,
+	}
+
+	// traffic, by rule key
+	previousTraffic := map[RuleKey]Traffic{}
+	previousIndexes := map[RuleKey]int{}
+	for i, traffic := range previousFeature.Traffic {
+		previousTraffic[traffic.Key] = traffic
+		previousIndexes[traffic.Key] = i
+	}
+	newTraffic := map[RuleKey]Traffic{}
+	newIndexes := map[RuleKey]int{}
+	for i, traffic := range newFeature.Traffic {
+		newTraffic[traffic.Key] = traffic
+		newIndexes[traffic.Key] = i
+	}
+
+	// order of rules existing in both, so adding or removing a rule does not move the others
+	previousOrder := getKeptTrafficOrder(previousFeature.Traffic, newTraffic)
+	newOrder := getKeptTrafficOrder(newFeature.Traffic, previousTraffic)
+
+	for _, ruleKey := range getTrafficKeys(previousFeature.Traffic, newFeature.Traffic) {
+		previous, inPrevious := previousTraffic[ruleKey]
+		current, inNew := newTraffic[ruleKey]
+		previousIndex, newIndex := previousIndexes[ruleKey], newIndexes[ruleKey]
+
+		trafficDiff := TrafficDiff{RuleKey: ruleKey}
+
+		switch {
+		case !inPrevious:
+			trafficDiff.Type = DiffTypeAdded
+			trafficDiff.PercentageAfter = &current.Percentage
+			trafficDiff.IndexAfter = &newIndex
+		case !inNew:
+			trafficDiff.Type = DiffTypeRemoved
+			trafficDiff.PercentageBefore = &previous.Percentage
+			trafficDiff.IndexBefore = &previousIndex
+		default:
+			trafficDiff.Type = DiffTypeChanged
+			if previous.Percentage != current.Percentage {
+				trafficDiff.PercentageBefore = &previous.Percentage
+				trafficDiff.PercentageAfter = &current.Percentage
+			}
+			if previousOrder[ruleKey] != newOrder[ruleKey] {
+				trafficDiff.IndexBefore = &previousIndex
+				trafficDiff.IndexAfter = &newIndex
+			}
+
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "segments", previous.Segments, current.Segments)
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "enabled", previous.Enabled, current.Enabled)
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "variation", previous.Variation, current.Variation)
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "variables", previous.Variables, current.Variables)
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "variationWeights", previous.VariationWeights, current.VariationWeights)
+			trafficDiff.Changes = appendValueDiff(trafficDiff.Changes, "allocation", previous.Allocation, current.Allocation)
+
+			if trafficDiff.PercentageBefore == nil && trafficDiff.IndexBefore == nil && len(trafficDiff.Changes) == 0 {
+				continue
+			}
+		}
+
+		featureDiff.Traffic = append(featureDiff.Traffic, trafficDiff)
+	}
+
+	// variations, by value
+	previousVariations := map[VariationValue]Variation{}
+	for _, variation := range previousFeature.Variations {
+		previousVariations[variation.Value] = variation
+	}
+	newVariations := map[VariationValue]Variation{}
+	for _, variation := range newFeature.Variations {
+		newVariations[variation.Value] = variation
+	}
+	featureDiff.Variations = diffMaps(previousVariations, newVariations)
+
+	// variables, by key
+	featureDiff.Variables = diffMaps(previousFeature.VariablesSchema, newFeature.VariablesSchema)
+
+	// force, by index
+	for i := 0; i < len(previousFeature.Force) || i < len(newFeature.Force); i++ {
+		var previous, current interface{}
+		if i < len(previousFeature.Force) {
+			previous = previousFeature.Force[i]
+		}
+		if i < len(newFeature.Force) {
+			current = newFeature.Force[i]
+		}
+		featureDiff.Force = appendValueDiff(featureDiff.Force, fmt.Sprintf("%d", i), previous, current)
+	}
+
+	// required, by feature key
+	featureDiff.Required = diffMaps(getRequiredMap(previousFeature.Required), getRequiredMap(newFeature.Required))
+
+	// everything else
+	featureDiff.Other = appendValueDiff(featureDiff.Other, "bucketBy", previousFeature.BucketBy, newFeature.BucketBy)
+	featureDiff.Other = appendValueDiff(featureDiff.Other, "ranges", previousFeature.Ranges, newFeature.Ranges)
+	featureDiff.Other = appendValueDiff(featureDiff.Other, "deprecated", previousFeature.Deprecated, newFeature.Deprecated)
+	featureDiff.Other = appendValueDiff(featureDiff.Other, "disabledVariationValue", previousFeature.DisabledVariationValue, newFeature.DisabledVariationValue)
+
+	return featureDiff
+}
+
+// diffConditions compares conditions item by item when both are lists, and as a whole otherwise
+func diffConditions(previousConditions Condition, newConditions Condition) []ValueDiff {
+	previousConditions = normalizeDiffValue(previousConditions)
+	newConditions = normalizeDiffValue(newConditions)
+
+	previousList, previousIsList := previousConditions.([]interface{})
+	newList, newIsList := newConditions.([]interface{})
+
+	if !previousIsList || !newIsList {
+		return appendValueDiff(nil, "", previousConditions, newConditions)
+	}
+
+	var diffs []ValueDiff
+	for i := 0; i < len(previousList) || i < len(newList); i++ {
+		var previous, current interface{}
+		if i < len(previousList) {
+			previous = previousList[i]
+		}
+		if i < len(newList) {
+			current = newList[i]
+		}
+		diffs = appendValueDiff(diffs, fmt.Sprintf("%d", i), previous, current)
+	}
+
+	return diffs
+}
+
+// diffMaps compares two maps key by key
+func diffMaps[V any](previous map[string]V, current map[string]V) []ValueDiff {
+	var diffs []ValueDiff
+	for _, key := range unionKeys(previous, current) {
+		var previousValue, currentValue interface{}
+		if value, exists := previous[key]; exists {
+			previousValue = value
+		}
+		if value, exists := current[key]; exists {
+			currentValue = value
+		}
+		diffs = appendValueDiff(diffs, key, previousValue, currentValue)
+	}
+	return diffs
+}
+
+// appendValueDiff appends a diff if the values differ, treating nil as absent
+func appendValueDiff(diffs []ValueDiff, path string, before interface{}, after interface{}) []ValueDiff {
+	before = normalizeDiffValue(before)
+	after = normalizeDiffValue(after)
+
+	switch {
+	case before == nil && after == nil:
+		return diffs
+	case before == nil:
+		return append(diffs, ValueDiff{Path: path, Type: DiffTypeAdded, After: after})
+	case after == nil:
+		return append(diffs, ValueDiff{Path: path, Type: DiffTypeRemoved, Before: before})
+	case reflect.DeepEqual(before, after):
+		return diffs
+	}
+
+	return append(diffs, ValueDiff{Path: path, Type: DiffTypeChanged, Before: before, After: after})
+}
+
+// normalizeDiffValue converts a value to its plain JSON form, so typed and untyped values compare equal
+func normalizeDiffValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	// stringified conditions and segments
+	if s, ok := value.(string); ok {
+		trimmed := strings.TrimSpace(s)
+		if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+			var parsed interface{}
+			if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+				return parsed
+			}
+		}
+		return s
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+
+	var normalized interface{}
+	if err := json.Unmarshal(bytes, &normalized); err != nil {
+		return value
+	}
+
+	// empty maps and lists are the same as absent ones in a datafile
+	switch v := normalized.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+
+	return normalized
+}
+
+// getRequiredMap returns required features keyed by feature key
+func getRequiredMap(required []Required) map[FeatureKey]interface{} {
+	result := map[FeatureKey]interface{}{}
+	for _, r := range required {
+		requiredKey, variation := getRequiredFeature(r)
+		if requiredKey == "" {
+			continue
+		}
+
+		if variation != nil {
+			result[requiredKey] = RequiredWithVariation{Key: requiredKey, Variation: *variation}
+		} else {
+			result[requiredKey] = requiredKey
+		}
+	}
+	return result
+}
+
+// getTrafficKeys returns rule keys in their new order, followed by removed ones
+func getTrafficKeys(previousTraffic []Traffic, newTraffic []Traffic) []RuleKey {
+	keys := []RuleKey{}
+	seen := map[RuleKey]bool{}
+	for _, traffic := range append(append([]Traffic{}, newTraffic...), previousTraffic...) {
+		if !seen[traffic.Key] {
+			seen[traffic.Key] = true
+			keys = append(keys, traffic.Key)
+		}
+	}
+	return keys
+}
+
+// getKeptTrafficOrder returns positions of rules among those also found in the other traffic
+func getKeptTrafficOrder(traffic []Traffic, otherTraffic map[RuleKey]Traffic) map[RuleKey]int {
+	order := map[RuleKey]int{}
+	for _, t := range traffic {
+		if _, exists := otherTraffic[t.Key]; exists {
+			order[t.Key] = len(order)
+		}
+	}
+	return order
+}
+
+// unionKeys returns the keys of both maps in sorted order
+func unionKeys[V any](a map[string]V, b map[string]V) []string {
+	keys := map[string]bool{}
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return sortedKeys(keys)
+}
+
+func containsDiffType(types []DiffType, diffType DiffType) bool {
+	for _, t := range types {
+		if t == diffType {
+			return true
+		}
+	}
+	return false
+}
+
+// getAffectedFeatureKeys returns keys of removed, changed and added features, in that order
+func getAffectedFeatureKeys(diff *DatafileDiff) []FeatureKey {
+	keys := []FeatureKey{}
+	for _, diffType := range []DiffType{DiffTypeRemoved, DiffTypeChanged, DiffTypeAdded} {
+		keys = append(keys, diff.GetFeatureKeys(diffType)...)
+	}
+	return keys
+}
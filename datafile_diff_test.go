@@ -0,0 +1,295 @@
+package featurevisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+var previousDatafileDiffTestDatafile = testDatafile{
+	segments: `{
+		"netherlands": {"conditions": "[{\"attribute\":\"country\",\"operator\":\"equals\",\"value\":\"nl\"}]"},
+		"germany": {"conditions": [{"attribute": "country", "operator": "equals", "value": "de"}]},
+		"old": {"conditions": "*"}
+	}`,
+	features: `{
+		"unchanged": {
+			"hash": "same",
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"usesSegment": {
+			"hash": "same",
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "netherlands", "percentage": 100000}]
+		},
+		"changed": {
+			"bucketBy": "userId",
+			"required": ["unchanged"],
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"variablesSchema": {
+				"color": {"type": "string", "defaultValue": "red"}
+			},
+			"traffic": [
+				{"key": "1", "segments": "germany", "percentage": 50000},
+				{"key": "2", "segments": "*", "percentage": 100000}
+			]
+		},
+		"removed": {"bucketBy": "userId", "traffic": []}
+	}`,
+}
+
+var newDatafileDiffTestDatafile = testDatafile{
+	revision: "2",
+	segments: `{
+		"netherlands": {"conditions": "[{\"attribute\":\"country\",\"operator\":\"equals\",\"value\":\"nl\"},{\"attribute\":\"device\",\"operator\":\"equals\",\"value\":\"mobile\"}]"},
+		"germany": {"conditions": [{"attribute": "country", "operator": "equals", "value": "de"}], "description": "Germany"}
+	}`,
+	features: `{
+		"unchanged": {
+			"hash": "same",
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"usesSegment": {
+			"hash": "same",
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "netherlands", "percentage": 100000}]
+		},
+		"changed": {
+			"bucketBy": "userId",
+			"required": [{"key": "unchanged", "variation": "on"}],
+			"variations": [{"value": "control"}, {"value": "treatment", "variables": {"color": "blue"}}],
+			"variablesSchema": {
+				"color": {"type": "string", "defaultValue": "green"},
+				"size": {"type": "integer", "defaultValue": 1}
+			},
+			"force": [{"segments": "germany", "enabled": true}],
+			"traffic": [
+				{"key": "1", "segments": "germany", "percentage": 80000}
+			]
+		},
+		"added": {"bucketBy": "userId", "traffic": []}
+	}`,
+}
+
+func TestDiffDatafiles(t *testing.T) {
+	previousDatafile, newDatafile := previousDatafileDiffTestDatafile.build(t), newDatafileDiffTestDatafile.build(t)
+
+	diff := DiffDatafiles(previousDatafile, newDatafile)
+
+	if diff.PreviousRevision != "1" || diff.Revision != "2" {
+		t.Fatalf("unexpected revisions %s -> %s", diff.PreviousRevision, diff.Revision)
+	}
+
+	if keys := diff.GetFeatureKeys(); !reflect.DeepEqual(keys, []FeatureKey{"added", "changed", "removed", "usesSegment"}) {
+		t.Fatalf("unexpected affected features %v", keys)
+	}
+	if keys := diff.GetFeatureKeys(DiffTypeAdded, DiffTypeRemoved); !reflect.DeepEqual(keys, []FeatureKey{"added", "removed"}) {
+		t.Fatalf("unexpected added and removed features %v", keys)
+	}
+
+	changed := diff.GetFeature("changed")
+	if changed == nil || changed.Type != DiffTypeChanged {
+		t.Fatalf("expected changed feature diff, got %+v", changed)
+	}
+
+	if len(changed.Traffic) != 2 {
+		t.Fatalf("expected 2 traffic diffs, got %+v", changed.Traffic)
+	}
+	if changed.Traffic[0].RuleKey != "1" || *changed.Traffic[0].PercentageBefore != 50000 || *changed.Traffic[0].PercentageAfter != 80000 {
+		t.Fatalf("unexpected percentage diff %+v", changed.Traffic[0])
+	}
+	if changed.Traffic[1].RuleKey != "2" || changed.Traffic[1].Type != DiffTypeRemoved {
+		t.Fatalf("expected removed rule, got %+v", changed.Traffic[1])
+	}
+
+	if len(changed.Variations) != 1 || changed.Variations[0].Path != "treatment" {
+		t.Fatalf("unexpected variation diffs %+v", changed.Variations)
+	}
+
+	if len(changed.Variables) != 2 ||
+		changed.Variables[0].Path != "color" || changed.Variables[0].Type != DiffTypeChanged ||
+		changed.Variables[1].Path != "size" || changed.Variables[1].Type != DiffTypeAdded {
+		t.Fatalf("unexpected variable diffs %+v", changed.Variables)
+	}
+
+	if len(changed.Force) != 1 || changed.Force[0].Type != DiffTypeAdded {
+		t.Fatalf("unexpected force diffs %+v", changed.Force)
+	}
+
+	if len(changed.Required) != 1 || changed.Required[0].Path != "unchanged" || changed.Required[0].Type != DiffTypeChanged {
+		t.Fatalf("unexpected required diffs %+v", changed.Required)
+	}
+
+	if diff.GetFeature("unchanged") != nil {
+		t.Fatalf("did not expect diff for unchanged feature")
+	}
+}
+
+func TestDiffDatafilesSegmentPropagation(t *testing.T) {
+	previousDatafile, newDatafile := previousDatafileDiffTestDatafile.build(t), newDatafileDiffTestDatafile.build(t)
+
+	diff := DiffDatafiles(previousDatafile, newDatafile)
+
+	netherlands := diff.GetSegment("netherlands")
+	if netherlands == nil || netherlands.Type != DiffTypeChanged {
+		t.Fatalf("expected changed segment, got %+v", netherlands)
+	}
+	if len(netherlands.Conditions) != 1 || netherlands.Conditions[0].Path != "1" || netherlands.Conditions[0].Type != DiffTypeAdded {
+		t.Fatalf("unexpected condition diffs %+v", netherlands.Conditions)
+	}
+	if !reflect.DeepEqual(netherlands.Features, []FeatureKey{"usesSegment"}) {
+		t.Fatalf("unexpected features of segment %v", netherlands.Features)
+	}
+
+	// feature content is the same, but its segment changed
+	usesSegment := diff.GetFeature("usesSegment")
+	if usesSegment == nil || !reflect.DeepEqual(usesSegment.Segments, []SegmentKey{"netherlands"}) || len(usesSegment.Traffic) != 0 {
+		t.Fatalf("expected segment change to propagate, got %+v", usesSegment)
+	}
+
+	// description only changes do not propagate
+	germany := diff.GetSegment("germany")
+	if germany == nil || len(germany.Conditions) != 0 || len(germany.Other) != 1 {
+		t.Fatalf("unexpected germany segment diff %+v", germany)
+	}
+	if len(diff.GetFeature("changed").Segments) != 0 {
+		t.Fatalf("did not expect description change to propagate")
+	}
+
+	if old := diff.GetSegment("old"); old == nil || old.Type != DiffTypeRemoved {
+		t.Fatalf("expected removed segment, got %+v", old)
+	}
+}
+
+func TestDiffDatafilesRuleOrder(t *testing.T) {
+	previousDatafile := testDatafile{features: `{
+		"test": {
+			"bucketBy": "userId",
+			"traffic": [
+				{"key": "a", "segments": "*", "percentage": 100000},
+				{"key": "b", "segments": "*", "percentage": 100000},
+				{"key": "c", "segments": "*", "percentage": 100000}
+			]
+		}
+	}`}.build(t)
+	newDatafile := testDatafile{features: `{
+		"test": {
+			"bucketBy": "userId",
+			"traffic": [
+				{"key": "x", "segments": "*", "percentage": 100000},
+				{"key": "b", "segments": "*", "percentage": 100000},
+				{"key": "a", "segments": "*", "percentage": 100000},
+				{"key": "c", "segments": "*", "percentage": 100000}
+			]
+		}
+	}`}.build(t)
+
+	diff := DiffDatafiles(previousDatafile, newDatafile)
+
+	feature := diff.GetFeature("test")
+	if feature == nil || len(feature.Traffic) != 3 {
+		t.Fatalf("expected added and swapped rules only, got %+v", feature)
+	}
+
+	expected := []struct {
+		ruleKey     RuleKey
+		diffType    DiffType
+		indexBefore *int
+		indexAfter  *int
+	}{
+		{"x", DiffTypeAdded, nil, &[]int{0}[0]},
+		{"b", DiffTypeChanged, &[]int{1}[0], &[]int{1}[0]},
+		{"a", DiffTypeChanged, &[]int{0}[0], &[]int{2}[0]},
+	}
+	for i, e := range expected {
+		trafficDiff := feature.Traffic[i]
+		if trafficDiff.RuleKey != e.ruleKey || trafficDiff.Type != e.diffType ||
+			!reflect.DeepEqual(trafficDiff.IndexBefore, e.indexBefore) || !reflect.DeepEqual(trafficDiff.IndexAfter, e.indexAfter) {
+			t.Errorf("unexpected diff of rule %q: %+v", e.ruleKey, trafficDiff)
+		}
+	}
+
+	if summary := diff.String(); summary != "1 feature(s), 0 segment(s) changed" {
+		t.Errorf("unexpected summary %q", summary)
+	}
+}
+
+func TestDatafileSetEventCarriesDiff(t *testing.T) {
+	previousDatafile, newDatafile := previousDatafileDiffTestDatafile.build(t), newDatafileDiffTestDatafile.build(t)
+
+	instance := CreateInstance(Options{
+		Datafile: previousDatafile,
+	})
+
+	var details EventDetails
+	instance.On(EventNameDatafileSet, func(d EventDetails) {
+		details = d
+	})
+
+	instance.SetDatafile(newDatafile)
+
+	diff, ok := details["diff"].(*DatafileDiff)
+	if !ok || !diff.HasChanges() {
+		t.Fatalf("expected diff in event details, got %+v", details["diff"])
+	}
+
+	expected := []FeatureKey{"removed", "changed", "usesSegment", "added"}
+	if !reflect.DeepEqual(details["features"], expected) {
+		t.Fatalf("expected features %v, got %v", expected, details["features"])
+	}
+
+	// same content again
+	instance.SetDatafile(newDatafile)
+
+	if features := details["features"].([]FeatureKey); len(features) != 0 {
+		t.Fatalf("expected no affected features for identical datafile, got %v", features)
+	}
+}
+
+func TestDatafileSetLogsChangedKeys(t *testing.T) {
+	previousDatafile, newDatafile := previousDatafileDiffTestDatafile.build(t), newDatafileDiffTestDatafile.build(t)
+
+	logged := map[LogMessage]LogDetails{}
+	level := LogLevelDebug
+	handler := LogHandler(func(level LogLevel, message LogMessage, details LogDetails) {
+		logged[message] = details
+	})
+	instance := CreateInstance(Options{
+		Datafile: previousDatafile,
+		Logger:   NewLogger(CreateLoggerOptions{Level: &level, Handler: &handler}),
+	})
+
+	var details EventDetails
+	instance.On(EventNameDatafileSet, func(d EventDetails) {
+		details = d
+	})
+
+	instance.SetDatafile(newDatafile)
+
+	diff, ok := details["diff"].(*DatafileDiff)
+	if !ok {
+		t.Fatalf("expected diff in event details, got %+v", details["diff"])
+	}
+
+	// only keys of what changed are logged at info level
+	info := logged["datafile set"]
+	if _, ok := info["diff"]; ok {
+		t.Errorf("did not expect diff in info log, got %v", info)
+	}
+	if !reflect.DeepEqual(info["features"], details["features"]) {
+		t.Errorf("expected features %v in info log, got %v", details["features"], info["features"])
+	}
+	segmentKeys := []SegmentKey{}
+	for _, segmentDiff := range diff.Segments {
+		segmentKeys = append(segmentKeys, segmentDiff.SegmentKey)
+	}
+	if len(segmentKeys) == 0 || !reflect.DeepEqual(info["segments"], segmentKeys) {
+		t.Errorf("expected segments %v in info log, got %v", segmentKeys, info["segments"])
+	}
+
+	// and the whole diff at debug level
+	if logged["datafile diff"]["diff"] != diff {
+		t.Errorf("expected diff in debug log, got %v", logged["datafile diff"])
+	}
+}
@@ -2,15 +2,28 @@ package featurevisor
 
 import (
 	"encoding/json"
-	"fmt"
 	"regexp"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DatafileReaderOptions contains options for creating a datafile reader
 type DatafileReaderOptions struct {
 	Datafile DatafileContent
 	Logger   *Logger
+
+	// RegexCacheSize is the maximum number of compiled regular expressions kept (defaults to DefaultRegexCacheSize)
+	RegexCacheSize int
+
+	// Operators are custom operators, taking precedence over globally registered ones
+	Operators Operators
+
+	// StrictSemver compares versions in semver operators by SemVer 2.0 precedence
+	StrictSemver bool
+
+	// TimeZone is used for dates without a time zone in before and after conditions (defaults to UTC)
+	TimeZone *time.Location
 }
 
 // ForceResult represents the result of a force lookup
@@ -25,20 +38,50 @@ type DatafileReader struct {
 	revision      string
 	segments      map[SegmentKey]Segment
 	features      map[FeatureKey]Feature
+	schemas       map[SchemaKey]Schema
 	logger        *Logger
-	regexCache    map[string]*regexp.Regexp
+	regexCache    *regexCache
+	operators     Operators
+	strictSemver  bool
+	timeZone      *time.Location
+
+	// unknown operators already warned about
+	warnedOperators sync.Map
+
+	// features already warned about for attributes of bucketBy missing in context
+	warnedMissingBucketBy sync.Map
+
+	validationResult DatafileValidationResult
 }
 
 // NewDatafileReader creates a new datafile reader instance
 func NewDatafileReader(options DatafileReaderOptions) *DatafileReader {
-	return &DatafileReader{
+	logger := options.Logger
+	if logger == nil {
+		logger = NewLogger(CreateLoggerOptions{})
+	}
+
+	reader := &DatafileReader{
 		schemaVersion: options.Datafile.SchemaVersion,
 		revision:      options.Datafile.Revision,
 		segments:      options.Datafile.Segments,
-		features:      options.Datafile.Features,
-		logger:        options.Logger,
-		regexCache:    make(map[string]*regexp.Regexp),
+		schemas:       options.Datafile.Schemas,
+		logger:        logger,
+		regexCache:    newRegexCache(options.RegexCacheSize),
+		operators:     options.Operators,
+		strictSemver:  options.StrictSemver,
+		timeZone:      options.TimeZone,
 	}
+
+	reader.features = reader.resolveFeatureSchemas(options.Datafile.Features)
+	reader.validationResult = reader.validate()
+
+	return reader
+}
+
+// GetValidationResult returns the result of validating the datafile when it was loaded
+func (d *DatafileReader) GetValidationResult() DatafileValidationResult {
+	return d.validationResult
 }
 
 // GetRevision returns the revision of the datafile
@@ -114,23 +157,91 @@ func (d *DatafileReader) HasVariations(featureKey FeatureKey) bool {
 	return feature.Variations != nil && len(feature.Variations) > 0
 }
 
-// GetRegex returns a regex pattern with caching
+// DependencyGraph returns the dependency graph of all features and segments in the datafile
+func (d *DatafileReader) DependencyGraph() *DependencyGraph {
+	return NewDependencyGraph(d.features, d.segments)
+}
+
+// GetRegex returns a compiled regex with caching, or nil if the pattern or flags are invalid
 func (d *DatafileReader) GetRegex(regexString string, regexFlags string) *regexp.Regexp {
-	flags := regexFlags
-	if flags == "" {
-		flags = ""
+	regex, err := d.regexCache.get(regexString, regexFlags)
+	if err != nil {
+		d.logger.Debug("could not compile regex", LogDetails{
+			"regex":      regexString,
+			"regexFlags": regexFlags,
+			"error":      err,
+		})
+		return nil
 	}
 
-	cacheKey := fmt.Sprintf("%s-%s", regexString, flags)
+	return regex
+}
 
-	if d.regexCache[cacheKey] != nil {
-		return d.regexCache[cacheKey]
+// conditionIsMatched checks if a plain condition is matched, using custom operators for unknown ones
+func (d *DatafileReader) conditionIsMatched(condition PlainCondition, context Context) bool {
+	getRegex := func(regexString string, regexFlags string) *regexp.Regexp {
+		return d.GetRegex(regexString, regexFlags)
 	}
 
-	regex := regexp.MustCompile(regexString)
-	d.regexCache[cacheKey] = regex
+	if IsBuiltInOperator(condition.Operator) {
+		return conditionIsMatched(condition, context, getRegex, conditionOptions{
+			strictSemver: d.strictSemver,
+			location:     d.timeZone,
+			logger:       d.logger,
+		})
+	}
 
-	return regex
+	matched, handled := matchCustomOperator(condition, context, getRegex, d.operators)
+	if !handled {
+		if _, warned := d.warnedOperators.LoadOrStore(condition.Operator, true); !warned {
+			d.logger.Warn("unknown operator", LogDetails{
+				"operator":  condition.Operator,
+				"attribute": condition.Attribute,
+			})
+		}
+	}
+
+	return matched
+}
+
+// plainConditionFromMap converts a plain condition from JSON unmarshaling, with ok being false if it is not one
+func plainConditionFromMap(conditionMap map[string]interface{}) (PlainCondition, bool) {
+	attribute, ok := conditionMap["attribute"].(string)
+	if !ok {
+		return PlainCondition{}, false
+	}
+
+	operator, ok := conditionMap["operator"].(string)
+	if !ok {
+		return PlainCondition{}, false
+	}
+
+	// Handle operators that don't have a value (exists, notExists, custom)
+	value, hasValue := conditionMap["value"]
+	if operator == "exists" || operator == "notExists" || (!hasValue && !IsBuiltInOperator(Operator(operator))) {
+		return PlainCondition{
+			Attribute: AttributeKey(attribute),
+			Operator:  Operator(operator),
+			Value:     nil, // exists/notExists and some custom operators don't have values
+		}, true
+	}
+
+	// Handle operators that have a value
+	if !hasValue {
+		return PlainCondition{}, false
+	}
+
+	conditionValue := ConditionValue(value)
+	plainCondition := PlainCondition{
+		Attribute: AttributeKey(attribute),
+		Operator:  Operator(operator),
+		Value:     &conditionValue,
+	}
+	if regexFlags, ok := conditionMap["regexFlags"].(string); ok {
+		plainCondition.RegexFlags = &regexFlags
+	}
+
+	return plainCondition, true
 }
 
 // AllConditionsAreMatched checks if all conditions are matched given a context
@@ -156,11 +267,7 @@ func (d *DatafileReader) AllConditionsAreMatched(conditions Condition, context C
 
 	// Handle plain conditions
 	if plainCondition, ok := conditions.(PlainCondition); ok {
-		getRegex := func(regexString string, regexFlags string) *regexp.Regexp {
-			return d.GetRegex(regexString, regexFlags)
-		}
-
-		matched := ConditionIsMatched(plainCondition, context, getRegex)
+		matched := d.conditionIsMatched(plainCondition, context)
 		return matched
 	}
 
@@ -168,40 +275,9 @@ func (d *DatafileReader) AllConditionsAreMatched(conditions Condition, context C
 	if conditionMap, ok := conditions.(map[string]interface{}); ok {
 
 		// Check if it's a plain condition
-		if attribute, ok := conditionMap["attribute"].(string); ok {
-			if operator, ok := conditionMap["operator"].(string); ok {
-				// Handle operators that don't have a value (exists, notExists)
-				if operator == "exists" || operator == "notExists" {
-					plainCondition := PlainCondition{
-						Attribute: AttributeKey(attribute),
-						Operator:  Operator(operator),
-						Value:     nil, // exists/notExists don't have values
-					}
-					getRegex := func(regexString string, regexFlags string) *regexp.Regexp {
-						return d.GetRegex(regexString, regexFlags)
-					}
-
-					matched := ConditionIsMatched(plainCondition, context, getRegex)
-					return matched
-				}
-
-				// Handle operators that have a value
-				if value, ok := conditionMap["value"]; ok {
-					conditionValue := ConditionValue(value)
-					plainCondition := PlainCondition{
-						Attribute: AttributeKey(attribute),
-						Operator:  Operator(operator),
-						Value:     &conditionValue,
-					}
-					getRegex := func(regexString string, regexFlags string) *regexp.Regexp {
-						return d.GetRegex(regexString, regexFlags)
-					}
-
-					// Add error handling like in TypeScript version
-					matched := ConditionIsMatched(plainCondition, context, getRegex)
-					return matched
-				}
-			}
+		if plainCondition, ok := plainConditionFromMap(conditionMap); ok {
+			matched := d.conditionIsMatched(plainCondition, context)
+			return matched
 		}
 		// Check if it's an and condition
 		if andConditions, ok := conditionMap["and"].([]interface{}); ok {
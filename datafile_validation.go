@@ -0,0 +1,303 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxRequiredDepth is the maximum depth of required features evaluated recursively
+const MaxRequiredDepth = 64
+
+// DatafileIssueType represents the type of a datafile issue
+type DatafileIssueType string
+
+const (
+	DatafileIssueTypeRequiredCycle      DatafileIssueType = "required_cycle"       // features require each other in a cycle
+	DatafileIssueTypeInvalidRegex       DatafileIssueType = "invalid_regex"        // pattern or flags of a matches condition cannot be compiled
+	DatafileIssueTypeInvalidSemverRange DatafileIssueType = "invalid_semver_range" // range of a semverSatisfies condition cannot be parsed
+	DatafileIssueTypeSchemaNotFound     DatafileIssueType = "schema_not_found"     // variable schema refers to a reusable schema missing from the datafile
+)
+
+// DatafileIssue represents a problem found in datafile content
+type DatafileIssue struct {
+	Type       DatafileIssueType `json:"type"`
+	FeatureKey FeatureKey        `json:"featureKey,omitempty"`
+	SegmentKey SegmentKey        `json:"segmentKey,omitempty"`
+	Path       []FeatureKey      `json:"path,omitempty"`
+	Message    string            `json:"message"`
+}
+
+// DatafileValidationResult represents the result of validating datafile content
+type DatafileValidationResult struct {
+	Valid  bool            `json:"valid"`
+	Issues []DatafileIssue `json:"issues,omitempty"`
+}
+
+// RequiredCycleError is returned when required features depend on each other in a cycle
+type RequiredCycleError struct {
+	Path []FeatureKey
+}
+
+func (e *RequiredCycleError) Error() string {
+	return fmt.Sprintf("required features cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// getRequiredFeature returns the feature key and optional variation of a required entry
+func getRequiredFeature(required Required) (FeatureKey, *VariationValue) {
+	switch r := required.(type) {
+	case string:
+		return FeatureKey(r), nil
+	case RequiredWithVariation:
+		variation := r.Variation
+		return r.Key, &variation
+	case map[string]interface{}:
+		key, _ := r["key"].(string)
+		if variation, ok := r["variation"].(string); ok {
+			return FeatureKey(key), &variation
+		}
+		return FeatureKey(key), nil
+	}
+
+	return "", nil
+}
+
+// findRequiredCycles finds cycles among required features, each reported once as a path from and to its first feature
+func findRequiredCycles(features map[FeatureKey]Feature) [][]FeatureKey {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	featureKeys := make([]FeatureKey, 0, len(features))
+	for featureKey := range features {
+		featureKeys = append(featureKeys, featureKey)
+	}
+	sort.Strings(featureKeys)
+
+	states := make(map[FeatureKey]int, len(features))
+	cycles := [][]FeatureKey{}
+	stack := []FeatureKey{}
+
+	var visit func(featureKey FeatureKey)
+	visit = func(featureKey FeatureKey) {
+		states[featureKey] = visiting
+		stack = append(stack, featureKey)
+
+		for _, required := range features[featureKey].Required {
+			requiredKey, _ := getRequiredFeature(required)
+			if _, exists := features[requiredKey]; !exists {
+				continue
+			}
+
+			switch states[requiredKey] {
+			case unvisited:
+				visit(requiredKey)
+			case visiting:
+				// cycle found: from the required feature's position in stack, back to itself
+				for i, key := range stack {
+					if key == requiredKey {
+						cycle := append(append([]FeatureKey{}, stack[i:]...), requiredKey)
+						cycles = append(cycles, cycle)
+						break
+					}
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		states[featureKey] = visited
+	}
+
+	for _, featureKey := range featureKeys {
+		if states[featureKey] == unvisited {
+			visit(featureKey)
+		}
+	}
+
+	return cycles
+}
+
+// getPlainConditions returns all plain conditions found in conditions of any shape
+func getPlainConditions(conditions Condition) []PlainCondition {
+	result := []PlainCondition{}
+
+	var collect func(conditions Condition)
+	collect = func(conditions Condition) {
+		switch c := conditions.(type) {
+		case string:
+			// stringified conditions
+			trimmed := strings.TrimSpace(c)
+			if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+				var parsed interface{}
+				if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+					collect(parsed)
+				}
+			}
+		case PlainCondition:
+			result = append(result, c)
+		case AndCondition:
+			collect(c.And)
+		case OrCondition:
+			collect(c.Or)
+		case NotCondition:
+			collect(c.Not)
+		case []Condition:
+			for _, condition := range c {
+				collect(condition)
+			}
+		case []interface{}:
+			for _, condition := range c {
+				collect(condition)
+			}
+		case map[string]interface{}:
+			if attribute, ok := c["attribute"].(string); ok {
+				operator, _ := c["operator"].(string)
+				plainCondition := PlainCondition{
+					Attribute: AttributeKey(attribute),
+					Operator:  Operator(operator),
+				}
+				if value, exists := c["value"]; exists {
+					conditionValue := ConditionValue(value)
+					plainCondition.Value = &conditionValue
+				}
+				if regexFlags, ok := c["regexFlags"].(string); ok {
+					plainCondition.RegexFlags = &regexFlags
+				}
+				result = append(result, plainCondition)
+				return
+			}
+
+			for _, operator := range []string{"and", "or", "not"} {
+				if value, exists := c[operator]; exists {
+					collect(value)
+				}
+			}
+		}
+	}
+
+	collect(conditions)
+
+	return result
+}
+
+// validateConditions checks values of conditions which can only be checked when evaluated, returning an issue for each invalid one
+func (d *DatafileReader) validateConditions(conditions Condition, featureKey FeatureKey, segmentKey SegmentKey) []DatafileIssue {
+	issues := []DatafileIssue{}
+
+	for _, condition := range getPlainConditions(conditions) {
+		conditionValue := ""
+		if condition.Value != nil {
+			conditionValue, _ = (*condition.Value).(string)
+		}
+
+		switch condition.Operator {
+		case OperatorMatches, OperatorNotMatches:
+			regexFlags := ""
+			if condition.RegexFlags != nil {
+				regexFlags = *condition.RegexFlags
+			}
+
+			// also warms up the cache
+			if _, err := d.regexCache.get(conditionValue, regexFlags); err != nil {
+				issues = append(issues, DatafileIssue{
+					Type:       DatafileIssueTypeInvalidRegex,
+					FeatureKey: featureKey,
+					SegmentKey: segmentKey,
+					Message:    err.Error(),
+				})
+			}
+		case OperatorSemverSatisfies:
+			if _, err := ParseSemverRange(conditionValue); err != nil {
+				issues = append(issues, DatafileIssue{
+					Type:       DatafileIssueTypeInvalidSemverRange,
+					FeatureKey: featureKey,
+					SegmentKey: segmentKey,
+					Message:    err.Error(),
+				})
+			}
+		}
+	}
+
+	return issues
+}
+
+// validate checks datafile content for problems that would break evaluation, and logs them
+func (d *DatafileReader) validate() DatafileValidationResult {
+	issues := []DatafileIssue{}
+
+	for _, cycle := range findRequiredCycles(d.features) {
+		err := &RequiredCycleError{Path: cycle}
+		issues = append(issues, DatafileIssue{
+			Type:       DatafileIssueTypeRequiredCycle,
+			FeatureKey: cycle[0],
+			Path:       cycle,
+			Message:    err.Error(),
+		})
+	}
+
+	for _, segmentKey := range sortedKeys(d.segments) {
+		issues = append(issues, d.validateConditions(d.segments[segmentKey].Conditions, "", segmentKey)...)
+	}
+
+	for _, featureKey := range sortedKeys(d.features) {
+		feature := d.features[featureKey]
+
+		for _, force := range feature.Force {
+			issues = append(issues, d.validateConditions(force.Conditions, featureKey, "")...)
+		}
+
+		for _, variation := range feature.Variations {
+			for _, variableKey := range sortedKeys(variation.VariableOverrides) {
+				for _, override := range variation.VariableOverrides[variableKey] {
+					issues = append(issues, d.validateConditions(override.Conditions, featureKey, "")...)
+				}
+			}
+		}
+
+		for _, variableKey := range sortedKeys(feature.VariablesSchema) {
+			for _, schemaKey := range getSchemaRefs(variableSchemaToSchema(feature.VariablesSchema[variableKey])) {
+				if d.GetSchema(schemaKey) == nil {
+					issues = append(issues, DatafileIssue{
+						Type:       DatafileIssueTypeSchemaNotFound,
+						FeatureKey: featureKey,
+						Message:    fmt.Sprintf("schema %q of variable %q is not found", schemaKey, variableKey),
+					})
+				}
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(d.schemas) {
+		for _, schemaKey := range getSchemaRefs(d.schemas[key]) {
+			if d.GetSchema(schemaKey) == nil {
+				issues = append(issues, DatafileIssue{
+					Type:    DatafileIssueTypeSchemaNotFound,
+					Message: fmt.Sprintf("schema %q referred to by schema %q is not found", schemaKey, key),
+				})
+			}
+		}
+	}
+
+	for _, issue := range issues {
+		details := LogDetails{
+			"type":    issue.Type,
+			"message": issue.Message,
+		}
+		if issue.FeatureKey != "" {
+			details["featureKey"] = issue.FeatureKey
+		}
+		if issue.SegmentKey != "" {
+			details["segmentKey"] = issue.SegmentKey
+		}
+
+		d.logger.Error("invalid datafile", details)
+	}
+
+	return DatafileValidationResult{
+		Valid:  len(issues) == 0,
+		Issues: issues,
+	}
+}
@@ -0,0 +1,154 @@
+package featurevisor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var requiredCycleTestDatafile = testDatafile{
+	features: `{
+		"a": {
+			"bucketBy": "userId",
+			"required": ["b"],
+			"variations": [{"value": "on"}],
+			"variablesSchema": {
+				"color": {"type": "string", "defaultValue": "red"}
+			},
+			"traffic": [
+				{
+					"key": "1",
+					"segments": "*",
+					"percentage": 100000,
+					"allocation": [{"variation": "on", "range": [0, 100000]}]
+				}
+			]
+		},
+		"b": {
+			"bucketBy": "userId",
+			"required": [{"key": "a", "variation": "on"}],
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"c": {
+			"bucketBy": "userId",
+			"required": ["a"],
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"self": {
+			"bucketBy": "userId",
+			"required": ["self"],
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"standalone": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		}
+	}`,
+}
+
+func TestDatafileValidationRequiredCycles(t *testing.T) {
+	var logged []LogDetails
+	handler := LogHandler(func(level LogLevel, message LogMessage, details LogDetails) {
+		if message == "invalid datafile" {
+			logged = append(logged, details)
+		}
+	})
+
+	reader := NewDatafileReader(DatafileReaderOptions{
+		Datafile: requiredCycleTestDatafile.build(t),
+		Logger:   NewLogger(CreateLoggerOptions{Handler: &handler}),
+	})
+
+	result := reader.GetValidationResult()
+	if result.Valid {
+		t.Fatalf("expected datafile to be invalid")
+	}
+
+	if len(result.Issues) != 2 || len(logged) != 2 {
+		t.Fatalf("expected 2 issues to be reported and logged, got %d and %d", len(result.Issues), len(logged))
+	}
+
+	if !reflect.DeepEqual(result.Issues[0].Path, []FeatureKey{"a", "b", "a"}) {
+		t.Fatalf("unexpected cycle path %v", result.Issues[0].Path)
+	}
+
+	if !reflect.DeepEqual(result.Issues[1].Path, []FeatureKey{"self", "self"}) {
+		t.Fatalf("unexpected cycle path %v", result.Issues[1].Path)
+	}
+}
+
+func TestRequiredCycleEvaluation(t *testing.T) {
+	instance := newTestInstance(t, requiredCycleTestDatafile, Options{})
+
+	if instance.GetDatafileValidationResult().Valid {
+		t.Fatalf("expected datafile validation result to be invalid")
+	}
+
+	evaluation := instance.EvaluateFlag("c", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonError {
+		t.Fatalf("expected error reason, got %s", evaluation.Reason)
+	}
+
+	var cycleError *RequiredCycleError
+	if !errors.As(evaluation.Error, &cycleError) {
+		t.Fatalf("expected required cycle error, got %v", evaluation.Error)
+	}
+
+	if !reflect.DeepEqual(cycleError.Path, []FeatureKey{"a", "b", "a"}) {
+		t.Fatalf("unexpected cycle path %v", cycleError.Path)
+	}
+
+	// variations and variables of features in a cycle are not served
+	if variation := instance.GetVariation("a", Context{"userId": "123"}); variation != nil {
+		t.Fatalf("expected no variation for feature in a cycle, got %s", *variation)
+	}
+
+	if value := instance.GetVariable("a", "color", Context{"userId": "123"}); value != nil {
+		t.Fatalf("expected no variable value for feature in a cycle, got %v", value)
+	}
+
+	evaluation = instance.EvaluateVariation("a", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonError || !errors.As(evaluation.Error, &cycleError) {
+		t.Fatalf("expected variation evaluation to carry the cycle error, got %+v", evaluation)
+	}
+
+	if instance.IsEnabled("self") {
+		t.Fatalf("expected feature requiring itself to not be enabled")
+	}
+
+	if !instance.IsEnabled("standalone") {
+		t.Fatalf("expected feature outside of cycle to be enabled")
+	}
+}
+
+func TestRequiredDepthGuard(t *testing.T) {
+	features := map[FeatureKey]Feature{}
+	for i := 0; i <= MaxRequiredDepth; i++ {
+		feature := Feature{
+			BucketBy: "userId",
+			Traffic:  []Traffic{{Key: "1", Segments: "*", Percentage: 100000}},
+		}
+		if i < MaxRequiredDepth {
+			feature.Required = []Required{toString(i + 1)}
+		}
+		features[toString(i)] = feature
+	}
+
+	instance := CreateInstance(Options{
+		Datafile: DatafileContent{SchemaVersion: "2", Revision: "1", Features: features},
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	if !instance.GetDatafileValidationResult().Valid {
+		t.Fatalf("expected chain without cycles to be valid")
+	}
+
+	evaluation := instance.EvaluateFlag("0", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonError {
+		t.Fatalf("expected error reason once max depth is exceeded, got %s", evaluation.Reason)
+	}
+
+	if !instance.IsEnabled("1", Context{"userId": "123"}) {
+		t.Fatalf("expected chain within max depth to be enabled")
+	}
+}
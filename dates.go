@@ -0,0 +1,126 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// epochMillisecondsThreshold separates epochs in seconds from epochs in milliseconds.
+// As seconds, it would be year 5138, and as milliseconds it is March 1973.
+const epochMillisecondsThreshold = 1e11
+
+// minEpochStringDigits is the least number of digits numeric strings need to be taken as epochs,
+// so shorter ones like years are not read as seconds after 1970
+const minEpochStringDigits = 10
+
+// compactDateLayout is for dates without separators, like "20240115"
+const compactDateLayout = "20060102"
+
+// zonedDateLayouts are layouts containing a time zone, tried in order
+var zonedDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.RubyDate,
+}
+
+// localDateLayouts are layouts without a time zone, parsed in the default location
+var localDateLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.ANSIC,
+}
+
+// ParseDate converts a value to time, accepting:
+//
+//   - time.Time and *time.Time
+//   - unix epochs in seconds or milliseconds, as any Go number, or json.Number and numeric strings of at least 10 digits
+//   - compact dates like "20240115", in the given location (UTC if nil)
+//   - RFC3339 (with or without fractional seconds), RFC1123 and other common layouts
+//   - layouts without a time zone, like "2006-01-02" or "2006-01-02T15:04:05", in the given location (UTC if nil)
+func ParseDate(value interface{}, location *time.Location) (time.Time, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		if v == nil {
+			return time.Time{}, fmt.Errorf("date is nil")
+		}
+		return *v, nil
+	case json.Number:
+		return parseDateString(string(v), location)
+	case string:
+		return parseDateString(v, location)
+	}
+
+	if epoch, ok := toFloat64(value); ok {
+		return parseEpoch(epoch)
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported date type %T", value)
+}
+
+func parseDateString(value string, location *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is empty")
+	}
+
+	// numeric epochs, and compact dates
+	if epoch, err := strconv.ParseFloat(value, 64); err == nil {
+		if date, err := time.ParseInLocation(compactDateLayout, value, location); err == nil {
+			return date, nil
+		}
+
+		integerPart, _, _ := strings.Cut(strings.TrimLeft(value, "+-"), ".")
+		if len(integerPart) < minEpochStringDigits {
+			return time.Time{}, fmt.Errorf("numeric date %q is too short for an epoch", value)
+		}
+
+		return parseEpoch(epoch)
+	}
+
+	for _, layout := range zonedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+
+	for _, layout := range localDateLayouts {
+		if date, err := time.ParseInLocation(layout, value, location); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported date format %q", value)
+}
+
+// parseEpoch converts unix epoch in seconds or milliseconds to time
+func parseEpoch(epoch float64) (time.Time, error) {
+	if math.IsNaN(epoch) || math.IsInf(epoch, 0) {
+		return time.Time{}, fmt.Errorf("invalid epoch %v", epoch)
+	}
+
+	if math.Abs(epoch) >= epochMillisecondsThreshold {
+		epoch = epoch / 1000
+	}
+
+	seconds, fraction := math.Modf(epoch)
+	return time.Unix(int64(seconds), int64(math.Round(fraction*1e9))).UTC(), nil
+}
@@ -0,0 +1,152 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	expected := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	expectedWithFraction := time.Date(2023, 11, 14, 22, 13, 20, 500000000, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected time.Time
+	}{
+		{"time", expected, expected},
+		{"time pointer", &expected, expected},
+		{"epoch seconds int", 1700000000, expected},
+		{"epoch seconds int64", int64(1700000000), expected},
+		{"epoch seconds uint32", uint32(1700000000), expected},
+		{"epoch seconds float", 1700000000.5, expectedWithFraction},
+		{"epoch milliseconds int64", int64(1700000000000), expected},
+		{"epoch milliseconds float", 1700000000500.0, expectedWithFraction},
+		{"epoch json number", json.Number("1700000000"), expected},
+		{"epoch string", "1700000000000", expected},
+		{"RFC3339", "2023-11-14T22:13:20Z", expected},
+		{"RFC3339 with offset", "2023-11-15T00:13:20+02:00", expected},
+		{"RFC3339 with nanoseconds", "2023-11-14T22:13:20.500000000Z", expectedWithFraction},
+		{"without zone", "2023-11-14T22:13:20", expected},
+		{"without zone with fraction", "2023-11-14T22:13:20.5", expectedWithFraction},
+		{"with space", "2023-11-14 22:13:20", expected},
+		{"RFC1123", "Tue, 14 Nov 2023 22:13:20 GMT", expected},
+		{"RFC1123Z", "Tue, 14 Nov 2023 22:13:20 +0000", expected},
+		{"date only", "2023-11-14", time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)},
+		{"compact date", "20240115", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, err := ParseDate(tt.value, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !date.Equal(tt.expected) {
+				t.Fatalf("expected %s, got %s", tt.expected, date)
+			}
+		})
+	}
+
+	// numeric strings too short for epochs are not read as seconds after 1970
+	for _, value := range []interface{}{"", "not a date", "2024", "123456789", "20241345", true, []interface{}{}, nil} {
+		if _, err := ParseDate(value, nil); err == nil {
+			t.Errorf("expected error for %v", value)
+		}
+	}
+}
+
+func TestParseDateInLocation(t *testing.T) {
+	location := time.FixedZone("UTC+2", 2*60*60)
+
+	date, err := ParseDate("2023-11-15T00:13:20", location)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !date.Equal(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)) {
+		t.Fatalf("expected date to be parsed in given location, got %s", date)
+	}
+
+	// dates with a zone are not affected
+	date, _ = ParseDate("2023-11-14T22:13:20Z", location)
+	if !date.Equal(time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)) {
+		t.Fatalf("expected zone of date to be kept, got %s", date)
+	}
+}
+
+func TestDateConditionsInDatafile(t *testing.T) {
+	datafileContent := `{
+		"schemaVersion": "2",
+		"revision": "1",
+		"segments": {
+			"beforeLaunch": {
+				"conditions": [{"attribute": "date", "operator": "before", "value": "2024-01-01T00:00:00"}]
+			}
+		},
+		"features": {
+			"beforeLaunch": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "beforeLaunch", "percentage": 100000}]
+			}
+		}
+	}`
+
+	debugMessages := 0
+	handler := LogHandler(func(level LogLevel, message LogMessage, details LogDetails) {
+		if level == LogLevelDebug && message == "could not parse date in context" {
+			debugMessages++
+		}
+	})
+
+	instance := CreateInstance(Options{
+		Datafile: datafileContent,
+		Logger:   NewLogger(CreateLoggerOptions{Level: &[]LogLevel{LogLevelDebug}[0], Handler: &handler}),
+	})
+
+	for value, expected := range map[interface{}]bool{
+		int64(1703980800):               true,  // 2023-12-31 in seconds
+		int64(1704153600000):            false, // 2024-01-02 in milliseconds
+		"Sun, 31 Dec 2023 12:00:00 GMT": true,
+		"2024-01-01T00:00:00.5":         false,
+	} {
+		if instance.IsEnabled("beforeLaunch", Context{"userId": "123", "date": value}) != expected {
+			t.Errorf("expected %v to be %v", value, expected)
+		}
+	}
+
+	if instance.IsEnabled("beforeLaunch", Context{"userId": "123", "date": "yesterday"}) {
+		t.Errorf("expected unparsable date not to match")
+	}
+	if debugMessages != 1 {
+		t.Errorf("expected debug message for unparsable date, got %d", debugMessages)
+	}
+
+	// midnight in UTC-1 is 01:00 in UTC
+	local := CreateInstance(Options{
+		Datafile: datafileContent,
+		TimeZone: time.FixedZone("UTC-1", -60*60),
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	if instance.IsEnabled("beforeLaunch", Context{"userId": "123", "date": "2024-01-01T00:30:00Z"}) {
+		t.Errorf("expected date without zone in condition to be parsed in UTC by default")
+	}
+	if !local.IsEnabled("beforeLaunch", Context{"userId": "123", "date": "2024-01-01T00:30:00Z"}) {
+		t.Errorf("expected date without zone in condition to be parsed in configured time zone")
+	}
+	if local.IsEnabled("beforeLaunch", Context{"userId": "123", "date": "2024-01-01T00:30:00"}) {
+		t.Errorf("expected dates without zone to be compared in the same time zone")
+	}
+}
+
+func TestGetValueByTypeDate(t *testing.T) {
+	date, ok := GetValueByType(int64(1700000000000), "date").(time.Time)
+	if !ok || date.Unix() != 1700000000 {
+		t.Fatalf("expected date, got %v", date)
+	}
+
+	if GetValueByType("not a date", "date") != nil {
+		t.Fatalf("expected nil for invalid date")
+	}
+}
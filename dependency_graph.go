@@ -0,0 +1,265 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SegmentUsageSource represents where in a feature a segment is used
+type SegmentUsageSource string
+
+const (
+	SegmentUsageSourceTraffic          SegmentUsageSource = "traffic"
+	SegmentUsageSourceForce            SegmentUsageSource = "force"
+	SegmentUsageSourceVariableOverride SegmentUsageSource = "variableOverride"
+)
+
+// RequiredEdge represents a feature requiring another feature
+type RequiredEdge struct {
+	FeatureKey  FeatureKey      `json:"featureKey"`
+	RequiredKey FeatureKey      `json:"requiredKey"`
+	Variation   *VariationValue `json:"variation,omitempty"` // set when a specific variation is required
+}
+
+// SegmentEdge represents a feature using a segment
+type SegmentEdge struct {
+	FeatureKey FeatureKey         `json:"featureKey"`
+	SegmentKey SegmentKey         `json:"segmentKey"`
+	Source     SegmentUsageSource `json:"source"`
+
+	RuleKey     *RuleKey        `json:"ruleKey,omitempty"`     // traffic
+	ForceIndex  *int            `json:"forceIndex,omitempty"`  // force
+	Variation   *VariationValue `json:"variation,omitempty"`   // variable override
+	VariableKey *VariableKey    `json:"variableKey,omitempty"` // variable override
+}
+
+// DependencyGraph represents how features depend on other features and segments
+type DependencyGraph struct {
+	Features      []FeatureKey   `json:"features"`
+	Segments      []SegmentKey   `json:"segments"`
+	RequiredEdges []RequiredEdge `json:"requiredEdges"`
+	SegmentEdges  []SegmentEdge  `json:"segmentEdges"`
+}
+
+// NewDependencyGraph builds a dependency graph from features and segments
+func NewDependencyGraph(features map[FeatureKey]Feature, segments map[SegmentKey]Segment) *DependencyGraph {
+	graph := &DependencyGraph{
+		Features:      sortedKeys(features),
+		Segments:      sortedKeys(segments),
+		RequiredEdges: []RequiredEdge{},
+		SegmentEdges:  []SegmentEdge{},
+	}
+	seenSegmentEdges := map[segmentEdgeKey]struct{}{}
+
+	for _, featureKey := range graph.Features {
+		feature := features[featureKey]
+
+		// required
+		for _, required := range feature.Required {
+			requiredKey, variation := getRequiredFeature(required)
+			if requiredKey == "" {
+				continue
+			}
+
+			graph.RequiredEdges = append(graph.RequiredEdges, RequiredEdge{
+				FeatureKey:  featureKey,
+				RequiredKey: requiredKey,
+				Variation:   variation,
+			})
+		}
+
+		// traffic
+		for _, traffic := range feature.Traffic {
+			ruleKey := traffic.Key
+			for _, segmentKey := range getSegmentKeys(traffic.Segments) {
+				graph.addSegmentEdge(seenSegmentEdges, SegmentEdge{
+					FeatureKey: featureKey,
+					SegmentKey: segmentKey,
+					Source:     SegmentUsageSourceTraffic,
+					RuleKey:    &ruleKey,
+				})
+			}
+		}
+
+		// force
+		for i, force := range feature.Force {
+			forceIndex := i
+			for _, segmentKey := range getSegmentKeys(force.Segments) {
+				graph.addSegmentEdge(seenSegmentEdges, SegmentEdge{
+					FeatureKey: featureKey,
+					SegmentKey: segmentKey,
+					Source:     SegmentUsageSourceForce,
+					ForceIndex: &forceIndex,
+				})
+			}
+		}
+
+		// variable overrides
+		for _, variation := range feature.Variations {
+			variationValue := variation.Value
+			for _, variableKey := range sortedKeys(variation.VariableOverrides) {
+				variableKey := variableKey
+				for _, override := range variation.VariableOverrides[variableKey] {
+					for _, segmentKey := range getSegmentKeys(override.Segments) {
+						graph.addSegmentEdge(seenSegmentEdges, SegmentEdge{
+							FeatureKey:  featureKey,
+							SegmentKey:  segmentKey,
+							Source:      SegmentUsageSourceVariableOverride,
+							Variation:   &variationValue,
+							VariableKey: &variableKey,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	return graph
+}
+
+// segmentEdgeKey identifies a segment edge by its values, for deduplicating edges
+type segmentEdgeKey struct {
+	featureKey FeatureKey
+	segmentKey SegmentKey
+	usage      string
+}
+
+func (g *DependencyGraph) addSegmentEdge(seen map[segmentEdgeKey]struct{}, edge SegmentEdge) {
+	// the same segment can be used more than once in the same place, like in two overrides of a variable
+	key := segmentEdgeKey{edge.FeatureKey, edge.SegmentKey, edge.describe()}
+	if _, exists := seen[key]; exists {
+		return
+	}
+	seen[key] = struct{}{}
+
+	g.SegmentEdges = append(g.SegmentEdges, edge)
+}
+
+// describe returns a short human readable description of where the segment is used
+func (e SegmentEdge) describe() string {
+	switch e.Source {
+	case SegmentUsageSourceTraffic:
+		if e.RuleKey != nil {
+			return fmt.Sprintf("traffic: %s", *e.RuleKey)
+		}
+	case SegmentUsageSourceForce:
+		if e.ForceIndex != nil {
+			return fmt.Sprintf("force: %d", *e.ForceIndex)
+		}
+	case SegmentUsageSourceVariableOverride:
+		if e.Variation != nil && e.VariableKey != nil {
+			return fmt.Sprintf("variable override: %s.%s", *e.Variation, *e.VariableKey)
+		}
+	}
+
+	return string(e.Source)
+}
+
+// GetRequired returns the features required by the given feature
+func (g *DependencyGraph) GetRequired(featureKey FeatureKey) []RequiredEdge {
+	result := []RequiredEdge{}
+	for _, edge := range g.RequiredEdges {
+		if edge.FeatureKey == featureKey {
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
+// GetRequiredBy returns the keys of features requiring the given feature
+func (g *DependencyGraph) GetRequiredBy(featureKey FeatureKey) []FeatureKey {
+	keys := map[FeatureKey]bool{}
+	for _, edge := range g.RequiredEdges {
+		if edge.RequiredKey == featureKey {
+			keys[edge.FeatureKey] = true
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// GetSegments returns the keys of segments used by the given feature
+func (g *DependencyGraph) GetSegments(featureKey FeatureKey) []SegmentKey {
+	keys := map[SegmentKey]bool{}
+	for _, edge := range g.SegmentEdges {
+		if edge.FeatureKey == featureKey {
+			keys[edge.SegmentKey] = true
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// GetSegmentEdges returns all usages of segments by the given feature
+func (g *DependencyGraph) GetSegmentEdges(featureKey FeatureKey) []SegmentEdge {
+	result := []SegmentEdge{}
+	for _, edge := range g.SegmentEdges {
+		if edge.FeatureKey == featureKey {
+			result = append(result, edge)
+		}
+	}
+	return result
+}
+
+// GetFeaturesUsingSegment returns the keys of features using the given segment
+func (g *DependencyGraph) GetFeaturesUsingSegment(segmentKey SegmentKey) []FeatureKey {
+	keys := map[FeatureKey]bool{}
+	for _, edge := range g.SegmentEdges {
+		if edge.SegmentKey == segmentKey {
+			keys[edge.FeatureKey] = true
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// GetFeaturesSharingSegments returns the keys of other features using any of the segments of the given feature
+func (g *DependencyGraph) GetFeaturesSharingSegments(featureKey FeatureKey) []FeatureKey {
+	keys := map[FeatureKey]bool{}
+	for _, segmentKey := range g.GetSegments(featureKey) {
+		for _, otherFeatureKey := range g.GetFeaturesUsingSegment(segmentKey) {
+			if otherFeatureKey != featureKey {
+				keys[otherFeatureKey] = true
+			}
+		}
+	}
+	return sortedKeys(keys)
+}
+
+// ToJSON converts the graph to JSON string
+func (g *DependencyGraph) ToJSON() (string, error) {
+	bytes, err := json.Marshal(g)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal DependencyGraph to JSON: %w", err)
+	}
+	return string(bytes), nil
+}
+
+// ToDOT converts the graph to Graphviz DOT format
+func (g *DependencyGraph) ToDOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph featurevisor {\n")
+
+	for _, featureKey := range g.Features {
+		fmt.Fprintf(&b, "  %q [label=%q, shape=box];\n", "feature:"+featureKey, featureKey)
+	}
+
+	for _, segmentKey := range g.Segments {
+		fmt.Fprintf(&b, "  %q [label=%q, shape=ellipse];\n", "segment:"+segmentKey, segmentKey)
+	}
+
+	for _, edge := range g.RequiredEdges {
+		label := "requires"
+		if edge.Variation != nil {
+			label = fmt.Sprintf("requires (variation: %s)", *edge.Variation)
+		}
+		fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", "feature:"+edge.FeatureKey, "feature:"+edge.RequiredKey, label)
+	}
+
+	for _, edge := range g.SegmentEdges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, style=dashed];\n", "feature:"+edge.FeatureKey, "segment:"+edge.SegmentKey, edge.describe())
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var dependencyGraphTestDatafile = testDatafile{
+	segments: `{
+		"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]},
+		"germany": {"conditions": [{"attribute": "country", "operator": "equals", "value": "de"}]},
+		"qa": {"conditions": [{"attribute": "qa", "operator": "equals", "value": true}]},
+		"unused": {"conditions": "*"}
+	}`,
+	features: `{
+		"base": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"checkout": {
+			"bucketBy": "userId",
+			"required": ["base", {"key": "theme", "variation": "dark"}],
+			"force": [{"segments": ["qa"], "enabled": true}],
+			"variations": [
+				{
+					"value": "control",
+					"variableOverrides": {
+						"color": [
+							{"segments": {"not": ["germany"]}, "value": "red"},
+							{"segments": "germany", "value": "blue"}
+						]
+					}
+				}
+			],
+			"traffic": [
+				{"key": "nl", "segments": {"or": ["netherlands", "germany"]}, "percentage": 100000},
+				{"key": "everyone", "segments": "*", "percentage": 0}
+			]
+		},
+		"theme": {
+			"bucketBy": "userId",
+			"variations": [{"value": "light"}, {"value": "dark"}],
+			"traffic": [{"key": "nl", "segments": "[\"netherlands\"]", "percentage": 100000}]
+		}
+	}`,
+}
+
+func TestDependencyGraph(t *testing.T) {
+	instance := newTestInstance(t, dependencyGraphTestDatafile, Options{})
+
+	graph := instance.DependencyGraph()
+
+	required := graph.GetRequired("checkout")
+	if len(required) != 2 || required[0].RequiredKey != "base" || required[0].Variation != nil {
+		t.Fatalf("unexpected required edges %+v", required)
+	}
+	if required[1].RequiredKey != "theme" || required[1].Variation == nil || *required[1].Variation != "dark" {
+		t.Fatalf("expected variation constraint for theme, got %+v", required[1])
+	}
+
+	if requiredBy := graph.GetRequiredBy("theme"); !reflect.DeepEqual(requiredBy, []FeatureKey{"checkout"}) {
+		t.Fatalf("unexpected reverse required lookup %v", requiredBy)
+	}
+
+	if segments := graph.GetSegments("checkout"); !reflect.DeepEqual(segments, []SegmentKey{"germany", "netherlands", "qa"}) {
+		t.Fatalf("unexpected segments %v", segments)
+	}
+
+	sources := map[SegmentUsageSource]int{}
+	for _, edge := range graph.GetSegmentEdges("checkout") {
+		sources[edge.Source]++
+	}
+	if sources[SegmentUsageSourceTraffic] != 2 || sources[SegmentUsageSourceForce] != 1 || sources[SegmentUsageSourceVariableOverride] != 1 {
+		t.Fatalf("unexpected segment edge sources %v", sources)
+	}
+
+	if features := graph.GetFeaturesUsingSegment("netherlands"); !reflect.DeepEqual(features, []FeatureKey{"checkout", "theme"}) {
+		t.Fatalf("unexpected features using segment %v", features)
+	}
+
+	if features := graph.GetFeaturesSharingSegments("theme"); !reflect.DeepEqual(features, []FeatureKey{"checkout"}) {
+		t.Fatalf("unexpected features sharing segments %v", features)
+	}
+
+	if features := graph.GetFeaturesUsingSegment("unused"); len(features) != 0 {
+		t.Fatalf("expected no features using unused segment, got %v", features)
+	}
+}
+
+func TestDependencyGraphExport(t *testing.T) {
+	instance := newTestInstance(t, dependencyGraphTestDatafile, Options{})
+
+	graph := instance.DependencyGraph()
+
+	dot := graph.ToDOT()
+	for _, expected := range []string{
+		`digraph featurevisor {`,
+		`"feature:checkout" -> "feature:theme" [label="requires (variation: dark)"];`,
+		`"feature:checkout" -> "segment:qa" [label="force: 0", style=dashed];`,
+		`"feature:checkout" -> "segment:germany" [label="variable override: control.color", style=dashed];`,
+	} {
+		if !strings.Contains(dot, expected) {
+			t.Fatalf("expected DOT output to contain %q, got:\n%s", expected, dot)
+		}
+	}
+
+	jsonString, err := graph.ToJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed DependencyGraph
+	if err := json.Unmarshal([]byte(jsonString), &parsed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(&parsed, graph) {
+		t.Fatalf("expected graph to round-trip through JSON")
+	}
+}
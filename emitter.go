@@ -9,9 +9,10 @@ import (
 type EventName string
 
 const (
-	EventNameDatafileSet EventName = "datafile_set"
-	EventNameContextSet  EventName = "context_set"
-	EventNameStickySet   EventName = "sticky_set"
+	EventNameDatafileSet  EventName = "datafile_set"
+	EventNameContextSet   EventName = "context_set"
+	EventNameStickySet    EventName = "sticky_set"
+	EventNameOverridesSet EventName = "overrides_set"
 )
 
 // EventDetails represents additional details for events
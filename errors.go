@@ -0,0 +1,159 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// sentinelError is an error with a code, to be matched with errors.Is
+type sentinelError struct {
+	code    EvaluationErrorCode
+	message string
+}
+
+func (e *sentinelError) Error() string {
+	return e.message
+}
+
+// ErrorCode returns the code of the error
+func (e *sentinelError) ErrorCode() EvaluationErrorCode {
+	return e.code
+}
+
+var (
+	// ErrFeatureNotFound is returned when the feature is not found in the datafile
+	ErrFeatureNotFound error = &sentinelError{EvaluationErrorCodeFeatureNotFound, "feature not found"}
+
+	// ErrVariableNotFound is returned when the variable is not found in the feature's schema
+	ErrVariableNotFound error = &sentinelError{EvaluationErrorCodeVariableNotFound, "variable not found"}
+
+	// ErrTypeMismatch is returned when the variable value is not of the requested type
+	ErrTypeMismatch error = &sentinelError{EvaluationErrorCodeTypeMismatch, "type mismatch"}
+
+	// ErrInvalidJSON is returned when the value of a json variable can not be parsed
+	ErrInvalidJSON error = &sentinelError{EvaluationErrorCodeInvalidJSON, "invalid JSON"}
+
+	// ErrNotReady is returned when evaluating before any datafile is set
+	ErrNotReady error = &sentinelError{EvaluationErrorCodeNotReady, "not ready"}
+)
+
+// sentinelErrors by their codes
+var sentinelErrors = map[EvaluationErrorCode]error{
+	EvaluationErrorCodeFeatureNotFound:  ErrFeatureNotFound,
+	EvaluationErrorCodeVariableNotFound: ErrVariableNotFound,
+	EvaluationErrorCodeTypeMismatch:     ErrTypeMismatch,
+	EvaluationErrorCodeInvalidJSON:      ErrInvalidJSON,
+	EvaluationErrorCodeNotReady:         ErrNotReady,
+}
+
+// Is matches the sentinel error of the same code, so errors of deserialized evaluations work with errors.Is
+func (e *EvaluationError) Is(target error) bool {
+	sentinel, exists := sentinelErrors[e.Code]
+	return exists && sentinel == target
+}
+
+// getEvaluationError returns the error of an evaluation, including features and variables not found
+func getEvaluationError(evaluation Evaluation, ready bool) error {
+	if evaluation.Error != nil {
+		return evaluation.Error
+	}
+
+	switch evaluation.Reason {
+	case EvaluationReasonFeatureNotFound:
+		if !ready {
+			return fmt.Errorf("%w: feature %q is evaluated before any datafile is set", ErrNotReady, evaluation.FeatureKey)
+		}
+
+		return fmt.Errorf("%w: %q", ErrFeatureNotFound, evaluation.FeatureKey)
+	case EvaluationReasonVariableNotFound:
+		variableKey := ""
+		if evaluation.VariableKey != nil {
+			variableKey = *evaluation.VariableKey
+		}
+
+		return fmt.Errorf("%w: %q in feature %q", ErrVariableNotFound, variableKey, evaluation.FeatureKey)
+	}
+
+	return nil
+}
+
+// newTypeMismatchError returns an error for a variable value not of the requested type
+func newTypeMismatchError(variableKey string, variableType VariableType, value VariableValue) error {
+	return fmt.Errorf("%w: variable %q is not %s, got %T", ErrTypeMismatch, variableKey, variableType, value)
+}
+
+// toVariableValue returns the value of a variable evaluation for GetVariableE, keeping the error it was evaluated with.
+// Stringified json values are parsed, with ErrInvalidJSON if they can not be, and default values are converted to their type.
+func toVariableValue(evaluation Evaluation, err error, variableKey string, logger *Logger) (VariableValue, error) {
+	if evaluation.VariableValue == nil {
+		return nil, err
+	}
+
+	if evaluation.VariableSchema != nil && evaluation.VariableSchema.Type == "json" {
+		if variableStr, ok := evaluation.VariableValue.(string); ok {
+			var parsedJSON interface{}
+			if parseErr := json.Unmarshal([]byte(variableStr), &parsedJSON); parseErr == nil {
+				return parsedJSON, err
+			} else {
+				logger.Error("could not parse JSON variable", LogDetails{
+					"featureKey":  evaluation.FeatureKey,
+					"variableKey": variableKey,
+					"error":       parseErr,
+				})
+
+				err = fmt.Errorf("%w: variable %q: %v", ErrInvalidJSON, variableKey, parseErr)
+			}
+		}
+	}
+
+	// default values, including ones served for invalid values, are converted to their type
+	if evaluation.VariableSchema != nil && (evaluation.Reason == EvaluationReasonVariableDefault || evaluation.Reason == EvaluationReasonVariableInvalid) {
+		return GetValueByType(evaluation.VariableValue, string(evaluation.VariableSchema.Type)), err
+	}
+
+	return evaluation.VariableValue, err
+}
+
+// toTypedVariable converts a variable value for typed getters, keeping the error it was evaluated with
+func toTypedVariable[T any](value VariableValue, err error, variableKey string, variableType VariableType) (*T, error) {
+	if value == nil {
+		return nil, err
+	}
+
+	if typedValue, ok := GetValueByType(value, string(variableType)).(T); ok {
+		return &typedValue, err
+	}
+
+	return nil, newTypeMismatchError(variableKey, variableType, value)
+}
+
+// toArrayVariable converts a variable value for GetVariableArrayE, keeping the error it was evaluated with
+func toArrayVariable(value VariableValue, err error, variableKey string) ([]string, error) {
+	if value == nil {
+		return nil, err
+	}
+
+	if typedValue := ToTypedArray[string](GetValueByType(value, "array")); typedValue != nil {
+		return typedValue, err
+	}
+
+	return nil, newTypeMismatchError(variableKey, VariableTypeArray, value)
+}
+
+// toObjectVariable converts a variable value for GetVariableObjectE, keeping the error it was evaluated with
+func toObjectVariable(value VariableValue, err error, variableKey string) (map[string]interface{}, error) {
+	if value == nil {
+		return nil, err
+	}
+
+	if typedValue := ToTypedObject[map[string]interface{}](GetValueByType(value, "object")); typedValue != nil {
+		return *typedValue, err
+	}
+
+	return nil, newTypeMismatchError(variableKey, VariableTypeObject, value)
+}
+
+// newPanicError returns an error for a panic recovered while getting values
+func newPanicError(r interface{}) error {
+	return &EvaluationError{Code: EvaluationErrorCodePanic, Message: fmt.Sprintf("panic: %v", r)}
+}
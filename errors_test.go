@@ -0,0 +1,172 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errorsTestDatafile = testDatafile{
+	features: `{
+		"checkout": {
+			"bucketBy": "userId",
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"variablesSchema": {
+				"title": {"type": "string", "defaultValue": "Checkout"},
+				"limit": {"type": "integer", "defaultValue": 10, "maximum": 100},
+				"config": {"type": "json", "defaultValue": "{not json"},
+				"theme": {"type": "json", "defaultValue": "{\"color\": \"red\"}"}
+			},
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"variables": {"limit": 500},
+					"allocation": [
+						{"variation": "control", "range": [0, 100000]}
+					]
+				}
+			]
+		}
+	}`,
+}
+
+func TestErrorReturningMethods(t *testing.T) {
+	instance := newTestInstance(t, errorsTestDatafile, Options{})
+	context := Context{"userId": "123"}
+
+	if enabled, err := instance.IsEnabledE("checkout", context); !enabled || err != nil {
+		t.Errorf("expected enabled without error, got %v, %v", enabled, err)
+	}
+	if variation, err := instance.GetVariationE("checkout", context); variation == nil || *variation != "control" || err != nil {
+		t.Errorf("expected variation without error, got %v, %v", variationOrNil(variation), err)
+	}
+
+	if enabled, err := instance.IsEnabledE("unknown", context); enabled || !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound, got %v, %v", enabled, err)
+	}
+	if _, err := instance.GetVariationE("unknown", context); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound, got %v", err)
+	}
+
+	if value, err := instance.GetVariableStringE("checkout", "unknown", context); value != nil || !errors.Is(err, ErrVariableNotFound) {
+		t.Errorf("expected ErrVariableNotFound, got %v, %v", value, err)
+	}
+
+	// type mismatch
+	value, err := instance.GetVariableIntegerE("checkout", "title", context)
+	if value != nil || !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %v, %v", value, err)
+	}
+	if GetEvaluationErrorCode(err) != EvaluationErrorCodeTypeMismatch {
+		t.Errorf("unexpected error code %q", GetEvaluationErrorCode(err))
+	}
+	if instance.GetVariableInteger("checkout", "title", context) != nil {
+		t.Error("expected nil from GetVariableInteger")
+	}
+
+	// invalid JSON
+	_, err = instance.GetVariableJSONE("checkout", "config", context)
+	if !errors.Is(err, ErrInvalidJSON) || errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrInvalidJSON for invalid JSON, got %v", err)
+	}
+	if GetEvaluationErrorCode(err) != EvaluationErrorCodeInvalidJSON {
+		t.Errorf("unexpected error code %q", GetEvaluationErrorCode(err))
+	}
+
+	// invalid values fall back to default, with the error
+	limit, err := instance.GetVariableIntegerE("checkout", "limit", context)
+	var schemaError *VariableSchemaError
+	if limit == nil || *limit != 10 || !errors.As(err, &schemaError) {
+		t.Errorf("expected default value with schema error, got %v, %v", limit, err)
+	}
+
+	// child
+	child := instance.Spawn(context)
+	if title, err := child.GetVariableStringE("checkout", "title"); title == nil || *title != "Checkout" || err != nil {
+		t.Errorf("expected title without error, got %v, %v", title, err)
+	}
+	if _, err := child.GetVariableArrayE("checkout", "title"); !errors.Is(err, ErrTypeMismatch) {
+		t.Errorf("expected ErrTypeMismatch, got %v", err)
+	}
+	if _, err := child.IsEnabledE("unknown"); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound, got %v", err)
+	}
+	if _, err := child.GetVariableJSONE("checkout", "config"); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON from child, got %v", err)
+	}
+	if limit, err := child.GetVariableIntegerE("checkout", "limit"); limit == nil || *limit != 10 || !errors.As(err, &schemaError) {
+		t.Errorf("expected default value with schema error from child, got %v, %v", limit, err)
+	}
+
+	// child and instance give the same values
+	for _, variableKey := range []string{"title", "limit", "config", "theme"} {
+		if childValue, instanceValue := child.GetVariable("checkout", variableKey), instance.GetVariable("checkout", variableKey, context); !reflect.DeepEqual(childValue, instanceValue) {
+			t.Errorf("expected same value of %q from child and instance, got %#v and %#v", variableKey, childValue, instanceValue)
+		}
+	}
+	if theme, err := child.GetVariableJSONE("checkout", "theme"); err != nil || !reflect.DeepEqual(theme, map[string]interface{}{"color": "red"}) {
+		t.Errorf("expected parsed json from child, got %v, %v", theme, err)
+	}
+
+	// not strict
+	if evaluation := instance.EvaluateFlag("unknown", context, OverrideOptions{}); evaluation.Error != nil {
+		t.Errorf("expected no error in evaluation, got %v", evaluation.Error)
+	}
+}
+
+func TestErrNotReady(t *testing.T) {
+	instance := CreateInstance(Options{
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	if _, err := instance.IsEnabledE("checkout", Context{"userId": "123"}); !errors.Is(err, ErrNotReady) {
+		t.Errorf("expected ErrNotReady, got %v", err)
+	}
+
+	instance.SetDatafile(errorsTestDatafile.build(t))
+
+	if _, err := instance.IsEnabledE("unknown", Context{"userId": "123"}); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound once ready, got %v", err)
+	}
+}
+
+func TestStrictEvaluationErrors(t *testing.T) {
+	instance := newTestInstance(t, errorsTestDatafile, Options{Strict: true})
+	context := Context{"userId": "123"}
+
+	evaluation := instance.EvaluateFlag("unknown", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonFeatureNotFound || !errors.Is(evaluation.Error, ErrFeatureNotFound) {
+		t.Errorf("expected ErrFeatureNotFound in evaluation, got %+v", evaluation)
+	}
+
+	evaluation = instance.EvaluateVariable("checkout", "unknown", context, OverrideOptions{})
+	if !errors.Is(evaluation.Error, ErrVariableNotFound) {
+		t.Errorf("expected ErrVariableNotFound in evaluation, got %+v", evaluation)
+	}
+
+	if evaluation := instance.EvaluateVariable("checkout", "title", context, OverrideOptions{}); evaluation.Error != nil {
+		t.Errorf("expected no error, got %v", evaluation.Error)
+	}
+
+	// serialized with its code, and matched after deserializing
+	bytes, err := json.Marshal(evaluation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed Evaluation
+	if err := json.Unmarshal(bytes, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(parsed.Error, ErrVariableNotFound) || errors.Is(parsed.Error, ErrFeatureNotFound) {
+		t.Errorf("expected deserialized error to match ErrVariableNotFound only, got %s", bytes)
+	}
+
+	notReady := CreateInstance(Options{Strict: true, LogLevel: &[]LogLevel{LogLevelFatal}[0]})
+	if evaluation := notReady.EvaluateFlag("checkout", context, OverrideOptions{}); !errors.Is(evaluation.Error, ErrNotReady) {
+		t.Errorf("expected ErrNotReady in evaluation, got %+v", evaluation)
+	}
+}
@@ -2,7 +2,6 @@ package featurevisor
 
 import (
 	"fmt"
-	"sort"
 )
 
 // EvaluateParams contains parameters for evaluation
@@ -18,23 +17,57 @@ type EvaluateDependencies struct {
 	Logger         *Logger
 	HooksManager   *HooksManager
 	DatafileReader *DatafileReader
+	Overrides      *OverridesManager
 
 	// OverrideOptions
-	Sticky *StickyFeatures
+	Sticky      *StickyFeatures
+	StickyStore *StickyStoreOptions
 
 	DefaultVariationValue *VariationValue
 	DefaultVariableValue  VariableValue
+
+	// MissingBucketBy handles attributes of bucketBy missing in context (ignored by default)
+	MissingBucketBy *MissingBucketByOptions
+
+	// Strict populates Evaluation.Error for features and variables not found
+	Strict bool
+
+	// no datafile is set yet
+	notReady bool
+
+	// features whose required features are being evaluated
+	requiredPath []FeatureKey
+}
+
+// getRequiredCyclePath returns the cycle path if requiredKey is already being evaluated, or nil
+func getRequiredCyclePath(requiredPath []FeatureKey, requiredKey FeatureKey) []FeatureKey {
+	for i, key := range requiredPath {
+		if key == requiredKey {
+			return append(append([]FeatureKey{}, requiredPath[i:]...), requiredKey)
+		}
+	}
+
+	return nil
 }
 
 // EvaluateOptions contains all options for evaluation
 type EvaluateOptions struct {
 	EvaluateParams
 	EvaluateDependencies
+
+	// Evaluation can be set by before hooks to skip evaluating, and be used as the result instead
+	Evaluation *Evaluation
+
+	// recorder collects the steps reached, for Explain
+	recorder *explainRecorder
 }
 
 // EvaluateWithHooks evaluates a feature with hooks
-func EvaluateWithHooks(opts EvaluateOptions) Evaluation {
-	var evaluation Evaluation
+func EvaluateWithHooks(opts EvaluateOptions) (evaluation Evaluation) {
+	hooksManager := opts.HooksManager
+	hooks := hooksManager.GetMatching(opts.FeatureKey, opts.Type)
+
+	options := opts
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -48,24 +81,32 @@ func EvaluateWithHooks(opts EvaluateOptions) Evaluation {
 				FeatureKey:  opts.FeatureKey,
 				VariableKey: opts.VariableKey,
 				Reason:      EvaluationReasonError,
-				Error:       fmt.Errorf("panic: %v", r),
+				Error:       &EvaluationError{Code: EvaluationErrorCodePanic, Message: fmt.Sprintf("panic: %v", r)},
 			}
+
+			evaluation = runErrorHooks(hooks, evaluation, options)
 		}
-	}()
 
-	hooksManager := opts.HooksManager
-	hooks := hooksManager.GetAll()
+		runFinallyHooks(hooks, evaluation, options)
+	}()
 
 	// run before hooks
-	options := opts
 	for _, hook := range hooks {
 		if hook.Before != nil {
 			options = hook.Before(options)
 		}
 	}
 
-	// evaluate
-	evaluation = Evaluate(options)
+	// evaluate, unless a before hook already provided the evaluation
+	if options.Evaluation != nil {
+		evaluation = *options.Evaluation
+
+		opts.Logger.Debug("evaluation provided by before hook", LogDetails{
+			"evaluation": evaluation,
+		})
+	} else {
+		evaluation = Evaluate(options)
+	}
 
 	// default: variation
 	if opts.DefaultVariationValue != nil &&
@@ -81,6 +122,11 @@ func EvaluateWithHooks(opts EvaluateOptions) Evaluation {
 		evaluation.VariableValue = opts.DefaultVariableValue
 	}
 
+	// run error hooks
+	if evaluation.Reason == EvaluationReasonError {
+		evaluation = runErrorHooks(hooks, evaluation, options)
+	}
+
 	// run after hooks
 	for _, hook := range hooks {
 		if hook.After != nil {
@@ -91,10 +137,54 @@ func EvaluateWithHooks(opts EvaluateOptions) Evaluation {
 	return evaluation
 }
 
-// Evaluate evaluates a feature
-func Evaluate(options EvaluateOptions) Evaluation {
-	var evaluation Evaluation
+// runErrorHooks runs error hooks, each of which can replace the evaluation
+func runErrorHooks(hooks []*Hook, evaluation Evaluation, options EvaluateOptions) Evaluation {
+	for _, hook := range hooks {
+		if hook.OnError == nil {
+			continue
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					options.Logger.Error("panic in error hook", LogDetails{
+						"hook":  hook.Name,
+						"error": r,
+					})
+				}
+			}()
+
+			evaluation = hook.OnError(evaluation, options)
+		}()
+	}
+
+	return evaluation
+}
+
+// runFinallyHooks runs finally hooks, which always run last
+func runFinallyHooks(hooks []*Hook, evaluation Evaluation, options EvaluateOptions) {
+	for _, hook := range hooks {
+		if hook.Finally == nil {
+			continue
+		}
+
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					options.Logger.Error("panic in finally hook", LogDetails{
+						"hook":  hook.Name,
+						"error": r,
+					})
+				}
+			}()
+
+			hook.Finally(evaluation, options)
+		}()
+	}
+}
 
+// Evaluate evaluates a feature
+func Evaluate(options EvaluateOptions) (evaluation Evaluation) {
 	defer func() {
 		if r := recover(); r != nil {
 			// Log the panic and return an error evaluation
@@ -108,8 +198,18 @@ func Evaluate(options EvaluateOptions) Evaluation {
 				FeatureKey:  options.FeatureKey,
 				VariableKey: options.VariableKey,
 				Reason:      EvaluationReasonError,
-				Error:       fmt.Errorf("panic: %v", r),
+				Error:       &EvaluationError{Code: EvaluationErrorCodePanic, Message: fmt.Sprintf("panic: %v", r)},
 			}
+
+			return
+		}
+
+		// values from rules, force, variations, overrides and sticky are checked against the variable schema
+		evaluation = validateVariableEvaluation(evaluation, options)
+
+		// features and variables not found are errors in strict mode
+		if options.Strict && evaluation.Error == nil {
+			evaluation.Error = getEvaluationError(evaluation, !options.notReady)
 		}
 	}()
 
@@ -136,9 +236,77 @@ func Evaluate(options EvaluateOptions) Evaluation {
 		})
 	}
 
+	/**
+	 * Local overrides
+	 */
+	override, source, exists := options.Overrides.Get(options.FeatureKey)
+	options.recorder.recordOverride(override, source, exists)
+
+	if exists {
+		// flag
+		if options.Type == EvaluationTypeFlag {
+			evaluation = Evaluation{
+				Type:       options.Type,
+				FeatureKey: options.FeatureKey,
+				Reason:     EvaluationReasonLocalOverride,
+				Override:   override,
+				Enabled:    &override.Enabled,
+			}
+
+			options.Logger.Debug("using local override enabled", LogDetails{
+				"source":     source,
+				"evaluation": evaluation,
+			})
+
+			return evaluation
+		}
+
+		// variation
+		if options.Type == EvaluationTypeVariation && override.Variation != nil {
+			variationValue := *override.Variation
+			evaluation = Evaluation{
+				Type:           options.Type,
+				FeatureKey:     options.FeatureKey,
+				Reason:         EvaluationReasonLocalOverride,
+				Override:       override,
+				VariationValue: &variationValue,
+			}
+
+			options.Logger.Debug("using local override variation", LogDetails{
+				"source":     source,
+				"evaluation": evaluation,
+			})
+
+			return evaluation
+		}
+
+		// variable
+		if options.Type == EvaluationTypeVariable && options.VariableKey != nil && override.Variables != nil {
+			if variableValue, exists := override.Variables[*options.VariableKey]; exists {
+				evaluation = Evaluation{
+					Type:          options.Type,
+					FeatureKey:    options.FeatureKey,
+					Reason:        EvaluationReasonLocalOverride,
+					Override:      override,
+					VariableKey:   options.VariableKey,
+					VariableValue: variableValue,
+				}
+
+				options.Logger.Debug("using local override variable", LogDetails{
+					"source":     source,
+					"evaluation": evaluation,
+				})
+
+				return evaluation
+			}
+		}
+	}
+
 	/**
 	 * Sticky
 	 */
+	options.recorder.recordSticky(options.Sticky, options.FeatureKey)
+
 	if options.Sticky != nil {
 		if stickyFeature, exists := (*options.Sticky)[options.FeatureKey]; exists {
 			// flag
@@ -259,8 +427,26 @@ func Evaluate(options EvaluateOptions) Evaluation {
 				FeatureKey: options.FeatureKey,
 			},
 			EvaluateDependencies: options.EvaluateDependencies,
+			recorder:             options.recorder.child(),
 		})
 
+		// flag could not be evaluated, like for features in a required cycle
+		if flag.Reason == EvaluationReasonError {
+			evaluation = Evaluation{
+				Type:        options.Type,
+				FeatureKey:  options.FeatureKey,
+				VariableKey: options.VariableKey,
+				Reason:      EvaluationReasonError,
+				Error:       flag.Error,
+			}
+
+			options.Logger.Debug("feature flag could not be evaluated", LogDetails{
+				"evaluation": evaluation,
+			})
+
+			return evaluation
+		}
+
 		if flag.Enabled != nil && !*flag.Enabled {
 			evaluation = Evaluation{
 				Type:       options.Type,
@@ -310,6 +496,14 @@ func Evaluate(options EvaluateOptions) Evaluation {
 				}
 			}
 
+			// disabled for missing bucketBy attributes
+			if flag.Reason == EvaluationReasonBucketByMissing {
+				if evaluation.Reason == EvaluationReasonDisabled {
+					evaluation.Reason = EvaluationReasonBucketByMissing
+				}
+				evaluation.BucketByMissing = flag.BucketByMissing
+			}
+
 			options.Logger.Debug("feature is disabled", LogDetails{
 				"evaluation": evaluation,
 			})
@@ -322,6 +516,7 @@ func Evaluate(options EvaluateOptions) Evaluation {
 	 * Forced
 	 */
 	forceResult := options.DatafileReader.GetMatchedForce(feature, options.Context)
+	options.recorder.recordForce(options.DatafileReader, feature, options.Context, forceResult.ForceIndex)
 
 	if forceResult.Force != nil {
 		force := forceResult.Force
@@ -397,28 +592,63 @@ func Evaluate(options EvaluateOptions) Evaluation {
 	if options.Type == EvaluationTypeFlag && feature.Required != nil && len(feature.Required) > 0 {
 		requiredFeaturesAreEnabled := true
 
-		for _, required := range feature.Required {
-			var requiredKey FeatureKey
-			var requiredVariation *VariationValue
+		// keep track of features being evaluated, to guard against cycles
+		requiredPath := append(append([]FeatureKey{}, options.requiredPath...), options.FeatureKey)
+		requiredDependencies := options.EvaluateDependencies
+		requiredDependencies.requiredPath = requiredPath
+
+		for index, required := range feature.Required {
+			requiredKey, requiredVariation := getRequiredFeature(required)
+
+			if cyclePath := getRequiredCyclePath(requiredPath, requiredKey); cyclePath != nil || len(requiredPath) >= MaxRequiredDepth {
+				if cyclePath == nil {
+					cyclePath = append(append([]FeatureKey{}, requiredPath...), requiredKey)
+				}
+
+				evaluation = Evaluation{
+					Type:       options.Type,
+					FeatureKey: options.FeatureKey,
+					Reason:     EvaluationReasonError,
+					Required:   feature.Required,
+					Error:      &RequiredCycleError{Path: cyclePath},
+				}
+
+				options.Logger.Error("required features cycle", LogDetails{
+					"featureKey": options.FeatureKey,
+					"path":       cyclePath,
+				})
+
+				options.recorder.recordRequiredCycle(requiredKey, evaluation.Error)
 
-			if requiredStr, ok := required.(string); ok {
-				requiredKey = FeatureKey(requiredStr)
-			} else if requiredWithVar, ok := required.(RequiredWithVariation); ok {
-				requiredKey = requiredWithVar.Key
-				requiredVariation = &requiredWithVar.Variation
+				return evaluation
 			}
 
+			requiredRecorder := options.recorder.child()
 			requiredEvaluation := Evaluate(EvaluateOptions{
 				EvaluateParams: EvaluateParams{
 					Type:       EvaluationTypeFlag,
 					FeatureKey: requiredKey,
 				},
-				EvaluateDependencies: options.EvaluateDependencies,
+				EvaluateDependencies: requiredDependencies,
+				recorder:             requiredRecorder,
 			})
+			requiredNode := options.recorder.recordRequired(requiredKey, requiredVariation, requiredEvaluation, requiredRecorder)
+
+			// propagate errors, like cycles found deeper down
+			if requiredEvaluation.Reason == EvaluationReasonError {
+				evaluation = requiredEvaluation
+				evaluation.Type = options.Type
+				evaluation.FeatureKey = options.FeatureKey
+				evaluation.Required = feature.Required
+
+				return evaluation
+			}
+
 			requiredIsEnabled := requiredEvaluation.Enabled != nil && *requiredEvaluation.Enabled
 
 			if !requiredIsEnabled {
 				requiredFeaturesAreEnabled = false
+				options.recorder.recordSkippedRequired(feature.Required[index+1:])
 				break
 			}
 
@@ -428,7 +658,8 @@ func Evaluate(options EvaluateOptions) Evaluation {
 						Type:       EvaluationTypeVariation,
 						FeatureKey: requiredKey,
 					},
-					EvaluateDependencies: options.EvaluateDependencies,
+					EvaluateDependencies: requiredDependencies,
+					recorder:             options.recorder.child(),
 				})
 
 				var requiredVariationValue *VariationValue
@@ -439,8 +670,12 @@ func Evaluate(options EvaluateOptions) Evaluation {
 					requiredVariationValue = &requiredVariationEvaluation.Variation.Value
 				}
 
-				if requiredVariationValue == nil || *requiredVariationValue != *requiredVariation {
+				requiredVariationIsMatched := requiredVariationValue != nil && *requiredVariationValue == *requiredVariation
+				options.recorder.recordRequiredVariation(requiredNode, requiredVariationValue, requiredVariationIsMatched)
+
+				if !requiredVariationIsMatched {
 					requiredFeaturesAreEnabled = false
+					options.recorder.recordSkippedRequired(feature.Required[index+1:])
 					break
 				}
 			}
@@ -463,18 +698,107 @@ func Evaluate(options EvaluateOptions) Evaluation {
 		}
 	}
 
+	/**
+	 * Sticky store
+	 */
+	stickyRecord := getStickyStoreRecord(options)
+	if options.StickyStore != nil {
+		options.recorder.recordStickyStore(stickyRecord)
+	}
+
+	if stickyRecord != nil {
+		// flag
+		if options.Type == EvaluationTypeFlag {
+			evaluation = Evaluation{
+				Type:       options.Type,
+				FeatureKey: options.FeatureKey,
+				Reason:     EvaluationReasonSticky,
+				Sticky:     &stickyRecord.Feature,
+				Enabled:    &stickyRecord.Feature.Enabled,
+			}
+
+			options.Logger.Debug("using sticky store enabled", LogDetails{
+				"evaluation": evaluation,
+			})
+
+			return evaluation
+		}
+
+		// variation
+		if options.Type == EvaluationTypeVariation && stickyRecord.Feature.Variation != nil && feature.Variations != nil {
+			for _, variation := range feature.Variations {
+				if variation.Value == *stickyRecord.Feature.Variation {
+					evaluation = Evaluation{
+						Type:           options.Type,
+						FeatureKey:     options.FeatureKey,
+						Reason:         EvaluationReasonSticky,
+						Sticky:         &stickyRecord.Feature,
+						Variation:      &variation,
+						VariationValue: &variation.Value,
+					}
+
+					options.Logger.Debug("using sticky store variation", LogDetails{
+						"evaluation": evaluation,
+					})
+
+					return evaluation
+				}
+			}
+		}
+	}
+
+	// persist bucketed outcomes, so that later datafile changes do not re-bucket (not when explaining)
+	defer func() {
+		if options.recorder == nil {
+			setStickyStoreRecord(options, evaluation)
+		}
+	}()
+
 	/**
 	 * Bucketing
 	 */
+	// missing bucketBy attributes
+	bucketBy := feature.BucketBy
+	bucketByMissing := getBucketByMissing(options, feature)
+
+	if bucketByMissing != nil {
+		if bucketByMissing.FallbackAttribute != nil {
+			bucketBy = *bucketByMissing.FallbackAttribute
+		} else if bucketByMissing.Policy == MissingBucketByPolicyDisable || bucketByMissing.Policy == MissingBucketByPolicyFallback {
+			evaluation = Evaluation{
+				Type:            options.Type,
+				FeatureKey:      options.FeatureKey,
+				Reason:          EvaluationReasonBucketByMissing,
+				BucketByMissing: bucketByMissing,
+				VariableKey:     options.VariableKey,
+				Enabled:         &[]bool{false}[0],
+			}
+
+			options.Logger.Debug("bucketBy attributes missing", LogDetails{
+				"evaluation": evaluation,
+			})
+
+			options.recorder.recordBucket(bucketBy, nil, nil, bucketByMissing)
+
+			return evaluation
+		}
+
+		defer func() {
+			if evaluation.BucketByMissing == nil {
+				evaluation.BucketByMissing = bucketByMissing
+			}
+		}()
+	}
+
 	// bucketKey
 	bucketKey := GetBucketKey(GetBucketKeyOptions{
 		FeatureKey: options.FeatureKey,
-		BucketBy:   feature.BucketBy,
+		BucketBy:   bucketBy,
 		Context:    options.Context,
 		Logger:     options.Logger,
 	})
 
-	for _, hook := range options.HooksManager.GetAll() {
+	for _, hook := range options.HooksManager.GetMatching(options.FeatureKey, options.Type) {
 		if hook.BucketKey != nil {
 			bucketKey = hook.BucketKey(ConfigureBucketKeyOptions{
 				FeatureKey: options.FeatureKey,
@@ -488,7 +812,7 @@ func Evaluate(options EvaluateOptions) Evaluation {
 	// bucketValue
 	bucketValue := GetBucketedNumber(bucketKey)
 
-	for _, hook := range options.HooksManager.GetAll() {
+	for _, hook := range options.HooksManager.GetMatching(options.FeatureKey, options.Type) {
 		if hook.BucketValue != nil {
 			bucketValue = hook.BucketValue(ConfigureBucketValueOptions{
 				FeatureKey:  options.FeatureKey,
@@ -499,6 +823,8 @@ func Evaluate(options EvaluateOptions) Evaluation {
 		}
 	}
 
+	options.recorder.recordBucket(bucketBy, &bucketKey, &bucketValue, bucketByMissing)
+
 	var matchedTraffic *Traffic
 	var matchedAllocation *Allocation
 
@@ -506,12 +832,14 @@ func Evaluate(options EvaluateOptions) Evaluation {
 		matchedTraffic = options.DatafileReader.GetMatchedTraffic(feature.Traffic, options.Context)
 
 		if matchedTraffic != nil {
-			matchedAllocation = options.DatafileReader.GetMatchedAllocation(matchedTraffic, bucketValue)
+			matchedAllocation = options.DatafileReader.GetMatchedAllocation(getWeightedTraffic(matchedTraffic, feature.Variations), bucketValue)
 		}
 	} else {
 		matchedTraffic = options.DatafileReader.GetMatchedTraffic(feature.Traffic, options.Context)
 	}
 
+	options.recorder.recordRules(options.DatafileReader, feature, options.Context, matchedTraffic, bucketValue)
+
 	if matchedTraffic != nil {
 		// percentage: 0
 		if matchedTraffic.Percentage == 0 {
@@ -655,82 +983,7 @@ func Evaluate(options EvaluateOptions) Evaluation {
 				}
 			}
 
-			// Handle variationWeights
-			if matchedTraffic.VariationWeights != nil && len(matchedTraffic.VariationWeights) > 0 {
-				// Create custom allocation based on variationWeights
-				totalWeight := 0
-				for _, weight := range matchedTraffic.VariationWeights {
-					totalWeight += int(weight)
-				}
-
-				if totalWeight > 0 {
-					// Calculate which variation the bucket value falls into
-					currentWeight := 0
-
-					// Sort variation weights to ensure consistent processing order
-					// This matches TypeScript's deterministic object property order
-					type variationWeight struct {
-						value  VariationValue
-						weight int
-					}
-					var sortedWeights []variationWeight
-					for variationValue, weight := range matchedTraffic.VariationWeights {
-						sortedWeights = append(sortedWeights, variationWeight{
-							value:  variationValue,
-							weight: int(weight),
-						})
-					}
-
-					// Sort by variation value to ensure consistent order
-					sort.Slice(sortedWeights, func(i, j int) bool {
-						return string(sortedWeights[i].value) < string(sortedWeights[j].value)
-					})
-
-					for _, vw := range sortedWeights {
-						weightInt := vw.weight
-						// Convert percentage to bucket range (0-100000)
-						startRange := currentWeight * 100000 / totalWeight
-						endRange := (currentWeight + weightInt) * 100000 / totalWeight
-
-						options.Logger.Debug("checking variation weight range", LogDetails{
-							"variationValue": vw.value,
-							"weight":         weightInt,
-							"startRange":     startRange,
-							"endRange":       endRange,
-							"bucketValue":    bucketValue,
-							"totalWeight":    totalWeight,
-						})
-
-						if bucketValue >= startRange && bucketValue < endRange {
-							// Find the variation object
-							for _, variation := range feature.Variations {
-								if variation.Value == vw.value {
-									evaluation = Evaluation{
-										Type:           options.Type,
-										FeatureKey:     options.FeatureKey,
-										Reason:         EvaluationReasonAllocated,
-										BucketKey:      &bucketKey,
-										BucketValue:    &bucketValue,
-										RuleKey:        &matchedTraffic.Key,
-										Traffic:        matchedTraffic,
-										Variation:      &variation,
-										VariationValue: &variation.Value,
-									}
-
-									options.Logger.Debug("allocated variation with custom weights", LogDetails{
-										"evaluation": evaluation,
-									})
-
-									return evaluation
-								}
-							}
-						}
-						currentWeight += weightInt
-					}
-				}
-			}
-
-			// regular allocation
+			// allocation, from variation weights if any
 			if matchedAllocation != nil && matchedAllocation.Variation != "" {
 				for _, variation := range feature.Variations {
 					if variation.Value == matchedAllocation.Variation {
@@ -806,6 +1059,8 @@ func Evaluate(options EvaluateOptions) Evaluation {
 
 		if forceResult.Force != nil && forceResult.Force.Variation != nil {
 			variationValue = forceResult.Force.Variation
+		} else if stickyRecord != nil && stickyRecord.Feature.Variation != nil {
+			variationValue = stickyRecord.Feature.Variation
 		} else if matchedTraffic != nil && matchedTraffic.Variation != nil {
 			variationValue = matchedTraffic.Variation
 		} else if matchedAllocation != nil && matchedAllocation.Variation != "" {
@@ -9,6 +9,7 @@ const (
 	EvaluationReasonDisabled        EvaluationReason = "disabled"          // feature is disabled
 	EvaluationReasonRequired        EvaluationReason = "required"          // required features are not enabled
 	EvaluationReasonOutOfRange      EvaluationReason = "out_of_range"      // out of range when mutually exclusive experiments are involved via Groups
+	EvaluationReasonBucketByMissing EvaluationReason = "bucket_by_missing" // attributes of bucketBy are missing in context, and the policy disables the feature
 
 	// Variations specific
 	EvaluationReasonNoVariations      EvaluationReason = "no_variations"      // feature has no variations
@@ -19,13 +20,19 @@ const (
 	EvaluationReasonVariableDefault  EvaluationReason = "variable_default"   // default variable value used
 	EvaluationReasonVariableDisabled EvaluationReason = "variable_disabled"  // feature is disabled, and variable's disabledValue is used
 	EvaluationReasonVariableOverride EvaluationReason = "variable_override"  // variable overridden from inside a variation
+	EvaluationReasonVariableInvalid  EvaluationReason = "variable_invalid"   // variable value does not satisfy its schema, and default value is used
+
+	// Segment specific
+	EvaluationReasonSegmentNotFound EvaluationReason = "segment_not_found" // segment is not found in datafile
+	EvaluationReasonSegmentMatched  EvaluationReason = "segment_matched"   // segment's conditions are matched
 
 	// Common
-	EvaluationReasonNoMatch   EvaluationReason = "no_match"  // no rules matched
-	EvaluationReasonForced    EvaluationReason = "forced"    // against a forced rule
-	EvaluationReasonSticky    EvaluationReason = "sticky"    // against a sticky feature
-	EvaluationReasonRule      EvaluationReason = "rule"      // against a regular rule
-	EvaluationReasonAllocated EvaluationReason = "allocated" // regular allocation based on bucketing
+	EvaluationReasonNoMatch       EvaluationReason = "no_match"       // no rules matched
+	EvaluationReasonForced        EvaluationReason = "forced"         // against a forced rule
+	EvaluationReasonLocalOverride EvaluationReason = "local_override" // against a local override (runtime, file or env)
+	EvaluationReasonSticky        EvaluationReason = "sticky"         // against a sticky feature
+	EvaluationReasonRule          EvaluationReason = "rule"           // against a regular rule
+	EvaluationReasonAllocated     EvaluationReason = "allocated"      // regular allocation based on bucketing
 
 	EvaluationReasonError EvaluationReason = "error" // error
 )
@@ -57,6 +64,9 @@ type Evaluation struct {
 	Force       *Force            `json:"force,omitempty"`
 	Required    []Required        `json:"required,omitempty"`
 	Sticky      *EvaluatedFeature `json:"sticky,omitempty"`
+	Override    *EvaluatedFeature `json:"override,omitempty"`
+
+	BucketByMissing *BucketByMissing `json:"bucketByMissing,omitempty"`
 
 	// Variation
 	Variation      *Variation      `json:"variation,omitempty"`
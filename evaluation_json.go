@@ -0,0 +1,202 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// EvaluationErrorCode identifies the kind of error of an evaluation
+type EvaluationErrorCode string
+
+const (
+	EvaluationErrorCodeUnknown          EvaluationErrorCode = "error"              // any other error
+	EvaluationErrorCodePanic            EvaluationErrorCode = "panic"              // recovered from a panic while evaluating
+	EvaluationErrorCodeRequiredCycle    EvaluationErrorCode = "required_cycle"     // required features depend on each other in a cycle
+	EvaluationErrorCodeInvalidVariable  EvaluationErrorCode = "invalid_variable"   // variable value does not satisfy its schema
+	EvaluationErrorCodeFeatureNotFound  EvaluationErrorCode = "feature_not_found"  // feature is not found in the datafile
+	EvaluationErrorCodeVariableNotFound EvaluationErrorCode = "variable_not_found" // variable is not found in the feature's schema
+	EvaluationErrorCodeTypeMismatch     EvaluationErrorCode = "type_mismatch"      // variable value is not of the requested type
+	EvaluationErrorCodeInvalidJSON      EvaluationErrorCode = "invalid_json"       // json variable value can not be parsed
+	EvaluationErrorCodeNotReady         EvaluationErrorCode = "not_ready"          // no datafile is set yet
+)
+
+// EvaluationError is an error with a code, as found in serialized evaluations
+type EvaluationError struct {
+	Code    EvaluationErrorCode `json:"code"`
+	Message string              `json:"message"`
+}
+
+func (e *EvaluationError) Error() string {
+	return e.Message
+}
+
+// ErrorCode returns the code of the error
+func (e *EvaluationError) ErrorCode() EvaluationErrorCode {
+	return e.Code
+}
+
+// ErrorCode returns the code of the error
+func (e *RequiredCycleError) ErrorCode() EvaluationErrorCode {
+	return EvaluationErrorCodeRequiredCycle
+}
+
+// GetEvaluationErrorCode returns the code of an error, or EvaluationErrorCodeUnknown if it has none
+func GetEvaluationErrorCode(err error) EvaluationErrorCode {
+	var coder interface{ ErrorCode() EvaluationErrorCode }
+	if errors.As(err, &coder) {
+		return coder.ErrorCode()
+	}
+
+	return EvaluationErrorCodeUnknown
+}
+
+// EvaluationSchemaRef refers to the schema of an evaluated variable, instead of embedding it
+type EvaluationSchemaRef struct {
+	FeatureKey  FeatureKey   `json:"featureKey"`
+	VariableKey VariableKey  `json:"variableKey"`
+	Type        VariableType `json:"type,omitempty"`
+	Schema      *SchemaKey   `json:"schema,omitempty"`
+}
+
+// evaluationJSON is the serialized form of Evaluation.
+//
+// The enabled and variation fields are named as in EvaluatedFeature. Variable evaluations write
+// variableKey and variableValue, unlike the variables map of EvaluatedFeature.
+type evaluationJSON struct {
+	Type       EvaluationType   `json:"type"`
+	FeatureKey FeatureKey       `json:"featureKey"`
+	Reason     EvaluationReason `json:"reason"`
+
+	BucketKey   *BucketKey        `json:"bucketKey,omitempty"`
+	BucketValue *BucketValue      `json:"bucketValue,omitempty"`
+	RuleKey     *RuleKey          `json:"ruleKey,omitempty"`
+	ForceIndex  *int              `json:"forceIndex,omitempty"`
+	Required    []Required        `json:"required,omitempty"`
+	Sticky      *EvaluatedFeature `json:"sticky,omitempty"`
+	Override    *EvaluatedFeature `json:"override,omitempty"`
+	Error       *EvaluationError  `json:"error,omitempty"`
+
+	BucketByMissing *BucketByMissing `json:"bucketByMissing,omitempty"`
+
+	Enabled   *bool           `json:"enabled,omitempty"`
+	Variation *VariationValue `json:"variation,omitempty"`
+
+	VariableKey    *VariableKey         `json:"variableKey,omitempty"`
+	VariableValue  VariableValue        `json:"variableValue,omitempty"`
+	VariableSchema *EvaluationSchemaRef `json:"variableSchema,omitempty"`
+}
+
+// MarshalJSON serializes the evaluation with a stable schema:
+// the error as its code and message, the variation by its value, and the variable schema by reference.
+// Traffic and force entries are referred to by RuleKey and ForceIndex.
+func (e Evaluation) MarshalJSON() ([]byte, error) {
+	data := evaluationJSON{
+		Type:            e.Type,
+		FeatureKey:      e.FeatureKey,
+		Reason:          e.Reason,
+		BucketKey:       e.BucketKey,
+		BucketValue:     e.BucketValue,
+		RuleKey:         e.RuleKey,
+		ForceIndex:      e.ForceIndex,
+		Required:        e.Required,
+		Sticky:          e.Sticky,
+		Override:        e.Override,
+		Enabled:         e.Enabled,
+		BucketByMissing: e.BucketByMissing,
+		VariableKey:     e.VariableKey,
+		VariableValue:   e.VariableValue,
+	}
+
+	if e.Error != nil {
+		data.Error = &EvaluationError{
+			Code:    GetEvaluationErrorCode(e.Error),
+			Message: e.Error.Error(),
+		}
+	}
+
+	if e.VariationValue != nil {
+		data.Variation = e.VariationValue
+	} else if e.Variation != nil {
+		data.Variation = &e.Variation.Value
+	}
+
+	if e.VariableSchema != nil && e.VariableKey != nil {
+		data.VariableSchema = &EvaluationSchemaRef{
+			FeatureKey:  e.FeatureKey,
+			VariableKey: *e.VariableKey,
+			Type:        e.VariableSchema.Type,
+			Schema:      e.VariableSchema.Schema,
+		}
+	}
+
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON deserializes an evaluation serialized by MarshalJSON.
+//
+// The error becomes an *EvaluationError, and the variation and variable schema only contain what they were referred to by.
+func (e *Evaluation) UnmarshalJSON(bytes []byte) error {
+	var data evaluationJSON
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return fmt.Errorf("failed to unmarshal evaluation: %w", err)
+	}
+
+	*e = Evaluation{
+		Type:            data.Type,
+		FeatureKey:      data.FeatureKey,
+		Reason:          data.Reason,
+		BucketKey:       data.BucketKey,
+		BucketValue:     data.BucketValue,
+		RuleKey:         data.RuleKey,
+		ForceIndex:      data.ForceIndex,
+		Required:        data.Required,
+		Sticky:          data.Sticky,
+		Override:        data.Override,
+		Enabled:         data.Enabled,
+		BucketByMissing: data.BucketByMissing,
+		VariableKey:     data.VariableKey,
+		VariableValue:   data.VariableValue,
+	}
+
+	if data.Error != nil {
+		e.Error = data.Error
+	}
+
+	if data.Variation != nil {
+		e.VariationValue = data.Variation
+		e.Variation = &Variation{Value: *data.Variation}
+	}
+
+	if data.VariableSchema != nil {
+		variableKey := data.VariableSchema.VariableKey
+		e.VariableSchema = &VariableSchema{
+			Key:    &variableKey,
+			Type:   data.VariableSchema.Type,
+			Schema: data.VariableSchema.Schema,
+		}
+	}
+
+	return nil
+}
+
+// ToEvaluatedFeature returns the evaluated value of a flag or variation evaluation, in the shape of EvaluatedFeature
+func (e Evaluation) ToEvaluatedFeature() EvaluatedFeature {
+	evaluatedFeature := EvaluatedFeature{}
+
+	if e.Enabled != nil {
+		evaluatedFeature.Enabled = *e.Enabled
+	}
+
+	if e.VariationValue != nil {
+		evaluatedFeature.Variation = e.VariationValue
+	} else if e.Variation != nil {
+		evaluatedFeature.Variation = &e.Variation.Value
+	}
+
+	if e.VariableKey != nil && e.VariableValue != nil {
+		evaluatedFeature.Variables = map[VariableKey]VariableValue{*e.VariableKey: e.VariableValue}
+	}
+
+	return evaluatedFeature
+}
@@ -0,0 +1,144 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluationJSON(t *testing.T) {
+	bucketKey := BucketKey("123.test")
+	bucketValue := BucketValue(42000)
+	ruleKey := RuleKey("everyone")
+	variableKey := VariableKey("color")
+	schemaKey := SchemaKey("color")
+
+	evaluation := Evaluation{
+		Type:           EvaluationTypeVariable,
+		FeatureKey:     "test",
+		Reason:         EvaluationReasonAllocated,
+		BucketKey:      &bucketKey,
+		BucketValue:    &bucketValue,
+		RuleKey:        &ruleKey,
+		Traffic:        &Traffic{Key: "everyone", Segments: "*", Percentage: 100000},
+		Variation:      &Variation{Value: "treatment", Variables: map[VariableKey]VariableValue{"color": "red"}},
+		VariableKey:    &variableKey,
+		VariableValue:  "red",
+		VariableSchema: &VariableSchema{Type: "string", Schema: &schemaKey, DefaultValue: "blue"},
+	}
+
+	bytes, err := json.Marshal(evaluation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"type":"variable","featureKey":"test","reason":"allocated","bucketKey":"123.test","bucketValue":42000,"ruleKey":"everyone","variation":"treatment","variableKey":"color","variableValue":"red","variableSchema":{"featureKey":"test","variableKey":"color","type":"string","schema":"color"}}`
+	if string(bytes) != expected {
+		t.Errorf("unexpected JSON:\n%s\nexpected:\n%s", bytes, expected)
+	}
+
+	var parsed Evaluation
+	if err := json.Unmarshal(bytes, &parsed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if parsed.VariationValue == nil || *parsed.VariationValue != "treatment" || parsed.Variation.Value != "treatment" {
+		t.Errorf("expected variation by value, got %+v", parsed.Variation)
+	}
+	if parsed.VariableSchema == nil || *parsed.VariableSchema.Key != "color" || parsed.VariableSchema.Type != "string" {
+		t.Errorf("expected variable schema reference, got %+v", parsed.VariableSchema)
+	}
+	if parsed.Traffic != nil {
+		t.Errorf("expected traffic to be referred to by rule key only")
+	}
+
+	// stable across round-trips
+	again, err := json.Marshal(parsed)
+	if err != nil || string(again) != expected {
+		t.Errorf("expected stable JSON after round-trip, got %s, %v", again, err)
+	}
+}
+
+func TestEvaluationJSONError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{&RequiredCycleError{Path: []FeatureKey{"a", "b", "a"}}, `{"code":"required_cycle","message":"required features cycle: a -\u003e b -\u003e a"}`},
+		{errors.New("something failed"), `{"code":"error","message":"something failed"}`},
+		{&EvaluationError{Code: EvaluationErrorCodePanic, Message: "panic: oops"}, `{"code":"panic","message":"panic: oops"}`},
+	}
+
+	for _, tt := range tests {
+		bytes, err := json.Marshal(Evaluation{Type: EvaluationTypeFlag, FeatureKey: "test", Reason: EvaluationReasonError, Error: tt.err})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(bytes, &raw); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(raw["error"]) != tt.expected {
+			t.Errorf("expected error %s, got %s", tt.expected, raw["error"])
+		}
+
+		var parsed Evaluation
+		if err := json.Unmarshal(bytes, &parsed); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if parsed.Error == nil || parsed.Error.Error() != tt.err.Error() || GetEvaluationErrorCode(parsed.Error) != GetEvaluationErrorCode(tt.err) {
+			t.Errorf("expected error to round-trip, got %v", parsed.Error)
+		}
+	}
+}
+
+func TestEvaluationJSONFromInstance(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: DatafileContent{
+			SchemaVersion: "2",
+			Revision:      "1",
+			Segments:      map[SegmentKey]Segment{},
+			Features: map[FeatureKey]Feature{
+				"test": {
+					BucketBy:   "userId",
+					Variations: []Variation{{Value: "control"}},
+					Traffic: []Traffic{{
+						Key:        "everyone",
+						Segments:   "*",
+						Percentage: 100000,
+						Allocation: []Allocation{{Variation: "control", Range: Range{0, 100000}}},
+					}},
+				},
+			},
+		},
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	evaluation := instance.EvaluateVariation("test", Context{"userId": "123"}, OverrideOptions{})
+
+	bytes, err := json.Marshal(evaluation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed Evaluation
+	if err := json.Unmarshal(bytes, &parsed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(parsed.ToEvaluatedFeature(), evaluation.ToEvaluatedFeature()) {
+		t.Errorf("expected same evaluated feature, got %+v and %+v", parsed.ToEvaluatedFeature(), evaluation.ToEvaluatedFeature())
+	}
+
+	// evaluated values are serialized the same way as EvaluatedFeature
+	featureBytes, _ := json.Marshal(evaluation.ToEvaluatedFeature())
+	var feature map[string]interface{}
+	var full map[string]interface{}
+	json.Unmarshal(featureBytes, &feature)
+	json.Unmarshal(bytes, &full)
+	if feature["variation"] != full["variation"] || feature["variation"] != "control" {
+		t.Errorf("expected shared variation field, got %v and %v", feature["variation"], full["variation"])
+	}
+}
@@ -2,85 +2,36 @@ package featurevisor
 
 // getParamsForDatafileSetEvent gets parameters for datafile set event
 func getParamsForDatafileSetEvent(previousDatafileReader *DatafileReader, newDatafileReader *DatafileReader) LogDetails {
-	previousRevision := ""
-	if previousDatafileReader != nil {
-		previousRevision = previousDatafileReader.GetRevision()
-	}
-
-	newRevision := ""
-	if newDatafileReader != nil {
-		newRevision = newDatafileReader.GetRevision()
-	}
-
-	previousFeatureKeys := []string{}
-	if previousDatafileReader != nil {
-		previousFeatureKeys = previousDatafileReader.GetFeatureKeys()
-	}
+	diff := diffDatafileReaders(previousDatafileReader, newDatafileReader)
 
-	newFeatureKeys := []string{}
-	if newDatafileReader != nil {
-		newFeatureKeys = newDatafileReader.GetFeatureKeys()
-	}
-
-	// Find removed features
-	removedFeatures := []string{}
-	for _, previousFeatureKey := range previousFeatureKeys {
-		found := false
-		for _, newFeatureKey := range newFeatureKeys {
-			if previousFeatureKey == newFeatureKey {
-				found = true
-				break
-			}
-		}
-		if !found {
-			removedFeatures = append(removedFeatures, previousFeatureKey)
-		}
+	return LogDetails{
+		"revision":         diff.Revision,
+		"previousRevision": diff.PreviousRevision,
+		"revisionChanged":  diff.PreviousRevision != diff.Revision,
+		"features":         getAffectedFeatureKeys(diff),
+		"diff":             diff,
 	}
+}
 
-	// Find changed features
-	changedFeatures := []string{}
-	for _, previousFeatureKey := range previousFeatureKeys {
-		for _, newFeatureKey := range newFeatureKeys {
-			if previousFeatureKey == newFeatureKey {
-				// Check if feature was changed by comparing hashes
-				previousFeature := previousDatafileReader.GetFeature(FeatureKey(previousFeatureKey))
-				newFeature := newDatafileReader.GetFeature(FeatureKey(newFeatureKey))
-
-				if previousFeature != nil && newFeature != nil {
-					// Compare hashes if available, otherwise assume changed
-					if previousFeature.Hash != newFeature.Hash {
-						changedFeatures = append(changedFeatures, previousFeatureKey)
-					}
-				}
-				break
-			}
+// getLogDetailsForDatafileSetEvent gets details of a datafile set event for logging at info level,
+// with the keys of changed segments instead of the whole diff, which can be large
+func getLogDetailsForDatafileSetEvent(params LogDetails) LogDetails {
+	details := LogDetails{}
+	for key, value := range params {
+		if key != "diff" {
+			details[key] = value
 		}
 	}
 
-	// Find added features
-	addedFeatures := []string{}
-	for _, newFeatureKey := range newFeatureKeys {
-		found := false
-		for _, previousFeatureKey := range previousFeatureKeys {
-			if newFeatureKey == previousFeatureKey {
-				found = true
-				break
-			}
-		}
-		if !found {
-			addedFeatures = append(addedFeatures, newFeatureKey)
+	segmentKeys := []SegmentKey{}
+	if diff, ok := params["diff"].(*DatafileDiff); ok {
+		for _, segmentDiff := range diff.Segments {
+			segmentKeys = append(segmentKeys, segmentDiff.SegmentKey)
 		}
 	}
+	details["segments"] = segmentKeys
 
-	// Combine all affected feature keys
-	allAffectedFeatures := append(append(removedFeatures, changedFeatures...), addedFeatures...)
-
-	return LogDetails{
-		"revision":         newRevision,
-		"previousRevision": previousRevision,
-		"revisionChanged":  previousRevision != newRevision,
-		"features":         allAffectedFeatures,
-	}
+	return details
 }
 
 // getParamsForStickySetEvent gets parameters for sticky set event
@@ -112,3 +63,11 @@ func getParamsForStickySetEvent(previousStickyFeatures StickyFeatures, newSticky
 		"replaced": replace,
 	}
 }
+
+// getParamsForOverridesSetEvent gets parameters for overrides set event
+func getParamsForOverridesSetEvent(source OverrideSource, featureKeys []FeatureKey) LogDetails {
+	return LogDetails{
+		"source":   source,
+		"features": featureKeys,
+	}
+}
@@ -0,0 +1,596 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExplainNodeType represents the type of a step in an explain trace
+type ExplainNodeType string
+
+const (
+	ExplainNodeTypeFeature     ExplainNodeType = "feature"
+	ExplainNodeTypeOverride    ExplainNodeType = "override"
+	ExplainNodeTypeSticky      ExplainNodeType = "sticky"
+	ExplainNodeTypeStickyStore ExplainNodeType = "stickyStore"
+	ExplainNodeTypeForce       ExplainNodeType = "force"
+	ExplainNodeTypeRequired    ExplainNodeType = "required"
+	ExplainNodeTypeBucket      ExplainNodeType = "bucket"
+	ExplainNodeTypeRule        ExplainNodeType = "rule"
+	ExplainNodeTypePercentage  ExplainNodeType = "percentage"
+	ExplainNodeTypeRange       ExplainNodeType = "range"
+	ExplainNodeTypeAllocation  ExplainNodeType = "allocation"
+	ExplainNodeTypeSegment     ExplainNodeType = "segment"
+	ExplainNodeTypeCondition   ExplainNodeType = "condition"
+	ExplainNodeTypeAnd         ExplainNodeType = "and"
+	ExplainNodeTypeOr          ExplainNodeType = "or"
+	ExplainNodeTypeNot         ExplainNodeType = "not"
+)
+
+// ExplainNode is a step of an explain trace, with the steps it consists of as children
+type ExplainNode struct {
+	Type     ExplainNodeType        `json:"type"`
+	Key      string                 `json:"key,omitempty"`
+	Matched  *bool                  `json:"matched,omitempty"` // nil for informational steps
+	Skipped  bool                   `json:"skipped,omitempty"` // not reached, as an earlier step decided the evaluation
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Children []*ExplainNode         `json:"children,omitempty"`
+}
+
+// IsMatched tells whether the step matched
+func (n *ExplainNode) IsMatched() bool {
+	return n.Matched != nil && *n.Matched
+}
+
+// ToJSON renders the trace as indented JSON
+func (n *ExplainNode) ToJSON() (string, error) {
+	bytes, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal explain trace to JSON: %w", err)
+	}
+	return string(bytes), nil
+}
+
+// String renders the trace as indented text, one step per line
+func (n *ExplainNode) String() string {
+	var builder strings.Builder
+	n.writeText(&builder, 0)
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+func (n *ExplainNode) writeText(builder *strings.Builder, depth int) {
+	builder.WriteString(strings.Repeat("  ", depth))
+	builder.WriteString(string(n.Type))
+
+	if n.Key != "" {
+		builder.WriteString(" " + strconv.Quote(n.Key))
+	}
+
+	if n.Matched != nil {
+		if *n.Matched {
+			builder.WriteString(": matched")
+		} else {
+			builder.WriteString(": not matched")
+		}
+	}
+
+	if n.Skipped {
+		builder.WriteString(" (skipped)")
+	}
+
+	if len(n.Details) > 0 {
+		keys := make([]string, 0, len(n.Details))
+		for key := range n.Details {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			parts = append(parts, key+"="+formatExplainValue(n.Details[key]))
+		}
+		builder.WriteString(" [" + strings.Join(parts, ", ") + "]")
+	}
+
+	builder.WriteString("\n")
+
+	for _, child := range n.Children {
+		child.writeText(builder, depth+1)
+	}
+}
+
+func formatExplainValue(value interface{}) string {
+	if err, ok := value.(error); ok {
+		return strconv.Quote(err.Error())
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(bytes)
+}
+
+func newExplainNode(nodeType ExplainNodeType, key string, matched bool) *ExplainNode {
+	return &ExplainNode{
+		Type:    nodeType,
+		Key:     key,
+		Matched: &matched,
+		Details: map[string]interface{}{},
+	}
+}
+
+// explainGroup combines child steps with and, or or not
+func explainGroup(nodeType ExplainNodeType, children []*ExplainNode) *ExplainNode {
+	var matched bool
+
+	switch nodeType {
+	case ExplainNodeTypeOr:
+		for _, child := range children {
+			matched = matched || child.IsMatched()
+		}
+	case ExplainNodeTypeNot:
+		matched = len(children) == 1 && !children[0].IsMatched()
+	default:
+		matched = true
+		for _, child := range children {
+			matched = matched && child.IsMatched()
+		}
+	}
+
+	node := newExplainNode(nodeType, "", matched)
+	node.Details = nil
+	node.Children = children
+
+	return node
+}
+
+/**
+ * Conditions and segments
+ */
+
+// explainCondition explains a single plain condition, with the value found in context
+func (d *DatafileReader) explainCondition(condition PlainCondition, context Context) *ExplainNode {
+	node := newExplainNode(ExplainNodeTypeCondition, "", d.conditionIsMatched(condition, context))
+
+	node.Details["attribute"] = condition.Attribute
+	node.Details["operator"] = condition.Operator
+	if condition.Value != nil {
+		node.Details["value"] = *condition.Value
+	}
+	if condition.RegexFlags != nil {
+		node.Details["regexFlags"] = *condition.RegexFlags
+	}
+	node.Details["contextValue"] = GetValueFromContext(context, string(condition.Attribute))
+
+	return node
+}
+
+// explainConditions explains conditions the way AllConditionsAreMatched matches them
+func (d *DatafileReader) explainConditions(conditions Condition, context Context) *ExplainNode {
+	explainAll := func(nodeType ExplainNodeType, list interface{}) *ExplainNode {
+		items, _ := toSlice(list)
+		children := make([]*ExplainNode, 0, len(items))
+		for _, item := range items {
+			children = append(children, d.explainConditions(item, context))
+		}
+		return explainGroup(nodeType, children)
+	}
+
+	switch c := conditions.(type) {
+	case string:
+		node := newExplainNode(ExplainNodeTypeCondition, c, c == "*")
+		node.Details = nil
+		return node
+	case PlainCondition:
+		return d.explainCondition(c, context)
+	case map[string]interface{}:
+		if plainCondition, ok := plainConditionFromMap(c); ok {
+			return d.explainCondition(plainCondition, context)
+		}
+		if and, ok := c["and"].([]interface{}); ok {
+			return explainAll(ExplainNodeTypeAnd, and)
+		}
+		if or, ok := c["or"].([]interface{}); ok {
+			return explainAll(ExplainNodeTypeOr, or)
+		}
+		if not, ok := c["not"]; ok {
+			return explainAll(ExplainNodeTypeNot, []Condition{not})
+		}
+	case AndCondition:
+		return explainAll(ExplainNodeTypeAnd, c.And)
+	case OrCondition:
+		return explainAll(ExplainNodeTypeOr, c.Or)
+	case NotCondition:
+		return explainAll(ExplainNodeTypeNot, []Condition{c.Not})
+	case []interface{}:
+		return explainAll(ExplainNodeTypeAnd, c)
+	case []Condition:
+		return explainAll(ExplainNodeTypeAnd, c)
+	}
+
+	node := newExplainNode(ExplainNodeTypeCondition, "", false)
+	node.Details["error"] = "unsupported conditions"
+	node.Details["conditions"] = conditions
+
+	return node
+}
+
+// explainSegments explains segments the way AllSegmentsAreMatched matches them
+func (d *DatafileReader) explainSegments(groupSegments interface{}, context Context) *ExplainNode {
+	explainAll := func(nodeType ExplainNodeType, list interface{}) *ExplainNode {
+		items, _ := toSlice(list)
+		children := make([]*ExplainNode, 0, len(items))
+		for _, item := range items {
+			children = append(children, d.explainSegments(item, context))
+		}
+		return explainGroup(nodeType, children)
+	}
+
+	switch s := groupSegments.(type) {
+	case string:
+		if s == "*" {
+			node := newExplainNode(ExplainNodeTypeSegment, s, true)
+			node.Details = nil
+			return node
+		}
+
+		segment := d.GetSegment(SegmentKey(s))
+		if segment == nil {
+			node := newExplainNode(ExplainNodeTypeSegment, s, false)
+			node.Details["error"] = "segment not found"
+			return node
+		}
+
+		conditions := d.explainConditions(segment.Conditions, context)
+		node := newExplainNode(ExplainNodeTypeSegment, s, conditions.IsMatched())
+		node.Details = nil
+		node.Children = []*ExplainNode{conditions}
+		return node
+	case AndGroupSegment:
+		return explainAll(ExplainNodeTypeAnd, s.And)
+	case OrGroupSegment:
+		return explainAll(ExplainNodeTypeOr, s.Or)
+	case NotGroupSegment:
+		return explainAll(ExplainNodeTypeNot, []interface{}{s.Not})
+	case []interface{}:
+		return explainAll(ExplainNodeTypeAnd, s)
+	case []GroupSegment:
+		return explainAll(ExplainNodeTypeAnd, s)
+	case map[string]interface{}:
+		if or, ok := s["or"].([]interface{}); ok {
+			return explainAll(ExplainNodeTypeOr, or)
+		}
+		if and, ok := s["and"].([]interface{}); ok {
+			return explainAll(ExplainNodeTypeAnd, and)
+		}
+		if not, ok := s["not"]; ok {
+			return explainAll(ExplainNodeTypeNot, []interface{}{not})
+		}
+	}
+
+	node := newExplainNode(ExplainNodeTypeSegment, "", false)
+	node.Details["error"] = "unsupported segments"
+	node.Details["segments"] = groupSegments
+
+	return node
+}
+
+/**
+ * Recording steps of evaluations
+ */
+
+// explainRecorder collects steps of an evaluation as Evaluate reaches them.
+//
+// Evaluations with a recorder have no side effects, like writing to the sticky store.
+type explainRecorder struct {
+	steps []*ExplainNode
+}
+
+// child returns a recorder for nested evaluations, like of required features
+func (r *explainRecorder) child() *explainRecorder {
+	if r == nil {
+		return nil
+	}
+
+	return &explainRecorder{}
+}
+
+func (r *explainRecorder) record(node *ExplainNode) *ExplainNode {
+	if r != nil {
+		r.steps = append(r.steps, node)
+	}
+
+	return node
+}
+
+func newSkippedExplainNode(nodeType ExplainNodeType, key string) *ExplainNode {
+	return &ExplainNode{Type: nodeType, Key: key, Skipped: true}
+}
+
+func (r *explainRecorder) recordOverride(override *EvaluatedFeature, source OverrideSource, exists bool) {
+	if r == nil {
+		return
+	}
+
+	node := newExplainNode(ExplainNodeTypeOverride, "", exists)
+	if exists {
+		node.Details["source"] = source
+		node.Details["enabled"] = override.Enabled
+		if override.Variation != nil {
+			node.Details["variation"] = *override.Variation
+		}
+	}
+
+	r.record(node)
+}
+
+func (r *explainRecorder) recordSticky(sticky *StickyFeatures, featureKey FeatureKey) {
+	if r == nil {
+		return
+	}
+
+	node := newExplainNode(ExplainNodeTypeSticky, "", false)
+	if sticky != nil {
+		if stickyFeature, exists := (*sticky)[featureKey]; exists {
+			node.Matched = &[]bool{true}[0]
+			node.Details["enabled"] = stickyFeature.Enabled
+			if stickyFeature.Variation != nil {
+				node.Details["variation"] = *stickyFeature.Variation
+			}
+		}
+	}
+
+	r.record(node)
+}
+
+// recordForce records force entries up to the matched one, and the ones after it as skipped
+func (r *explainRecorder) recordForce(reader *DatafileReader, feature *Feature, context Context, forceIndex *int) {
+	if r == nil {
+		return
+	}
+
+	for index, force := range feature.Force {
+		if forceIndex != nil && index > *forceIndex {
+			r.record(newSkippedExplainNode(ExplainNodeTypeForce, strconv.Itoa(index)))
+			continue
+		}
+
+		var conditions *ExplainNode
+		if force.Conditions != nil {
+			conditions = reader.explainConditions(reader.parseConditionsIfStringified(force.Conditions), context)
+		} else if force.Segments != nil {
+			conditions = reader.explainSegments(reader.parseSegmentsIfStringified(force.Segments), context)
+		}
+
+		node := newExplainNode(ExplainNodeTypeForce, strconv.Itoa(index), forceIndex != nil && index == *forceIndex)
+		if force.Enabled != nil {
+			node.Details["enabled"] = *force.Enabled
+		}
+		if force.Variation != nil {
+			node.Details["variation"] = *force.Variation
+		}
+		if conditions != nil {
+			node.Children = []*ExplainNode{conditions}
+		}
+
+		r.record(node)
+	}
+}
+
+// recordRequired records a required feature with the trace of its own evaluation
+func (r *explainRecorder) recordRequired(requiredKey FeatureKey, requiredVariation *VariationValue, evaluation Evaluation, steps *explainRecorder) *ExplainNode {
+	if r == nil {
+		return nil
+	}
+
+	node := newExplainNode(ExplainNodeTypeRequired, requiredKey, evaluation.Enabled != nil && *evaluation.Enabled)
+	if requiredVariation != nil {
+		node.Details["variation"] = *requiredVariation
+	}
+	node.Children = []*ExplainNode{newExplainFeatureNode(requiredKey, evaluation, steps)}
+
+	return r.record(node)
+}
+
+// recordRequiredVariation records the variation a required feature evaluated to
+func (r *explainRecorder) recordRequiredVariation(node *ExplainNode, variationValue *VariationValue, matched bool) {
+	if r == nil || node == nil {
+		return
+	}
+
+	if variationValue != nil {
+		node.Children[0].Details["variation"] = *variationValue
+	}
+	node.Matched = &matched
+}
+
+func (r *explainRecorder) recordRequiredCycle(requiredKey FeatureKey, err error) {
+	if r == nil {
+		return
+	}
+
+	node := newExplainNode(ExplainNodeTypeRequired, requiredKey, false)
+	node.Details["error"] = err.Error()
+
+	r.record(node)
+}
+
+func (r *explainRecorder) recordSkippedRequired(required []Required) {
+	if r == nil {
+		return
+	}
+
+	for _, item := range required {
+		requiredKey, _ := getRequiredFeature(item)
+		r.record(newSkippedExplainNode(ExplainNodeTypeRequired, requiredKey))
+	}
+}
+
+func (r *explainRecorder) recordStickyStore(record *StickyRecord) {
+	if r == nil {
+		return
+	}
+
+	node := newExplainNode(ExplainNodeTypeStickyStore, "", record != nil)
+	if record != nil {
+		node.Details["enabled"] = record.Feature.Enabled
+		if record.Feature.Variation != nil {
+			node.Details["variation"] = *record.Feature.Variation
+		}
+		node.Details["createdAt"] = record.CreatedAt
+	}
+
+	r.record(node)
+}
+
+// recordBucket records the bucket key and value, which are nil if bucketing is skipped for missing attributes
+func (r *explainRecorder) recordBucket(bucketBy BucketBy, bucketKey *BucketKey, bucketValue *int, bucketByMissing *BucketByMissing) {
+	if r == nil {
+		return
+	}
+
+	node := &ExplainNode{
+		Type: ExplainNodeTypeBucket,
+		Details: map[string]interface{}{
+			"bucketBy": bucketBy,
+		},
+	}
+	if bucketKey != nil {
+		node.Details["bucketKey"] = *bucketKey
+	}
+	if bucketValue != nil {
+		node.Details["bucketValue"] = *bucketValue
+	}
+	if bucketByMissing != nil {
+		node.Details["bucketByMissing"] = bucketByMissing
+	}
+
+	r.record(node)
+}
+
+// recordRules records rules up to the matched one, with its percentage and allocation, and the ones after it as skipped
+func (r *explainRecorder) recordRules(reader *DatafileReader, feature *Feature, context Context, matchedTraffic *Traffic, bucketValue int) {
+	if r == nil {
+		return
+	}
+
+	reached := true
+	for _, traffic := range feature.Traffic {
+		if !reached {
+			r.record(newSkippedExplainNode(ExplainNodeTypeRule, traffic.Key))
+			continue
+		}
+
+		segments := reader.explainSegments(reader.parseSegmentsIfStringified(traffic.Segments), context)
+
+		node := newExplainNode(ExplainNodeTypeRule, traffic.Key, segments.IsMatched())
+		node.Details["percentage"] = traffic.Percentage
+		if traffic.Enabled != nil {
+			node.Details["enabled"] = *traffic.Enabled
+		}
+		if traffic.Variation != nil {
+			node.Details["variation"] = *traffic.Variation
+		}
+		if len(traffic.VariationWeights) > 0 {
+			node.Details["variationWeights"] = traffic.VariationWeights
+		}
+		node.Children = []*ExplainNode{segments}
+
+		if matchedTraffic != nil && traffic.Key == matchedTraffic.Key {
+			reached = false
+
+			percentage := newExplainNode(ExplainNodeTypePercentage, "", bucketValue <= traffic.Percentage && traffic.Percentage > 0)
+			percentage.Details["bucketValue"] = bucketValue
+			percentage.Details["percentage"] = traffic.Percentage
+			node.Children = append(node.Children, percentage)
+
+			for _, rangeItem := range feature.Ranges {
+				rangeNode := newExplainNode(ExplainNodeTypeRange, "", bucketValue >= rangeItem[0] && bucketValue < rangeItem[1])
+				rangeNode.Details["range"] = rangeItem
+				node.Children = append(node.Children, rangeNode)
+			}
+
+			for _, allocation := range getWeightedTraffic(&traffic, feature.Variations).Allocation {
+				allocationNode := newExplainNode(ExplainNodeTypeAllocation, allocation.Variation, allocation.Range[0] <= bucketValue && allocation.Range[1] >= bucketValue)
+				allocationNode.Details["range"] = allocation.Range
+				node.Children = append(node.Children, allocationNode)
+			}
+		}
+
+		r.record(node)
+	}
+}
+
+/**
+ * Feature
+ */
+
+// newExplainFeatureNode returns the root step of a feature's trace, from its flag evaluation and the steps recorded
+func newExplainFeatureNode(featureKey FeatureKey, evaluation Evaluation, steps *explainRecorder) *ExplainNode {
+	node := &ExplainNode{
+		Type:    ExplainNodeTypeFeature,
+		Key:     featureKey,
+		Matched: &[]bool{evaluation.Enabled != nil && *evaluation.Enabled}[0],
+		Details: map[string]interface{}{
+			"reason": evaluation.Reason,
+		},
+	}
+	if evaluation.RuleKey != nil {
+		node.Details["ruleKey"] = *evaluation.RuleKey
+	}
+	if evaluation.Error != nil {
+		node.Details["error"] = evaluation.Error.Error()
+	}
+	if steps != nil {
+		node.Children = steps.steps
+	}
+
+	return node
+}
+
+// explainFeature traces the evaluation of a feature flag, and evaluates its variation if it has any
+func explainFeature(featureKey FeatureKey, dependencies EvaluateDependencies) *ExplainNode {
+	recorder := &explainRecorder{}
+	flagEvaluation := Evaluate(EvaluateOptions{
+		EvaluateParams:       EvaluateParams{Type: EvaluationTypeFlag, FeatureKey: featureKey},
+		EvaluateDependencies: dependencies,
+		recorder:             recorder,
+	})
+
+	root := newExplainFeatureNode(featureKey, flagEvaluation, recorder)
+
+	feature := dependencies.DatafileReader.GetFeature(featureKey)
+	if feature != nil && len(feature.Variations) > 0 {
+		variationEvaluation := Evaluate(EvaluateOptions{
+			EvaluateParams:       EvaluateParams{Type: EvaluationTypeVariation, FeatureKey: featureKey},
+			EvaluateDependencies: dependencies,
+			recorder:             &explainRecorder{},
+		})
+		if variationEvaluation.VariationValue != nil {
+			root.Details["variation"] = *variationEvaluation.VariationValue
+		} else if variationEvaluation.Variation != nil {
+			root.Details["variation"] = variationEvaluation.Variation.Value
+		}
+		root.Details["variationReason"] = variationEvaluation.Reason
+	}
+
+	return root
+}
+
+// Explain traces how a feature is evaluated for the given context, step by step
+func (i *Featurevisor) Explain(featureKey string, args ...interface{}) *ExplainNode {
+	contextValue, optionsValue, _ := parseArgs(args, i.logger)
+
+	return explainFeature(FeatureKey(featureKey), i.getEvaluationDependencies(contextValue, optionsValue))
+}
+
+// Explain traces how a feature is evaluated for the given context, step by step
+func (c *FeaturevisorChild) Explain(featureKey string, args ...interface{}) *ExplainNode {
+	contextValue, optionsValue, _ := parseArgs(args, c.parent.logger)
+
+	return explainFeature(FeatureKey(featureKey), c.getEvaluationDependencies(contextValue, optionsValue))
+}
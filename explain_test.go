@@ -0,0 +1,215 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var explainTestDatafile = testDatafile{
+	segments: `{
+		"netherlands": {"key": "netherlands", "conditions": "[{\"attribute\":\"country\",\"operator\":\"equals\",\"value\":\"nl\"}]"},
+		"mobile": {"key": "mobile", "conditions": {"or": [{"attribute":"device","operator":"equals","value":"ios"},{"attribute":"device","operator":"equals","value":"android"}]}}
+	}`,
+	features: `{
+		"base": {
+			"key": "base",
+			"bucketBy": "userId",
+			"traffic": [{"key": "everyone", "segments": "*", "percentage": 100000}]
+		},
+		"test": {
+			"key": "test",
+			"bucketBy": "userId",
+			"required": ["base"],
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"force": [
+				{"conditions": [{"attribute": "userId", "operator": "equals", "value": "forced"}], "enabled": true, "variation": "treatment"}
+			],
+			"traffic": [
+				{"key": "nl-mobile", "segments": {"and": ["netherlands", "mobile"]}, "percentage": 100000, "allocation": [
+					{"variation": "control", "range": [0, 50000]},
+					{"variation": "treatment", "range": [50000, 100000]}
+				]},
+				{"key": "everyone", "segments": "*", "percentage": 0}
+			]
+		}
+	}`,
+}
+
+func findExplainNode(node *ExplainNode, nodeType ExplainNodeType, key string) *ExplainNode {
+	if node.Type == nodeType && node.Key == key {
+		return node
+	}
+	for _, child := range node.Children {
+		if found := findExplainNode(child, nodeType, key); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func findExplainStep(trace *ExplainNode, nodeType ExplainNodeType, key string) *ExplainNode {
+	for _, child := range trace.Children {
+		if child.Type == nodeType && child.Key == key {
+			return child
+		}
+	}
+	return nil
+}
+
+func TestExplain(t *testing.T) {
+	instance := newTestInstance(t, explainTestDatafile, Options{})
+
+	trace := instance.Explain("test", Context{"userId": "123", "country": "nl", "device": "web"})
+
+	if trace.Type != ExplainNodeTypeFeature || trace.Key != "test" || trace.IsMatched() {
+		t.Fatalf("unexpected root %+v", trace)
+	}
+	if trace.Details["reason"] != EvaluationReasonRule || trace.Details["ruleKey"] != "everyone" {
+		t.Errorf("expected disabled by everyone rule, got %v", trace.Details)
+	}
+
+	force := findExplainStep(trace, ExplainNodeTypeForce, "0")
+	if force == nil || force.IsMatched() || force.Skipped {
+		t.Errorf("expected unmatched force entry, got %+v", force)
+	}
+
+	required := findExplainStep(trace, ExplainNodeTypeRequired, "base")
+	if required == nil || !required.IsMatched() || len(required.Children) != 1 {
+		t.Errorf("expected matched required feature with its trace, got %+v", required)
+	}
+
+	rule := findExplainStep(trace, ExplainNodeTypeRule, "nl-mobile")
+	if rule == nil || rule.IsMatched() || rule.Skipped {
+		t.Fatalf("expected unmatched first rule, got %+v", rule)
+	}
+
+	netherlands := findExplainNode(rule, ExplainNodeTypeSegment, "netherlands")
+	if netherlands == nil || !netherlands.IsMatched() {
+		t.Errorf("expected netherlands segment to match, got %+v", netherlands)
+	}
+
+	mobile := findExplainNode(rule, ExplainNodeTypeSegment, "mobile")
+	if mobile == nil || mobile.IsMatched() {
+		t.Fatalf("expected mobile segment not to match, got %+v", mobile)
+	}
+
+	condition := mobile.Children[0].Children[0]
+	if condition.Type != ExplainNodeTypeCondition || condition.Details["contextValue"] != "web" || condition.Details["value"] != "ios" {
+		t.Errorf("expected condition with context value, got %+v", condition)
+	}
+
+	bucket := findExplainStep(trace, ExplainNodeTypeBucket, "")
+	if bucket == nil || bucket.Details["bucketKey"] != "123.test" {
+		t.Errorf("expected bucket key, got %+v", bucket)
+	}
+
+	text := trace.String()
+	for _, line := range []string{
+		`feature "test": not matched`,
+		`  rule "nl-mobile": not matched [percentage=100000]`,
+		`      segment "mobile": not matched`,
+		`          condition: not matched [attribute="device", contextValue="web", operator="equals", value="ios"]`,
+		`    percentage: not matched [bucketValue=31194, percentage=0]`,
+	} {
+		if !strings.Contains(text, line) {
+			t.Errorf("expected text to contain %q, got:\n%s", line, text)
+		}
+	}
+
+	output, err := trace.ToJSON()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var parsed ExplainNode
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil || parsed.Key != "test" || len(parsed.Children) != len(trace.Children) {
+		t.Errorf("expected JSON to round-trip, got %v", err)
+	}
+}
+
+func TestExplainSkipsStepsAfterDecision(t *testing.T) {
+	instance := newTestInstance(t, explainTestDatafile, Options{})
+
+	trace := instance.Explain("test", Context{"userId": "forced"})
+
+	if !trace.IsMatched() || trace.Details["reason"] != EvaluationReasonForced || trace.Details["variation"] != "treatment" {
+		t.Fatalf("expected forced evaluation, got %v", trace.Details)
+	}
+
+	force := findExplainStep(trace, ExplainNodeTypeForce, "0")
+	if !force.IsMatched() || force.Skipped {
+		t.Errorf("expected force to match, got %+v", force)
+	}
+
+	// steps after the decision are not reached
+	for _, nodeType := range []ExplainNodeType{ExplainNodeTypeRequired, ExplainNodeTypeBucket, ExplainNodeTypeRule} {
+		for _, child := range trace.Children {
+			if child.Type == nodeType {
+				t.Errorf("expected %s step not to be reached, got %+v", nodeType, child)
+			}
+		}
+	}
+
+	allocated := instance.Spawn(Context{"country": "nl", "device": "ios"}).Explain("test", Context{"userId": "123"})
+	rule := findExplainStep(allocated, ExplainNodeTypeRule, "nl-mobile")
+	if !rule.IsMatched() || rule.Skipped {
+		t.Errorf("expected first rule to match for child, got %+v", rule)
+	}
+	if everyone := findExplainStep(allocated, ExplainNodeTypeRule, "everyone"); !everyone.Skipped || everyone.Matched != nil {
+		t.Errorf("expected later rule to be skipped, got %+v", everyone)
+	}
+	if allocation := findExplainNode(rule, ExplainNodeTypeAllocation, "control"); allocation == nil {
+		t.Errorf("expected allocation of matched rule, got %+v", rule)
+	}
+}
+
+func TestExplainFeatureNotFound(t *testing.T) {
+	trace := newTestInstance(t, explainTestDatafile, Options{}).Explain("unknown")
+
+	if trace.IsMatched() || trace.Details["reason"] != EvaluationReasonFeatureNotFound || len(trace.Children) != 0 {
+		t.Errorf("unexpected trace %+v", trace)
+	}
+}
+
+func TestExplainFollowsEvaluation(t *testing.T) {
+	store := NewMemoryStickyStore()
+	instance := newTestInstance(t, testDatafile{
+		features: `{
+			"invalid": {"bucketBy": 123, "traffic": [{"key": "everyone", "segments": "*", "percentage": 100000}]},
+			"sticky": {"bucketBy": "userId", "traffic": [{"key": "everyone", "segments": "*", "percentage": 100000}]},
+			"fallback": {"bucketBy": "userId", "traffic": [{"key": "everyone", "segments": "*", "percentage": 100000}]}
+		}`,
+	}, Options{
+		StickyStore:     &StickyStoreOptions{Store: store},
+		MissingBucketBy: &MissingBucketByOptions{Policy: MissingBucketByPolicyFallback, FallbackAttribute: "deviceId"},
+		Hooks: []*Hook{
+			{
+				Name: "bucket-value",
+				BucketValue: func(options ConfigureBucketValueOptions) int {
+					return 42
+				},
+			},
+		},
+	})
+
+	// panics while bucketing are recovered, like when evaluating
+	trace := instance.Explain("invalid", Context{"userId": "u"})
+	if trace.IsMatched() || trace.Details["reason"] != EvaluationReasonError || instance.IsEnabled("invalid", Context{"userId": "u"}) {
+		t.Errorf("expected error evaluation, got %v", trace.Details)
+	}
+
+	// no sticky store records are written
+	if trace := instance.Explain("sticky", Context{"userId": "u"}); !trace.IsMatched() {
+		t.Fatalf("expected feature to be enabled, got %v", trace.Details)
+	}
+	if record, _ := store.Get("u", "sticky"); record != nil {
+		t.Errorf("expected no sticky store record, got %+v", record)
+	}
+
+	// bucketing by fallback attribute, with hooks applied
+	trace = instance.Explain("fallback", Context{"deviceId": "d1"})
+	bucket := findExplainStep(trace, ExplainNodeTypeBucket, "")
+	if bucket == nil || bucket.Details["bucketKey"] != "d1.fallback" || bucket.Details["bucketValue"] != 42 || bucket.Details["bucketBy"] != "deviceId" {
+		t.Errorf("expected bucketing by fallback attribute, got %+v", bucket)
+	}
+}
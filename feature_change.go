@@ -0,0 +1,89 @@
+package featurevisor
+
+import (
+	"reflect"
+	"sync"
+)
+
+// FeatureChangeCallback is called with the previous and new evaluated values of a feature
+type FeatureChangeCallback func(previous EvaluatedFeature, current EvaluatedFeature)
+
+// featureChangeEvents lists events which can change the evaluated values of a feature
+var featureChangeEvents = []EventName{
+	EventNameDatafileSet,
+	EventNameContextSet,
+	EventNameStickySet,
+	EventNameOverridesSet,
+}
+
+// onFeatureChange re-evaluates a feature on every event that can change it, and calls back only when its values differ
+func onFeatureChange(
+	evaluate func() EvaluatedFeature,
+	callback FeatureChangeCallback,
+	subscribe func(eventName EventName, callback EventCallback) Unsubscribe,
+) Unsubscribe {
+	var mu sync.Mutex
+	previous := evaluate()
+
+	handler := func(details EventDetails) {
+		mu.Lock()
+		current := evaluate()
+		if reflect.DeepEqual(previous, current) {
+			mu.Unlock()
+			return
+		}
+		old := previous
+		previous = current
+		mu.Unlock()
+
+		callback(old, current)
+	}
+
+	unsubscribes := make([]Unsubscribe, 0, len(featureChangeEvents))
+	for _, eventName := range featureChangeEvents {
+		unsubscribes = append(unsubscribes, subscribe(eventName, handler))
+	}
+
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// OnFeatureChange calls back whenever the evaluated flag, variation or variables of a feature change for the given context
+func (i *Featurevisor) OnFeatureChange(featureKey string, context Context, callback FeatureChangeCallback) Unsubscribe {
+	evaluate := func() EvaluatedFeature {
+		return i.GetAllEvaluations(context, []string{featureKey}, OverrideOptions{})[featureKey]
+	}
+
+	return onFeatureChange(evaluate, callback, i.emitter.On)
+}
+
+// OnFeatureChange calls back whenever the evaluated flag, variation or variables of a feature change for the given context
+func (c *FeaturevisorChild) OnFeatureChange(featureKey string, context Context, callback FeatureChangeCallback) Unsubscribe {
+	evaluate := func() EvaluatedFeature {
+		return c.GetAllEvaluations(context, []string{featureKey}, OverrideOptions{})[featureKey]
+	}
+
+	// listeners added to the parent are removed when closing the child
+	subscribe := func(eventName EventName, callback EventCallback) Unsubscribe {
+		switch eventName {
+		case EventNameStickySet:
+			return c.On(eventName, callback)
+		case EventNameContextSet:
+			// child context is merged with the parent's
+			unsubscribeChild := c.On(eventName, callback)
+			unsubscribeParent := c.onParent(eventName, callback)
+
+			return func() {
+				unsubscribeChild()
+				unsubscribeParent()
+			}
+		}
+
+		return c.onParent(eventName, callback)
+	}
+
+	return onFeatureChange(evaluate, callback, subscribe)
+}
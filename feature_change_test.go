@@ -0,0 +1,133 @@
+package featurevisor
+
+import (
+	"fmt"
+	"testing"
+)
+
+// featureChangeTestFeatures has the percentage of the "nl" rule to be formatted in
+const featureChangeTestFeatures = `{
+	"test": {
+		"bucketBy": "userId",
+		"traffic": [
+			{"key": "nl", "segments": "netherlands", "percentage": %d},
+			{"key": "everyone", "segments": "*", "percentage": 0}
+		]
+	}
+}`
+
+const featureChangeTestSegments = `{
+	"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]}
+}`
+
+func TestOnFeatureChange(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: testDatafile{segments: featureChangeTestSegments, features: fmt.Sprintf(featureChangeTestFeatures, 100000)}.build(t),
+		Context:  Context{"userId": "123"},
+	})
+
+	type change struct {
+		previous bool
+		current  bool
+	}
+	changes := []change{}
+
+	unsubscribe := instance.OnFeatureChange("test", nil, func(previous EvaluatedFeature, current EvaluatedFeature) {
+		changes = append(changes, change{previous.Enabled, current.Enabled})
+	})
+
+	// context change
+	instance.SetContext(Context{"country": "nl"})
+	if len(changes) != 1 || changes[0] != (change{false, true}) {
+		t.Fatalf("expected change after context set, got %v", changes)
+	}
+
+	// unrelated context change
+	instance.SetContext(Context{"device": "mobile"})
+	if len(changes) != 1 {
+		t.Fatalf("did not expect change for unrelated context, got %v", changes)
+	}
+
+	// datafile change
+	instance.SetDatafile(testDatafile{segments: featureChangeTestSegments, features: fmt.Sprintf(featureChangeTestFeatures, 0)}.build(t))
+	if len(changes) != 2 || changes[1] != (change{true, false}) {
+		t.Fatalf("expected change after datafile set, got %v", changes)
+	}
+
+	// sticky change
+	instance.SetSticky(StickyFeatures{"test": {Enabled: true}})
+	if len(changes) != 3 || changes[2] != (change{false, true}) {
+		t.Fatalf("expected change after sticky set, got %v", changes)
+	}
+
+	unsubscribe()
+
+	instance.SetSticky(StickyFeatures{}, true)
+	if len(changes) != 3 {
+		t.Fatalf("did not expect changes after unsubscribing, got %v", changes)
+	}
+}
+
+func TestChildOnFeatureChange(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: testDatafile{segments: featureChangeTestSegments, features: fmt.Sprintf(featureChangeTestFeatures, 100000)}.build(t),
+	})
+	child := instance.Spawn(Context{"userId": "123"})
+
+	calls := 0
+	child.OnFeatureChange("test", nil, func(previous EvaluatedFeature, current EvaluatedFeature) {
+		calls++
+	})
+
+	child.SetContext(Context{"country": "nl"})
+	if calls != 1 {
+		t.Fatalf("expected change after child context set, got %d", calls)
+	}
+
+	instance.SetDatafile(testDatafile{segments: featureChangeTestSegments, features: fmt.Sprintf(featureChangeTestFeatures, 0)}.build(t))
+	if calls != 2 {
+		t.Fatalf("expected change after parent datafile set, got %d", calls)
+	}
+
+	child.SetSticky(StickyFeatures{"test": {Enabled: true}})
+	if calls != 3 {
+		t.Fatalf("expected change after child sticky set, got %d", calls)
+	}
+
+	instance.SetContext(Context{"country": "de"})
+	if calls != 3 {
+		t.Fatalf("expected no change when evaluated values stay the same, got %d", calls)
+	}
+}
+
+func TestChildOnFeatureChangeRemovedOnClose(t *testing.T) {
+	instance := newTestInstance(t, testDatafile{segments: featureChangeTestSegments, features: fmt.Sprintf(featureChangeTestFeatures, 100000)}, Options{})
+	child := instance.Spawn(Context{"userId": "123"})
+
+	calls := 0
+	child.OnFeatureChange("test", nil, func(previous EvaluatedFeature, current EvaluatedFeature) {
+		calls++
+	})
+
+	unsubscribe := child.OnFeatureChange("test", nil, func(previous EvaluatedFeature, current EvaluatedFeature) {})
+	unsubscribe()
+
+	for _, eventName := range featureChangeEvents {
+		if eventName != EventNameStickySet && instance.emitter.GetListenerCount(eventName) != 1 {
+			t.Fatalf("expected one parent listener for %s, got %d", eventName, instance.emitter.GetListenerCount(eventName))
+		}
+	}
+
+	child.Close()
+
+	for _, eventName := range featureChangeEvents {
+		if count := instance.emitter.GetListenerCount(eventName); count != 0 {
+			t.Errorf("expected no parent listeners for %s after closing child, got %d", eventName, count)
+		}
+	}
+
+	instance.SetContext(Context{"country": "nl"})
+	if calls != 0 {
+		t.Errorf("did not expect calls after closing child, got %d", calls)
+	}
+}
@@ -59,6 +59,11 @@ func GetValueByType(value interface{}, fieldType string) interface{} {
 	case "json":
 		// JSON type is handled specially in the calling code
 		return value
+	case "date":
+		if date, err := ParseDate(value, nil); err == nil {
+			return date
+		}
+		return nil
 	default:
 		return value
 	}
@@ -161,20 +166,29 @@ func decodeInto(value interface{}, out interface{}) error {
 	return nil
 }
 
+// parseVariableIntoArgs finds context, override options and the output pointer in variadic arguments,
+// with context given as Context, a map or a tagged struct like in parseArgs
 func parseVariableIntoArgs(args ...interface{}) (Context, OverrideOptions, interface{}, error) {
 	context := Context{}
 	options := OverrideOptions{}
 	var out interface{}
 
 	for _, arg := range args {
-		switch value := arg.(type) {
-		case Context:
-			context = value
-		case map[string]interface{}:
-			context = Context(value)
-		case OverrideOptions:
+		if value, ok := arg.(OverrideOptions); ok {
 			options = value
-		default:
+			continue
+		}
+
+		if isContextArg(arg) {
+			value, err := ContextFrom(arg)
+			if err != nil {
+				return Context{}, OverrideOptions{}, nil, err
+			}
+			context = value
+			continue
+		}
+
+		if arg != nil {
 			if out != nil {
 				return Context{}, OverrideOptions{}, nil, fmt.Errorf("multiple output arguments provided")
 			}
@@ -1,5 +1,7 @@
 package featurevisor
 
+import "sort"
+
 // ConfigureBucketKeyOptions contains options for configuring bucket key
 type ConfigureBucketKeyOptions struct {
 	FeatureKey FeatureKey `json:"featureKey"`
@@ -22,14 +24,70 @@ type ConfigureBucketValueOptions struct {
 // ConfigureBucketValue is a function type for configuring bucket value
 type ConfigureBucketValue func(options ConfigureBucketValueOptions) BucketValue
 
+// HookFilter scopes a hook to specific features and evaluation types (empty lists match all)
+type HookFilter struct {
+	FeatureKeys []FeatureKey     `json:"featureKeys,omitempty"`
+	Types       []EvaluationType `json:"types,omitempty"`
+}
+
+// Matches checks if the filter matches given feature key and evaluation type
+func (f *HookFilter) Matches(featureKey FeatureKey, evaluationType EvaluationType) bool {
+	if f == nil {
+		return true
+	}
+
+	if len(f.FeatureKeys) > 0 {
+		found := false
+		for _, key := range f.FeatureKeys {
+			if key == featureKey {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			if t == evaluationType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Hook represents a hook that can be executed during evaluation
+//
+// Before can skip evaluation by setting options.Evaluation to a ready evaluation.
+// OnError runs when evaluation results in an error (including panics), and can return a fallback evaluation.
+// Finally always runs last, after all other stages.
 type Hook struct {
 	Name string `json:"name"`
 
+	// Priority decides the order hooks run in: higher first, and insertion order for equal priorities
+	Priority int         `json:"priority,omitempty"`
+	Filter   *HookFilter `json:"filter,omitempty"`
+
 	Before      func(options EvaluateOptions) EvaluateOptions                   `json:"before,omitempty"`
 	BucketKey   ConfigureBucketKey                                              `json:"bucketKey,omitempty"`
 	BucketValue ConfigureBucketValue                                            `json:"bucketValue,omitempty"`
 	After       func(evaluation Evaluation, options EvaluateOptions) Evaluation `json:"after,omitempty"`
+	OnError     func(evaluation Evaluation, options EvaluateOptions) Evaluation `json:"onError,omitempty"`
+	Finally     func(evaluation Evaluation, options EvaluateOptions)            `json:"finally,omitempty"`
+}
+
+// Matches checks if the hook applies to given feature key and evaluation type
+func (h *Hook) Matches(featureKey FeatureKey, evaluationType EvaluationType) bool {
+	return h.Filter.Matches(featureKey, evaluationType)
 }
 
 // HooksManagerOptions contains options for creating a hooks manager
@@ -75,6 +133,10 @@ func (hm *HooksManager) Add(hook *Hook) func() {
 
 	hm.hooks = append(hm.hooks, hook)
 
+	sort.SliceStable(hm.hooks, func(i, j int) bool {
+		return hm.hooks[i].Priority > hm.hooks[j].Priority
+	})
+
 	// Return a function to remove the hook
 	return func() {
 		hm.Remove(hook.Name)
@@ -92,7 +154,18 @@ func (hm *HooksManager) Remove(name string) {
 	hm.hooks = newHooks
 }
 
-// GetAll returns all hooks
+// GetAll returns all hooks, in the order they run
 func (hm *HooksManager) GetAll() []*Hook {
 	return hm.hooks
 }
+
+// GetMatching returns hooks applying to given feature key and evaluation type, in the order they run
+func (hm *HooksManager) GetMatching(featureKey FeatureKey, evaluationType EvaluationType) []*Hook {
+	matchingHooks := make([]*Hook, 0, len(hm.hooks))
+	for _, hook := range hm.hooks {
+		if hook.Matches(featureKey, evaluationType) {
+			matchingHooks = append(matchingHooks, hook)
+		}
+	}
+	return matchingHooks
+}
@@ -0,0 +1,163 @@
+package featurevisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+var hooksTestDatafile = testDatafile{
+	features: `{
+		"test": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		},
+		"other": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "*", "percentage": 100000}]
+		}
+	}`,
+}
+
+func TestBeforeHookShortCircuit(t *testing.T) {
+	evaluated := false
+
+	instance := CreateInstance(Options{
+		Datafile: hooksTestDatafile.build(t),
+		Hooks: []*Hook{
+			{
+				Name: "cache",
+				Before: func(options EvaluateOptions) EvaluateOptions {
+					options.Evaluation = &Evaluation{
+						Type:       options.Type,
+						FeatureKey: options.FeatureKey,
+						Reason:     EvaluationReasonRule,
+						Enabled:    &[]bool{false}[0],
+					}
+					return options
+				},
+				BucketKey: func(options ConfigureBucketKeyOptions) BucketKey {
+					evaluated = true
+					return options.BucketKey
+				},
+			},
+		},
+	})
+
+	if instance.IsEnabled("test", Context{"userId": "123"}) {
+		t.Fatalf("expected evaluation from before hook to be used")
+	}
+
+	if evaluated {
+		t.Fatalf("expected evaluation to be skipped")
+	}
+}
+
+func TestOnErrorAndFinallyHooks(t *testing.T) {
+	var finallyEvaluation Evaluation
+
+	instance := CreateInstance(Options{
+		Datafile: hooksTestDatafile.build(t),
+		Hooks: []*Hook{
+			{
+				Name: "panicking",
+				Before: func(options EvaluateOptions) EvaluateOptions {
+					panic("boom")
+				},
+			},
+			{
+				Name: "fallback",
+				OnError: func(evaluation Evaluation, options EvaluateOptions) Evaluation {
+					if evaluation.Error == nil {
+						t.Errorf("expected error in evaluation passed to OnError")
+					}
+					evaluation.Enabled = &[]bool{true}[0]
+					return evaluation
+				},
+				Finally: func(evaluation Evaluation, options EvaluateOptions) {
+					finallyEvaluation = evaluation
+				},
+			},
+		},
+	})
+
+	if !instance.IsEnabled("test", Context{"userId": "123"}) {
+		t.Fatalf("expected fallback evaluation from OnError hook")
+	}
+
+	if finallyEvaluation.Reason != EvaluationReasonError {
+		t.Fatalf("expected finally hook to receive error evaluation, got %s", finallyEvaluation.Reason)
+	}
+}
+
+func TestOnErrorHookForEvaluatePanic(t *testing.T) {
+	called := false
+
+	instance := CreateInstance(Options{
+		Datafile: hooksTestDatafile.build(t),
+		Hooks: []*Hook{
+			{
+				Name: "panicking-bucket-key",
+				BucketKey: func(options ConfigureBucketKeyOptions) BucketKey {
+					panic("boom")
+				},
+				OnError: func(evaluation Evaluation, options EvaluateOptions) Evaluation {
+					called = true
+					return evaluation
+				},
+			},
+		},
+	})
+
+	evaluation := instance.EvaluateFlag("test", Context{"userId": "123"}, OverrideOptions{})
+
+	if evaluation.Reason != EvaluationReasonError || evaluation.Error == nil {
+		t.Fatalf("expected error evaluation, got %+v", evaluation)
+	}
+
+	if !called {
+		t.Fatalf("expected OnError hook to be called")
+	}
+}
+
+func TestHooksPriorityAndFilter(t *testing.T) {
+	order := []string{}
+
+	newHook := func(name string, priority int, filter *HookFilter) *Hook {
+		return &Hook{
+			Name:     name,
+			Priority: priority,
+			Filter:   filter,
+			Before: func(options EvaluateOptions) EvaluateOptions {
+				order = append(order, name)
+				return options
+			},
+		}
+	}
+
+	instance := CreateInstance(Options{
+		Datafile: hooksTestDatafile.build(t),
+		Hooks: []*Hook{
+			newHook("low", -1, nil),
+			newHook("default-1", 0, nil),
+			newHook("high", 10, nil),
+			newHook("default-2", 0, nil),
+			newHook("other-only", 20, &HookFilter{FeatureKeys: []FeatureKey{"other"}}),
+			newHook("variation-only", 20, &HookFilter{Types: []EvaluationType{EvaluationTypeVariation}}),
+		},
+	})
+
+	instance.IsEnabled("test")
+
+	expected := []string{"high", "default-1", "default-2", "low"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected order %v, got %v", expected, order)
+	}
+
+	order = []string{}
+	instance.IsEnabled("other")
+
+	expected = []string{"other-only", "high", "default-1", "default-2", "low"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected order %v, got %v", expected, order)
+	}
+}
@@ -3,11 +3,14 @@ package featurevisor
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 )
 
 // OverrideOptions contains options for overriding evaluation
 type OverrideOptions struct {
-	Sticky *StickyFeatures
+	Sticky      *StickyFeatures
+	StickyStore *StickyStoreOptions
 
 	DefaultVariationValue *VariationValue
 	DefaultVariableValue  VariableValue
@@ -21,19 +24,56 @@ type Options struct {
 	Logger   *Logger
 	Sticky   *StickyFeatures
 	Hooks    []*Hook
+
+	StickyStore *StickyStoreOptions
+	Overrides   *OverridesOptions
+	Operators   Operators
+
+	// StrictSemver compares versions in semver operators by SemVer 2.0 precedence
+	StrictSemver bool
+
+	// TimeZone is used for dates without a time zone in before and after conditions (defaults to UTC)
+	TimeZone *time.Location
+
+	// Attributes, if set, are used for validating and coercing context
+	Attributes []Attribute
+
+	// StrictAttributes leaves unknown and mistyped attributes out of context
+	StrictAttributes bool
+
+	// MissingBucketBy decides what happens when attributes of bucketBy are missing in context (ignored by default)
+	MissingBucketBy *MissingBucketByOptions
+
+	// Strict populates Evaluation.Error for features and variables not found, and evaluating before a datafile is set
+	Strict bool
 }
 
 // Featurevisor represents a Featurevisor SDK instance
 type Featurevisor struct {
 	// from options
-	context Context
-	logger  *Logger
-	sticky  *StickyFeatures
+	context      Context
+	logger       *Logger
+	sticky       *StickyFeatures
+	stickyStore  *StickyStoreOptions
+	operators    Operators
+	strictSemver bool
+	timeZone     *time.Location
+
+	missingBucketBy *MissingBucketByOptions
+	strict          bool
+
+	// whether a datafile is set
+	ready bool
 
 	// internally created
-	datafileReader *DatafileReader
-	hooksManager   *HooksManager
-	emitter        *Emitter
+	datafileReader   *DatafileReader
+	hooksManager     *HooksManager
+	overridesManager *OverridesManager
+	emitter          *Emitter
+
+	// context validation, if attributes are defined
+	contextValidator    *ContextValidator
+	warnedContextIssues sync.Map
 }
 
 // NewFeaturevisor creates a new Featurevisor instance
@@ -65,6 +105,17 @@ func NewFeaturevisor(options Options) *Featurevisor {
 	// Create emitter
 	emitter := NewEmitter()
 
+	// Create overrides manager
+	overridesManager := NewOverridesManager(OverridesManagerOptions{
+		Logger: logger,
+		OnChange: func(source OverrideSource, featureKeys []FeatureKey) {
+			params := getParamsForOverridesSetEvent(source, featureKeys)
+
+			logger.Info("overrides set", params)
+			emitter.Trigger(EventNameOverridesSet, EventDetails(params))
+		},
+	})
+
 	// Create datafile reader
 	emptyDatafile := DatafileContent{
 		SchemaVersion: "2",
@@ -73,33 +124,85 @@ func NewFeaturevisor(options Options) *Featurevisor {
 		Features:      make(map[FeatureKey]Feature),
 	}
 
+	// Custom operators can not replace built-in ones
+	for operator := range options.Operators {
+		if IsBuiltInOperator(operator) {
+			logger.Warn("ignoring custom operator with the name of a built-in one", LogDetails{"operator": operator})
+		}
+	}
+
 	datafileReader := NewDatafileReader(DatafileReaderOptions{
-		Datafile: emptyDatafile,
-		Logger:   logger,
+		Datafile:     emptyDatafile,
+		Logger:       logger,
+		Operators:    options.Operators,
+		StrictSemver: options.StrictSemver,
+		TimeZone:     options.TimeZone,
 	})
 
 	// If datafile is provided, set it
+	ready := false
 	if options.Datafile != nil {
 		datafileContent, err := parseDatafileInput(options.Datafile)
 		if err != nil {
 			logger.Error("could not parse datafile", LogDetails{"error": err})
 		} else {
 			datafileReader = NewDatafileReader(DatafileReaderOptions{
-				Datafile: datafileContent,
-				Logger:   logger,
+				Datafile:     datafileContent,
+				Logger:       logger,
+				Operators:    options.Operators,
+				StrictSemver: options.StrictSemver,
+				TimeZone:     options.TimeZone,
 			})
+			ready = true
 		}
 	}
 
+	// Validate context against attribute definitions
+	var contextValidator *ContextValidator
+	if len(options.Attributes) > 0 {
+		contextValidator = NewContextValidator(ContextValidatorOptions{
+			Attributes: options.Attributes,
+			Strict:     options.StrictAttributes,
+			TimeZone:   options.TimeZone,
+		})
+	}
+
 	instance := &Featurevisor{
-		context:        context,
-		logger:         logger,
-		hooksManager:   hooksManager,
-		emitter:        emitter,
-		datafileReader: datafileReader,
-		sticky:         options.Sticky,
+		context:          context,
+		logger:           logger,
+		hooksManager:     hooksManager,
+		overridesManager: overridesManager,
+		emitter:          emitter,
+		datafileReader:   datafileReader,
+		sticky:           options.Sticky,
+		stickyStore:      options.StickyStore,
+		operators:        options.Operators,
+		strictSemver:     options.StrictSemver,
+		timeZone:         options.TimeZone,
+		contextValidator: contextValidator,
+		missingBucketBy:  options.MissingBucketBy,
+		strict:           options.Strict,
+		ready:            ready,
+	}
+
+	// Load overrides
+	if options.Overrides != nil {
+		if options.Overrides.FromEnv {
+			if err := overridesManager.LoadFromEnv(); err != nil {
+				logger.Error("could not load overrides from env", LogDetails{"error": err})
+			}
+		}
+
+		if options.Overrides.FilePath != "" {
+			if err := overridesManager.LoadFromFile(options.Overrides.FilePath); err != nil {
+				logger.Error("could not load overrides from file", LogDetails{"error": err})
+			}
+			overridesManager.WatchFile(options.Overrides.FilePath, options.Overrides.WatchInterval)
+		}
 	}
 
+	instance.context = instance.validateContext(instance.context)
+
 	logger.Info("Featurevisor SDK initialized", LogDetails{})
 
 	return instance
@@ -119,15 +222,20 @@ func (i *Featurevisor) SetDatafile(datafile interface{}) {
 	}
 
 	newDatafileReader := NewDatafileReader(DatafileReaderOptions{
-		Datafile: datafileContent,
-		Logger:   i.logger,
+		Datafile:     datafileContent,
+		Logger:       i.logger,
+		Operators:    i.operators,
+		StrictSemver: i.strictSemver,
+		TimeZone:     i.timeZone,
 	})
 
 	details := getParamsForDatafileSetEvent(i.datafileReader, newDatafileReader)
 
 	i.datafileReader = newDatafileReader
+	i.ready = true
 
-	i.logger.Info("datafile set", details)
+	i.logger.Info("datafile set", getLogDetailsForDatafileSetEvent(details))
+	i.logger.Debug("datafile diff", LogDetails{"diff": details["diff"]})
 	i.emitter.Trigger(EventNameDatafileSet, EventDetails(details))
 }
 
@@ -163,11 +271,36 @@ func (i *Featurevisor) SetSticky(sticky StickyFeatures, replace ...bool) {
 	i.emitter.Trigger(EventNameStickySet, EventDetails(params))
 }
 
+// Override forces evaluated values of a feature locally, taking precedence over file and env overrides
+func (i *Featurevisor) Override(featureKey string, feature EvaluatedFeature) {
+	i.overridesManager.Set(OverrideSourceRuntime, FeatureKey(featureKey), feature)
+}
+
+// RemoveOverride removes the runtime override of a feature
+func (i *Featurevisor) RemoveOverride(featureKey string) {
+	i.overridesManager.Remove(OverrideSourceRuntime, FeatureKey(featureKey))
+}
+
+// GetOverrides returns the effective local overrides of all features
+func (i *Featurevisor) GetOverrides() EvaluatedFeatures {
+	return i.overridesManager.GetAll()
+}
+
 // GetRevision returns the revision
 func (i *Featurevisor) GetRevision() string {
 	return i.datafileReader.GetRevision()
 }
 
+// GetDatafileValidationResult returns the result of validating the current datafile
+func (i *Featurevisor) GetDatafileValidationResult() DatafileValidationResult {
+	return i.datafileReader.GetValidationResult()
+}
+
+// DependencyGraph returns the dependency graph of features and segments in the current datafile
+func (i *Featurevisor) DependencyGraph() *DependencyGraph {
+	return i.datafileReader.DependencyGraph()
+}
+
 // GetFeature returns a feature by key
 func (i *Featurevisor) GetFeature(featureKey string) *Feature {
 	return i.datafileReader.GetFeature(FeatureKey(featureKey))
@@ -186,10 +319,18 @@ func (i *Featurevisor) On(eventName EventName, callback EventCallback) Unsubscri
 // Close closes the instance
 func (i *Featurevisor) Close() {
 	i.emitter.ClearAll()
+	i.overridesManager.Close()
 }
 
-// SetContext sets the context
-func (i *Featurevisor) SetContext(context Context, replace ...bool) {
+// SetContext sets the context, given as Context, a map or a tagged struct (see ContextFrom)
+func (i *Featurevisor) SetContext(value interface{}, replace ...bool) {
+	context, err := ContextFrom(value)
+	if err != nil {
+		i.logger.Error("could not set context", LogDetails{"error": err})
+		return
+	}
+	context = i.validateContext(context)
+
 	replaceValue := false
 	if len(replace) > 0 {
 		replaceValue = replace[0]
@@ -236,24 +377,13 @@ func (i *Featurevisor) GetContext(context Context) Context {
 
 // Spawn creates a child instance
 func (i *Featurevisor) Spawn(args ...interface{}) *FeaturevisorChild {
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, _ := parseArgs(args, i.logger)
 
 	return NewFeaturevisorChild(ChildOptions{
-		Parent:  i,
-		Context: i.GetContext(contextValue),
-		Sticky:  optionsValue.Sticky,
+		Parent:      i,
+		Context:     i.GetContext(i.validateContext(contextValue)),
+		Sticky:      optionsValue.Sticky,
+		StickyStore: optionsValue.StickyStore,
 	})
 }
 
@@ -278,129 +408,144 @@ func (i *Featurevisor) getEvaluationDependencies(context Context, options Overri
 		sticky = i.sticky
 	}
 
+	stickyStore := i.stickyStore
+	if options.StickyStore != nil {
+		stickyStore = options.StickyStore
+	}
+
 	return EvaluateDependencies{
-		Context:               i.GetContext(context),
+		Context:               i.GetContext(i.validateContext(context)),
 		Logger:                i.logger,
 		HooksManager:          i.hooksManager,
 		DatafileReader:        i.datafileReader,
+		Overrides:             i.overridesManager,
 		Sticky:                sticky,
+		StickyStore:           stickyStore,
 		DefaultVariationValue: options.DefaultVariationValue,
 		DefaultVariableValue:  options.DefaultVariableValue,
+		MissingBucketBy:       i.missingBucketBy,
+		Strict:                i.strict,
+		notReady:              !i.ready,
 	}
 }
 
 // EvaluateFlag evaluates a feature flag
-func (i *Featurevisor) EvaluateFlag(featureKey string, context Context, options OverrideOptions) Evaluation {
+func (i *Featurevisor) EvaluateFlag(featureKey string, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:       EvaluationTypeFlag,
 			FeatureKey: FeatureKey(featureKey),
 		},
-		EvaluateDependencies: i.getEvaluationDependencies(context, options),
+		EvaluateDependencies: i.getEvaluationDependencies(toContext(context, i.logger), options),
 	})
 }
 
 // IsEnabled checks if a feature is enabled
 func (i *Featurevisor) IsEnabled(featureKey string, args ...interface{}) bool {
+	enabled, _ := i.IsEnabledE(featureKey, args...)
+	return enabled
+}
+
+// IsEnabledE checks if a feature is enabled, with an error if it could not be evaluated
+func (i *Featurevisor) IsEnabledE(featureKey string, args ...interface{}) (enabled bool, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			i.logger.Error("isEnabled", LogDetails{
 				"featureKey": featureKey,
 				"error":      r,
 			})
+			enabled, err = false, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, i.logger)
 
 	evaluation := i.EvaluateFlag(featureKey, contextValue, optionsValue)
+	err = getEvaluationError(evaluation, i.ready)
+	if argsErr != nil {
+		err = argsErr
+	}
 
 	if evaluation.Enabled != nil {
-		return *evaluation.Enabled
+		return *evaluation.Enabled, err
 	}
 
-	return false
+	return false, err
 }
 
 // EvaluateVariation evaluates a feature variation
-func (i *Featurevisor) EvaluateVariation(featureKey string, context Context, options OverrideOptions) Evaluation {
+func (i *Featurevisor) EvaluateVariation(featureKey string, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:       EvaluationTypeVariation,
 			FeatureKey: FeatureKey(featureKey),
 		},
-		EvaluateDependencies: i.getEvaluationDependencies(context, options),
+		EvaluateDependencies: i.getEvaluationDependencies(toContext(context, i.logger), options),
 	})
 }
 
 // GetVariation gets a feature variation
 func (i *Featurevisor) GetVariation(featureKey string, args ...interface{}) *string {
+	variation, _ := i.GetVariationE(featureKey, args...)
+	return variation
+}
+
+// GetVariationE gets a feature variation, with an error if it could not be evaluated
+func (i *Featurevisor) GetVariationE(featureKey string, args ...interface{}) (variation *string, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			i.logger.Error("getVariation", LogDetails{
 				"featureKey": featureKey,
 				"error":      r,
 			})
+			variation, err = nil, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, i.logger)
 
 	evaluation := i.EvaluateVariation(featureKey, contextValue, optionsValue)
+	err = getEvaluationError(evaluation, i.ready)
+	if argsErr != nil {
+		err = argsErr
+	}
 
 	if evaluation.VariationValue != nil {
 		// VariationValue is already a string type alias
 		variationValue := string(*evaluation.VariationValue)
-		return &variationValue
+		return &variationValue, err
 	}
 
 	if evaluation.Variation != nil {
 		// Variation.Value is already a VariationValue (string)
 		variationValue := string(evaluation.Variation.Value)
-		return &variationValue
+		return &variationValue, err
 	}
 
-	return nil
+	return nil, err
 }
 
 // EvaluateVariable evaluates a feature variable
-func (i *Featurevisor) EvaluateVariable(featureKey string, variableKey VariableKey, context Context, options OverrideOptions) Evaluation {
+func (i *Featurevisor) EvaluateVariable(featureKey string, variableKey VariableKey, context interface{}, options OverrideOptions) Evaluation {
 	return EvaluateWithHooks(EvaluateOptions{
 		EvaluateParams: EvaluateParams{
 			Type:        EvaluationTypeVariable,
 			FeatureKey:  FeatureKey(featureKey),
 			VariableKey: &variableKey,
 		},
-		EvaluateDependencies: i.getEvaluationDependencies(context, options),
+		EvaluateDependencies: i.getEvaluationDependencies(toContext(context, i.logger), options),
 	})
 }
 
 // GetVariable gets a feature variable
 func (i *Featurevisor) GetVariable(featureKey string, variableKey string, args ...interface{}) VariableValue {
+	value, _ := i.GetVariableE(featureKey, variableKey, args...)
+	return value
+}
+
+// GetVariableE gets a feature variable, with an error if it could not be evaluated.
+// The value is the same as GetVariable returns, like the default value for invalid ones.
+func (i *Featurevisor) GetVariableE(featureKey string, variableKey string, args ...interface{}) (value VariableValue, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			i.logger.Error("getVariable", LogDetails{
@@ -408,137 +553,91 @@ func (i *Featurevisor) GetVariable(featureKey string, variableKey string, args .
 				"variableKey": variableKey,
 				"error":       r,
 			})
+			value, err = nil, newPanicError(r)
 		}
 	}()
 
-	// Default values
-	contextValue := Context{}
-	optionsValue := OverrideOptions{}
-
-	// Parse variadic arguments
-	for _, arg := range args {
-		switch v := arg.(type) {
-		case Context:
-			contextValue = v
-		case OverrideOptions:
-			optionsValue = v
-		}
-	}
+	contextValue, optionsValue, argsErr := parseArgs(args, i.logger)
 
 	evaluation := i.EvaluateVariable(featureKey, VariableKey(variableKey), contextValue, optionsValue)
-
-	if evaluation.VariableValue != nil {
-		// Handle JSON variables
-		if evaluation.VariableSchema != nil && evaluation.VariableSchema.Type == "json" {
-			if variableStr, ok := evaluation.VariableValue.(string); ok {
-				var parsedJSON interface{}
-				if err := json.Unmarshal([]byte(variableStr), &parsedJSON); err == nil {
-					return parsedJSON
-				} else {
-					// Log error if JSON parsing fails
-					i.logger.Error("could not parse JSON variable", LogDetails{
-						"featureKey":  featureKey,
-						"variableKey": variableKey,
-						"error":       err,
-					})
-				}
-			}
-		}
-
-		// Apply type conversion for default values
-		if evaluation.VariableSchema != nil && evaluation.Reason == EvaluationReasonVariableDefault {
-			return GetValueByType(evaluation.VariableValue, string(evaluation.VariableSchema.Type))
-		}
-
-		return evaluation.VariableValue
+	err = getEvaluationError(evaluation, i.ready)
+	if argsErr != nil {
+		err = argsErr
 	}
 
-	return nil
+	return toVariableValue(evaluation, err, variableKey, i.logger)
 }
 
 // GetVariableBoolean gets a boolean variable
 func (i *Featurevisor) GetVariableBoolean(featureKey string, variableKey string, args ...interface{}) *bool {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "boolean")
-	if boolValue, ok := typedValue.(bool); ok {
-		return &boolValue
-	}
+	value, _ := i.GetVariableBooleanE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableBooleanE gets a boolean variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableBooleanE(featureKey string, variableKey string, args ...interface{}) (*bool, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[bool](value, err, variableKey, VariableTypeBoolean)
 }
 
 // GetVariableString gets a string variable
 func (i *Featurevisor) GetVariableString(featureKey string, variableKey string, args ...interface{}) *string {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "string")
-	if stringValue, ok := typedValue.(string); ok {
-		return &stringValue
-	}
+	value, _ := i.GetVariableStringE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableStringE gets a string variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableStringE(featureKey string, variableKey string, args ...interface{}) (*string, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[string](value, err, variableKey, VariableTypeString)
 }
 
 // GetVariableInteger gets an integer variable
 func (i *Featurevisor) GetVariableInteger(featureKey string, variableKey string, args ...interface{}) *int {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "integer")
-	if intValue, ok := typedValue.(int); ok {
-		return &intValue
-	}
+	value, _ := i.GetVariableIntegerE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableIntegerE gets an integer variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableIntegerE(featureKey string, variableKey string, args ...interface{}) (*int, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[int](value, err, variableKey, VariableTypeInteger)
 }
 
 // GetVariableDouble gets a double variable
 func (i *Featurevisor) GetVariableDouble(featureKey string, variableKey string, args ...interface{}) *float64 {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := GetValueByType(value, "double")
-	if floatValue, ok := typedValue.(float64); ok {
-		return &floatValue
-	}
+	value, _ := i.GetVariableDoubleE(featureKey, variableKey, args...)
+	return value
+}
 
-	return nil
+// GetVariableDoubleE gets a double variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableDoubleE(featureKey string, variableKey string, args ...interface{}) (*float64, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toTypedVariable[float64](value, err, variableKey, VariableTypeDouble)
 }
 
 // GetVariableArray gets an array variable
 func (i *Featurevisor) GetVariableArray(featureKey string, variableKey string, args ...interface{}) []string {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
+	value, _ := i.GetVariableArrayE(featureKey, variableKey, args...)
+	return value
+}
 
-	return ToTypedArray[string](GetValueByType(value, "array"))
+// GetVariableArrayE gets an array variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableArrayE(featureKey string, variableKey string, args ...interface{}) ([]string, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toArrayVariable(value, err, variableKey)
 }
 
 // GetVariableObject gets an object variable
 func (i *Featurevisor) GetVariableObject(featureKey string, variableKey string, args ...interface{}) map[string]interface{} {
-	value := i.GetVariable(featureKey, variableKey, args...)
-	if value == nil {
-		return nil
-	}
-
-	typedValue := ToTypedObject[map[string]interface{}](GetValueByType(value, "object"))
-	if typedValue == nil {
-		return nil
-	}
+	value, _ := i.GetVariableObjectE(featureKey, variableKey, args...)
+	return value
+}
 
-	return *typedValue
+// GetVariableObjectE gets an object variable, with an error if it could not be evaluated, or is not of the type
+func (i *Featurevisor) GetVariableObjectE(featureKey string, variableKey string, args ...interface{}) (map[string]interface{}, error) {
+	value, err := i.GetVariableE(featureKey, variableKey, args...)
+	return toObjectVariable(value, err, variableKey)
 }
 
 // GetVariableJSON gets a JSON variable
@@ -552,6 +651,11 @@ func (i *Featurevisor) GetVariableJSON(featureKey string, variableKey string, ar
 	return value
 }
 
+// GetVariableJSONE gets a JSON variable, with an error if it could not be evaluated, or parsed
+func (i *Featurevisor) GetVariableJSONE(featureKey string, variableKey string, args ...interface{}) (interface{}, error) {
+	return i.GetVariableE(featureKey, variableKey, args...)
+}
+
 // GetVariableArrayInto decodes an array variable into the provided pointer output.
 // Supported argument order (after featureKey, variableKey): out OR context, out OR context, options, out.
 func (i *Featurevisor) GetVariableArrayInto(featureKey string, variableKey string, args ...interface{}) error {
@@ -595,8 +699,9 @@ func (i *Featurevisor) GetVariableObjectInto(featureKey string, variableKey stri
 }
 
 // GetAllEvaluations gets all evaluations for features
-func (i *Featurevisor) GetAllEvaluations(context Context, featureKeys []string, options OverrideOptions) EvaluatedFeatures {
+func (i *Featurevisor) GetAllEvaluations(context interface{}, featureKeys []string, options OverrideOptions) EvaluatedFeatures {
 	result := EvaluatedFeatures{}
+	contextValue := toContext(context, i.logger)
 
 	keys := featureKeys
 	if len(keys) == 0 {
@@ -611,12 +716,12 @@ func (i *Featurevisor) GetAllEvaluations(context Context, featureKeys []string,
 	for _, featureKey := range keys {
 		// isEnabled
 		evaluatedFeature := EvaluatedFeature{
-			Enabled: i.IsEnabled(featureKey, context, options),
+			Enabled: i.IsEnabled(featureKey, contextValue, options),
 		}
 
 		// variation
 		if i.datafileReader.HasVariations(FeatureKey(featureKey)) {
-			variation := i.GetVariation(featureKey, context, options)
+			variation := i.GetVariation(featureKey, contextValue, options)
 			if variation != nil {
 				evaluatedFeature.Variation = variation
 			}
@@ -630,7 +735,7 @@ func (i *Featurevisor) GetAllEvaluations(context Context, featureKeys []string,
 				evaluatedFeature.Variables[variableKey] = i.GetVariable(
 					featureKey,
 					string(variableKey),
-					context,
+					contextValue,
 					options,
 				)
 			}
@@ -678,3 +783,37 @@ func parseDatafileInput(datafile interface{}) (DatafileContent, error) {
 		return DatafileContent{}, fmt.Errorf("unsupported datafile input type: %T", datafile)
 	}
 }
+
+// validateContext validates and coerces context against attribute definitions, logging each issue once
+func (i *Featurevisor) validateContext(context Context) Context {
+	if i.contextValidator == nil || len(context) == 0 {
+		return context
+	}
+
+	result := i.contextValidator.Validate(context)
+
+	for _, issue := range result.Issues {
+		key := string(issue.Type) + ":" + issue.Attribute
+		if _, warned := i.warnedContextIssues.LoadOrStore(key, true); warned {
+			continue
+		}
+
+		details := LogDetails{
+			"attribute": issue.Attribute,
+			"type":      issue.Type,
+			"value":     issue.Value,
+			"message":   issue.Message,
+		}
+		if issue.Expected != "" {
+			details["expected"] = issue.Expected
+		}
+
+		if i.contextValidator.strict {
+			i.logger.Error("rejected context attribute", details)
+		} else {
+			i.logger.Warn("invalid context attribute", details)
+		}
+	}
+
+	return result.Context
+}
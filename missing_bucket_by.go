@@ -0,0 +1,136 @@
+package featurevisor
+
+import "strings"
+
+// MissingBucketByPolicy decides what happens when attributes of a feature's bucketBy are missing in context
+type MissingBucketByPolicy string
+
+const (
+	MissingBucketByPolicyIgnore   MissingBucketByPolicy = "ignore"   // bucket by the attributes found, like other SDKs (default)
+	MissingBucketByPolicyWarn     MissingBucketByPolicy = "warn"     // same as ignore, with a warning logged once per feature
+	MissingBucketByPolicyDisable  MissingBucketByPolicy = "disable"  // evaluate as disabled, with the reason bucket_by_missing
+	MissingBucketByPolicyFallback MissingBucketByPolicy = "fallback" // bucket by a fallback attribute, or evaluate as disabled if it is missing too
+)
+
+// MissingBucketByOptions contains options for handling attributes of bucketBy missing in context
+type MissingBucketByOptions struct {
+	Policy MissingBucketByPolicy
+
+	// FallbackAttribute is bucketed by instead, with the fallback policy (like "deviceId")
+	FallbackAttribute AttributeKey
+}
+
+// BucketByMissing reports attributes of bucketBy missing in context, and how they were handled
+type BucketByMissing struct {
+	Attributes        []AttributeKey        `json:"attributes"`
+	Policy            MissingBucketByPolicy `json:"policy"`
+	FallbackAttribute *AttributeKey         `json:"fallbackAttribute,omitempty"` // set if bucketed by it instead
+}
+
+// parseBucketBy returns the type of bucketBy ("plain", "and" or "or") and its attribute keys
+func parseBucketBy(bucketBy BucketBy) (string, []AttributeKey, bool) {
+	switch b := bucketBy.(type) {
+	case string:
+		return "plain", []string{b}, true
+	case []string:
+		return "and", b, true
+	case OrBucketBy:
+		return "or", b.Or, true
+	case map[string]interface{}:
+		// Handle JSON unmarshaled bucketBy
+		if orValue, exists := b["or"]; exists {
+			var attributeKeys []string
+			if orArray, ok := orValue.([]string); ok {
+				attributeKeys = orArray
+			} else if orArray, ok := orValue.([]interface{}); ok {
+				attributeKeys = make([]string, len(orArray))
+				for i, v := range orArray {
+					if str, ok := v.(string); ok {
+						attributeKeys[i] = str
+					}
+				}
+			}
+			return "or", attributeKeys, true
+		}
+
+		// This is a plain string case that was unmarshaled as map
+		for key := range b {
+			return "plain", []string{key}, true
+		}
+		return "plain", nil, true
+	case []interface{}:
+		// Handle JSON unmarshaled array
+		attributeKeys := make([]string, len(b))
+		for i, v := range b {
+			if str, ok := v.(string); ok {
+				attributeKeys[i] = str
+			}
+		}
+		return "and", attributeKeys, true
+	}
+
+	return "", nil, false
+}
+
+// getMissingBucketByAttributes returns attributes of bucketBy missing in context:
+// any of them for plain and "and" bucketBy, or all of them if none is found for "or"
+func getMissingBucketByAttributes(bucketBy BucketBy, context Context) []AttributeKey {
+	bucketType, attributeKeys, ok := parseBucketBy(bucketBy)
+	if !ok {
+		return nil
+	}
+
+	var missing []AttributeKey
+	for _, attributeKey := range attributeKeys {
+		if GetValueFromContext(context, attributeKey) == nil {
+			missing = append(missing, attributeKey)
+		} else if bucketType == "or" {
+			return nil
+		}
+	}
+
+	return missing
+}
+
+// warnMissingBucketBy logs a warning for attributes of bucketBy missing in context, once per feature and attributes
+func (d *DatafileReader) warnMissingBucketBy(featureKey FeatureKey, missing []AttributeKey) {
+	key := featureKey + ":" + strings.Join(missing, ",")
+	if _, warned := d.warnedMissingBucketBy.LoadOrStore(key, true); warned {
+		return
+	}
+
+	d.logger.Warn("bucketBy attributes missing in context", LogDetails{
+		"featureKey": featureKey,
+		"attributes": missing,
+	})
+}
+
+// getBucketByMissing reports attributes of the feature's bucketBy missing in context, applying the policy:
+// warning about them, or finding the fallback attribute. It returns nil if none is missing.
+func getBucketByMissing(options EvaluateOptions, feature *Feature) *BucketByMissing {
+	missing := getMissingBucketByAttributes(feature.BucketBy, options.Context)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	bucketByMissing := &BucketByMissing{
+		Attributes: missing,
+		Policy:     MissingBucketByPolicyIgnore,
+	}
+
+	if options.MissingBucketBy != nil && options.MissingBucketBy.Policy != "" {
+		bucketByMissing.Policy = options.MissingBucketBy.Policy
+	}
+
+	switch bucketByMissing.Policy {
+	case MissingBucketByPolicyWarn:
+		options.DatafileReader.warnMissingBucketBy(options.FeatureKey, missing)
+	case MissingBucketByPolicyFallback:
+		fallbackAttribute := options.MissingBucketBy.FallbackAttribute
+		if fallbackAttribute != "" && GetValueFromContext(options.Context, fallbackAttribute) != nil {
+			bucketByMissing.FallbackAttribute = &fallbackAttribute
+		}
+	}
+
+	return bucketByMissing
+}
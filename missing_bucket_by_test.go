@@ -0,0 +1,141 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var missingBucketByTestDatafile = testDatafile{
+	features: `{
+		"checkout": {
+			"bucketBy": ["organizationId", "userId"],
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"variablesSchema": {
+				"color": {"type": "string", "defaultValue": "red"}
+			},
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"allocation": [
+						{"variation": "control", "range": [0, 50000]},
+						{"variation": "treatment", "range": [50000, 100000]}
+					]
+				}
+			]
+		},
+		"search": {
+			"bucketBy": {"or": ["userId", "deviceId"]},
+			"traffic": [{"key": "everyone", "segments": "*", "percentage": 100000}]
+		}
+	}`,
+}
+
+func TestMissingBucketByIgnore(t *testing.T) {
+	instance := newTestInstance(t, missingBucketByTestDatafile, Options{
+		Logger: newTestLogger(func(level LogLevel, message LogMessage, details LogDetails) {
+			t.Errorf("unexpected log %q", message)
+		}),
+	})
+
+	evaluation := instance.EvaluateFlag("checkout", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.BucketKey == nil || *evaluation.BucketKey != "123.checkout" {
+		t.Fatalf("expected bucketing by attributes found, got %+v", evaluation)
+	}
+	if evaluation.BucketByMissing == nil || evaluation.BucketByMissing.Policy != MissingBucketByPolicyIgnore || evaluation.BucketByMissing.Attributes[0] != "organizationId" {
+		t.Errorf("expected missing attributes to be reported, got %+v", evaluation.BucketByMissing)
+	}
+
+	// none missing
+	if evaluation := instance.EvaluateFlag("checkout", Context{"userId": "123", "organizationId": "1"}, OverrideOptions{}); evaluation.BucketByMissing != nil {
+		t.Errorf("expected no report, got %+v", evaluation.BucketByMissing)
+	}
+
+	// "or" bucketBy needs only one of them
+	if evaluation := instance.EvaluateFlag("search", Context{"deviceId": "d1"}, OverrideOptions{}); evaluation.BucketByMissing != nil {
+		t.Errorf("expected no report, got %+v", evaluation.BucketByMissing)
+	}
+	if evaluation := instance.EvaluateFlag("search", Context{}, OverrideOptions{}); evaluation.BucketByMissing == nil || len(evaluation.BucketByMissing.Attributes) != 2 {
+		t.Errorf("expected both attributes to be reported, got %+v", evaluation.BucketByMissing)
+	}
+}
+
+func TestMissingBucketByWarn(t *testing.T) {
+	var warnings []LogDetails
+	instance := newTestInstance(t, missingBucketByTestDatafile, Options{
+		Logger: newTestLogger(func(level LogLevel, message LogMessage, details LogDetails) {
+			if message == "bucketBy attributes missing in context" {
+				warnings = append(warnings, details)
+			}
+		}),
+		MissingBucketBy: &MissingBucketByOptions{Policy: MissingBucketByPolicyWarn},
+	})
+
+	for i := 0; i < 3; i++ {
+		if !instance.IsEnabled("checkout", Context{"userId": "123"}) {
+			t.Fatal("expected feature to be enabled")
+		}
+	}
+
+	if len(warnings) != 1 || warnings[0]["featureKey"] != "checkout" {
+		t.Errorf("expected one warning, got %v", warnings)
+	}
+}
+
+func TestMissingBucketByDisable(t *testing.T) {
+	instance := newTestInstance(t, missingBucketByTestDatafile, Options{
+		MissingBucketBy: &MissingBucketByOptions{Policy: MissingBucketByPolicyDisable},
+	})
+	context := Context{"userId": "123"}
+
+	evaluation := instance.EvaluateFlag("checkout", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonBucketByMissing || evaluation.Enabled == nil || *evaluation.Enabled {
+		t.Fatalf("expected disabled flag with reason bucket_by_missing, got %+v", evaluation)
+	}
+	if evaluation.BucketByMissing == nil || evaluation.BucketByMissing.Policy != MissingBucketByPolicyDisable {
+		t.Errorf("expected missing attributes to be reported, got %+v", evaluation.BucketByMissing)
+	}
+
+	if instance.IsEnabled("checkout", context) {
+		t.Error("expected feature to be disabled")
+	}
+	if variation := instance.GetVariation("checkout", context); variation != nil {
+		t.Errorf("expected no variation, got %v", *variation)
+	}
+
+	evaluation = instance.EvaluateVariable("checkout", "color", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonBucketByMissing || evaluation.BucketByMissing == nil {
+		t.Errorf("expected variable evaluation to report missing attributes, got %+v", evaluation)
+	}
+
+	// reported in JSON
+	bytes, _ := json.Marshal(instance.EvaluateFlag("checkout", context, OverrideOptions{}))
+	var parsed Evaluation
+	if err := json.Unmarshal(bytes, &parsed); err != nil || parsed.BucketByMissing == nil || parsed.BucketByMissing.Attributes[0] != "organizationId" {
+		t.Errorf("expected report to survive JSON, got %s", bytes)
+	}
+}
+
+func TestMissingBucketByFallback(t *testing.T) {
+	instance := newTestInstance(t, missingBucketByTestDatafile, Options{
+		MissingBucketBy: &MissingBucketByOptions{
+			Policy:            MissingBucketByPolicyFallback,
+			FallbackAttribute: "deviceId",
+		},
+	})
+
+	evaluation := instance.EvaluateFlag("checkout", Context{"userId": "123", "deviceId": "d1"}, OverrideOptions{})
+	if evaluation.BucketKey == nil || *evaluation.BucketKey != "d1.checkout" {
+		t.Fatalf("expected bucketing by fallback attribute, got %+v", evaluation)
+	}
+	if evaluation.BucketByMissing == nil || evaluation.BucketByMissing.FallbackAttribute == nil || *evaluation.BucketByMissing.FallbackAttribute != "deviceId" {
+		t.Errorf("expected fallback attribute to be reported, got %+v", evaluation.BucketByMissing)
+	}
+
+	// fallback attribute missing too
+	evaluation = instance.EvaluateFlag("checkout", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonBucketByMissing || *evaluation.Enabled {
+		t.Errorf("expected disabled flag, got %+v", evaluation)
+	}
+}
@@ -0,0 +1,210 @@
+package featurevisor
+
+import (
+	"cmp"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// toFloat64 converts any Go number to float64, including values of named numeric types
+func toFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	// named numeric types, like `type Age int`
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+
+	return 0, false
+}
+
+// toNumber normalizes any Go number or json.Number to float64, the way numbers are represented in JSON and JavaScript
+func toNumber(value interface{}) (float64, bool) {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	f, ok := toFloat64(value)
+	if !ok || math.IsNaN(f) {
+		return 0, false
+	}
+
+	return f, true
+}
+
+// toInteger converts any Go integer or integral json.Number exactly, as int64 or, with unsigned being true, as uint64
+func toInteger(value interface{}) (signedValue int64, unsignedValue uint64, unsigned bool, ok bool) {
+	if n, isJSONNumber := value.(json.Number); isJSONNumber {
+		if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+			return i, 0, false, true
+		}
+		if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+			return 0, u, true, true
+		}
+		return 0, 0, false, false
+	}
+
+	// also named numeric types, like `type UserID int64`
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), 0, false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 0, rv.Uint(), true, true
+	}
+
+	return 0, 0, false, false
+}
+
+// compareIntegers compares two integers exactly, even above 2^53, returning false if either one is not an integer
+func compareIntegers(a, b interface{}) (int, bool) {
+	aInt, aUint, aUnsigned, ok := toInteger(a)
+	if !ok {
+		return 0, false
+	}
+
+	bInt, bUint, bUnsigned, ok := toInteger(b)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case !aUnsigned && !bUnsigned:
+		return cmp.Compare(aInt, bInt), true
+	case aUnsigned && bUnsigned:
+		return cmp.Compare(aUint, bUint), true
+	case aUnsigned:
+		if bInt < 0 {
+			return 1, true
+		}
+		return cmp.Compare(aUint, uint64(bInt)), true
+	default:
+		if aInt < 0 {
+			return -1, true
+		}
+		return cmp.Compare(uint64(aInt), bUint), true
+	}
+}
+
+// isNumber tells whether a value is any Go number or json.Number
+func isNumber(value interface{}) bool {
+	_, ok := toNumber(value)
+	return ok
+}
+
+// valuesEqual compares two values, treating numbers of different Go types as equal if their values are,
+// and comparing slices, arrays and maps deeply. Integers are compared exactly, and other numbers as float64.
+func valuesEqual(a, b interface{}) bool {
+	if result, ok := compareIntegers(a, b); ok {
+		return result == 0
+	}
+
+	if aNum, ok := toNumber(a); ok {
+		bNum, ok := toNumber(b)
+		return ok && aNum == bNum
+	}
+
+	if isNumber(b) {
+		return false
+	}
+
+	if equal, ok := collectionsEqual(a, b); ok {
+		return equal
+	}
+
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if reflect.TypeOf(a).Comparable() && reflect.TypeOf(b).Comparable() {
+		return a == b
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compareNumbers compares two values numerically, returning false if either one is not a number.
+// Integers are compared exactly, and other numbers as float64.
+func compareNumbers(a, b interface{}) (int, bool) {
+	if result, ok := compareIntegers(a, b); ok {
+		return result, true
+	}
+
+	aNum, ok := toNumber(a)
+	if !ok {
+		return 0, false
+	}
+
+	bNum, ok := toNumber(b)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case aNum < bNum:
+		return -1, true
+	case aNum > bNum:
+		return 1, true
+	}
+
+	return 0, true
+}
+
+// formatNumber formats a number the way JavaScript's String(number) does,
+// with the shortest digits representing it exactly, and exponents below 1e-6 and from 1e21
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		// also -0
+		return "0"
+	}
+
+	if abs := math.Abs(f); abs >= 1e-6 && abs < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	// exponents have no leading zeros in JavaScript, like 1e-7 instead of 1e-07
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	return mantissa + "e" + exponent[:1] + strings.TrimLeft(exponent[1:], "0")
+}
@@ -0,0 +1,159 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+// named numeric types, like ones used for context attributes
+type (
+	numbersTestAge   int
+	numbersTestCount uint16
+	numbersTestScore float32
+)
+
+func TestNumericConditionConformance(t *testing.T) {
+	// every Go number type holding 5, compared against datafile numbers which are float64
+	values := map[string]interface{}{
+		"int":         int(5),
+		"int8":        int8(5),
+		"int16":       int16(5),
+		"int32":       int32(5),
+		"int64":       int64(5),
+		"uint":        uint(5),
+		"uint8":       uint8(5),
+		"uint16":      uint16(5),
+		"uint32":      uint32(5),
+		"uint64":      uint64(5),
+		"float32":     float32(5),
+		"float64":     float64(5),
+		"json.Number": json.Number("5"),
+		"named int":   numbersTestAge(5),
+		"named uint":  numbersTestCount(5),
+		"named float": numbersTestScore(5),
+	}
+
+	cases := []struct {
+		operator Operator
+		value    interface{}
+		expected bool
+	}{
+		{OperatorEquals, float64(5), true},
+		{OperatorEquals, float64(6), false},
+		{OperatorNotEquals, float64(5), false},
+		{OperatorNotEquals, float64(6), true},
+		{OperatorIn, []interface{}{float64(4), float64(5)}, true},
+		{OperatorIn, []interface{}{float64(4), float64(6)}, false},
+		{OperatorNotIn, []interface{}{float64(4), float64(5)}, false},
+		{OperatorNotIn, []interface{}{float64(4), float64(6)}, true},
+		{OperatorGreaterThan, float64(4), true},
+		{OperatorGreaterThan, float64(5), false},
+		{OperatorGreaterThanOrEquals, float64(5), true},
+		{OperatorGreaterThanOrEquals, float64(6), false},
+		{OperatorLessThan, float64(6), true},
+		{OperatorLessThan, float64(5), false},
+		{OperatorLessThanOrEquals, float64(5), true},
+		{OperatorLessThanOrEquals, float64(4), false},
+		{OperatorEquals, "5", false},
+	}
+
+	for typeName, contextValue := range values {
+		for _, c := range cases {
+			condition := PlainCondition{
+				Attribute: "n",
+				Operator:  c.operator,
+				Value:     conditionValue(c.value),
+			}
+
+			result := ConditionIsMatched(condition, Context{"n": contextValue}, nil)
+			if result != c.expected {
+				t.Errorf("%s %s %v: expected %v, got %v", typeName, c.operator, c.value, c.expected, result)
+			}
+		}
+
+		includes := PlainCondition{Attribute: "list", Operator: OperatorIncludes, Value: conditionValue(float64(5))}
+		if !ConditionIsMatched(includes, Context{"list": []interface{}{float64(1), contextValue}}, nil) {
+			t.Errorf("%s: expected list to include 5", typeName)
+		}
+
+		notIncludes := PlainCondition{Attribute: "list", Operator: OperatorNotIncludes, Value: conditionValue(float64(5))}
+		if ConditionIsMatched(notIncludes, Context{"list": []interface{}{contextValue}}, nil) {
+			t.Errorf("%s: expected notIncludes to not match", typeName)
+		}
+
+		if got := toString(contextValue); got != "5" {
+			t.Errorf("%s: expected bucket key part %q, got %q", typeName, "5", got)
+		}
+	}
+}
+
+func TestIntegersAbove2To53(t *testing.T) {
+	// 2^53 + 1 is the same float64 as 2^53
+	id := int64(9007199254740993)
+
+	tests := []struct {
+		a, b     interface{}
+		expected int
+	}{
+		{id, int64(9007199254740992), 1},
+		{id, id, 0},
+		{uint64(9007199254740993), int64(9007199254740992), 1},
+		{int64(9007199254740992), uint64(9007199254740993), -1},
+		{uint64(math.MaxUint64), int64(math.MaxInt64), 1},
+		{int64(-1), uint64(math.MaxUint64), -1},
+		{json.Number("9007199254740993"), id, 0},
+		{json.Number("9007199254740993"), json.Number("9007199254740992"), 1},
+		{numbersTestAge(5), uint8(5), 0},
+	}
+
+	for _, tt := range tests {
+		if result, ok := compareNumbers(tt.a, tt.b); !ok || result != tt.expected {
+			t.Errorf("compareNumbers(%v, %v): expected %d, got %d", tt.a, tt.b, tt.expected, result)
+		}
+		if equal := valuesEqual(tt.a, tt.b); equal != (tt.expected == 0) {
+			t.Errorf("valuesEqual(%v, %v): expected %v, got %v", tt.a, tt.b, tt.expected == 0, equal)
+		}
+	}
+
+	// in conditions and schema enums
+	in := PlainCondition{Attribute: "id", Operator: OperatorIn, Value: conditionValue([]interface{}{int64(9007199254740992)})}
+	if ConditionIsMatched(in, Context{"id": id}, nil) {
+		t.Error("expected 9007199254740993 not to be in [9007199254740992]")
+	}
+	reader := NewDatafileReader(DatafileReaderOptions{
+		Datafile: DatafileContent{SchemaVersion: "2"},
+		Logger:   NewLogger(CreateLoggerOptions{Level: &[]LogLevel{LogLevelFatal}[0]}),
+	})
+	if violations := reader.ValidateVariableValue(id, VariableSchema{Type: VariableTypeInteger, Enum: []Value{int64(9007199254740992)}}); len(violations) == 0 {
+		t.Error("expected 9007199254740993 not to be in enum [9007199254740992]")
+	}
+
+	// floats and mixed values are compared as float64
+	if !valuesEqual(float64(5), int64(5)) || !valuesEqual(json.Number("1.5"), float32(1.5)) {
+		t.Error("expected mixed numbers to be compared by value")
+	}
+}
+
+func TestNumericConditionNegativeAndFractional(t *testing.T) {
+	condition := PlainCondition{Attribute: "n", Operator: OperatorLessThan, Value: conditionValue(float64(0))}
+	if !ConditionIsMatched(condition, Context{"n": int8(-3)}, nil) {
+		t.Error("expected int8(-3) < 0")
+	}
+	if ConditionIsMatched(condition, Context{"n": uint64(3)}, nil) {
+		t.Error("expected uint64(3) not < 0")
+	}
+
+	condition = PlainCondition{Attribute: "n", Operator: OperatorEquals, Value: conditionValue(float64(1.5))}
+	if !ConditionIsMatched(condition, Context{"n": json.Number("1.5")}, nil) {
+		t.Error("expected json.Number(1.5) to equal 1.5")
+	}
+	if !ConditionIsMatched(condition, Context{"n": float32(1.5)}, nil) {
+		t.Error("expected float32(1.5) to equal 1.5")
+	}
+
+	condition = PlainCondition{Attribute: "n", Operator: OperatorGreaterThan, Value: conditionValue(float64(1))}
+	if ConditionIsMatched(condition, Context{"n": json.Number("abc")}, nil) {
+		t.Error("expected invalid json.Number not to match")
+	}
+}
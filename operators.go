@@ -0,0 +1,120 @@
+package featurevisor
+
+import (
+	"fmt"
+	"sync"
+)
+
+// OperatorParams contains everything a custom operator gets for matching a condition
+type OperatorParams struct {
+	Condition      PlainCondition
+	Context        Context
+	ContextValue   interface{}    // value of the condition's attribute in context, nil if missing
+	ConditionValue ConditionValue // nil if condition has no value
+	GetRegex       GetRegex
+}
+
+// OperatorFunc tells whether a condition with a custom operator is matched
+type OperatorFunc func(params OperatorParams) bool
+
+// Operators maps custom operators to their handlers
+type Operators map[Operator]OperatorFunc
+
+// builtInOperators lists operators handled by ConditionIsMatched itself
+var builtInOperators = map[Operator]bool{
+	OperatorEquals:                    true,
+	OperatorNotEquals:                 true,
+	OperatorExists:                    true,
+	OperatorNotExists:                 true,
+	OperatorGreaterThan:               true,
+	OperatorGreaterThanOrEquals:       true,
+	OperatorLessThan:                  true,
+	OperatorLessThanOrEquals:          true,
+	OperatorContains:                  true,
+	OperatorNotContains:               true,
+	OperatorStartsWith:                true,
+	OperatorEndsWith:                  true,
+	OperatorSemverEquals:              true,
+	OperatorSemverNotEquals:           true,
+	OperatorSemverGreaterThan:         true,
+	OperatorSemverGreaterThanOrEquals: true,
+	OperatorSemverLessThan:            true,
+	OperatorSemverLessThanOrEquals:    true,
+	OperatorSemverSatisfies:           true,
+	OperatorBefore:                    true,
+	OperatorAfter:                     true,
+	OperatorIncludes:                  true,
+	OperatorNotIncludes:               true,
+	OperatorMatches:                   true,
+	OperatorNotMatches:                true,
+	OperatorIn:                        true,
+	OperatorNotIn:                     true,
+}
+
+var (
+	registeredOperators   = Operators{}
+	registeredOperatorsMu sync.RWMutex
+)
+
+// IsBuiltInOperator tells whether an operator is handled by the SDK itself
+func IsBuiltInOperator(operator Operator) bool {
+	return builtInOperators[operator]
+}
+
+// RegisterOperator registers a custom operator globally, for all instances
+func RegisterOperator(operator Operator, fn OperatorFunc) error {
+	if IsBuiltInOperator(operator) {
+		return fmt.Errorf("cannot register built-in operator %q", operator)
+	}
+	if fn == nil {
+		return fmt.Errorf("operator %q has no handler", operator)
+	}
+
+	registeredOperatorsMu.Lock()
+	defer registeredOperatorsMu.Unlock()
+
+	registeredOperators[operator] = fn
+
+	return nil
+}
+
+// UnregisterOperator removes a globally registered custom operator
+func UnregisterOperator(operator Operator) {
+	registeredOperatorsMu.Lock()
+	defer registeredOperatorsMu.Unlock()
+
+	delete(registeredOperators, operator)
+}
+
+// getOperatorFunc finds the handler of a custom operator, preferring the given operators over global ones
+func getOperatorFunc(operator Operator, operators Operators) OperatorFunc {
+	if fn, exists := operators[operator]; exists && fn != nil {
+		return fn
+	}
+
+	registeredOperatorsMu.RLock()
+	defer registeredOperatorsMu.RUnlock()
+
+	return registeredOperators[operator]
+}
+
+// matchCustomOperator matches a condition with a custom operator, with handled being false if it has no handler
+func matchCustomOperator(condition PlainCondition, context Context, getRegex GetRegex, operators Operators) (matched bool, handled bool) {
+	fn := getOperatorFunc(condition.Operator, operators)
+	if fn == nil {
+		return false, false
+	}
+
+	var conditionValue ConditionValue
+	if condition.Value != nil {
+		conditionValue = *condition.Value
+	}
+
+	return fn(OperatorParams{
+		Condition:      condition,
+		Context:        context,
+		ContextValue:   GetValueFromContext(context, string(condition.Attribute)),
+		ConditionValue: conditionValue,
+		GetRegex:       getRegex,
+	}), true
+}
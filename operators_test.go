@@ -0,0 +1,126 @@
+package featurevisor
+
+import (
+	"net"
+	"regexp"
+	"testing"
+)
+
+var operatorsTestDatafile = testDatafile{
+	segments: `{
+		"office": {
+			"conditions": [{"attribute": "ip", "operator": "inCIDR", "value": "10.0.0.0/8"}]
+		},
+		"unknown": {
+			"conditions": [{"attribute": "ip", "operator": "nearby", "value": 10}]
+		}
+	}`,
+	features: `{
+		"office": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "office", "percentage": 100000}]
+		},
+		"unknown": {
+			"bucketBy": "userId",
+			"traffic": [{"key": "1", "segments": "unknown", "percentage": 100000}]
+		}
+	}`,
+}
+
+func inCIDR(params OperatorParams) bool {
+	ip, _ := params.ContextValue.(string)
+	cidr, _ := params.ConditionValue.(string)
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+
+	parsed := net.ParseIP(ip)
+	return parsed != nil && network.Contains(parsed)
+}
+
+func TestCustomOperatorFromOptions(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: operatorsTestDatafile.build(t),
+		Operators: Operators{
+			"inCIDR": inCIDR,
+		},
+	})
+
+	if !instance.IsEnabled("office", Context{"userId": "123", "ip": "10.1.2.3"}) {
+		t.Fatalf("expected custom operator to match")
+	}
+
+	if instance.IsEnabled("office", Context{"userId": "123", "ip": "192.168.1.1"}) {
+		t.Fatalf("expected custom operator not to match")
+	}
+
+	// also used after setting a new datafile
+	instance.SetDatafile(operatorsTestDatafile.build(t))
+
+	if !instance.IsEnabled("office", Context{"userId": "123", "ip": "10.1.2.3"}) {
+		t.Fatalf("expected custom operator to match after setting datafile")
+	}
+}
+
+func TestRegisterOperator(t *testing.T) {
+	if err := RegisterOperator(OperatorEquals, inCIDR); err == nil {
+		t.Fatalf("expected error when registering a built-in operator")
+	}
+
+	if err := RegisterOperator("inCIDR", inCIDR); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer UnregisterOperator("inCIDR")
+
+	conditionValue := ConditionValue("10.0.0.0/8")
+	condition := PlainCondition{
+		Attribute: "ip",
+		Operator:  "inCIDR",
+		Value:     &conditionValue,
+	}
+
+	getRegex := func(regexString string, regexFlags string) *regexp.Regexp {
+		return regexp.MustCompile(regexString)
+	}
+
+	if !ConditionIsMatched(condition, Context{"ip": "10.0.0.1"}, getRegex) {
+		t.Fatalf("expected globally registered operator to match")
+	}
+
+	// options take precedence over globally registered operators
+	reader := NewDatafileReader(DatafileReaderOptions{
+		Operators: Operators{
+			"inCIDR": func(params OperatorParams) bool { return false },
+		},
+	})
+
+	if reader.AllConditionsAreMatched(condition, Context{"ip": "10.0.0.1"}) {
+		t.Fatalf("expected operator from options to take precedence")
+	}
+}
+
+func TestUnknownOperatorWarning(t *testing.T) {
+	warnings := 0
+	handler := LogHandler(func(level LogLevel, message LogMessage, details LogDetails) {
+		if level == LogLevelWarn && message == "unknown operator" {
+			warnings++
+		}
+	})
+
+	instance := CreateInstance(Options{
+		Datafile: operatorsTestDatafile.build(t),
+		Logger:   NewLogger(CreateLoggerOptions{Handler: &handler}),
+	})
+
+	for i := 0; i < 3; i++ {
+		if instance.IsEnabled("unknown", Context{"userId": "123", "ip": "10.1.2.3"}) {
+			t.Fatalf("expected unknown operator not to match")
+		}
+	}
+
+	if warnings != 1 {
+		t.Fatalf("expected a single warning for unknown operator, got %d", warnings)
+	}
+}
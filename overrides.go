@@ -0,0 +1,272 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// OverridesEnvVariable is the environment variable overrides are loaded from
+const OverridesEnvVariable = "FEATUREVISOR_OVERRIDES"
+
+// DefaultOverridesWatchInterval is how often the overrides file is checked for changes
+const DefaultOverridesWatchInterval = time.Second
+
+// OverrideSource represents where an override comes from
+type OverrideSource string
+
+const (
+	OverrideSourceEnv     OverrideSource = "env"
+	OverrideSourceFile    OverrideSource = "file"
+	OverrideSourceRuntime OverrideSource = "runtime"
+)
+
+// OverrideSources lists all sources in order of precedence, highest first
+var OverrideSources = []OverrideSource{
+	OverrideSourceRuntime,
+	OverrideSourceFile,
+	OverrideSourceEnv,
+}
+
+// OverridesOptions contains options for loading overrides
+type OverridesOptions struct {
+	// FromEnv loads overrides as JSON from the FEATUREVISOR_OVERRIDES environment variable
+	FromEnv bool
+
+	// FilePath loads overrides from a JSON file, which is watched for changes
+	FilePath string
+
+	// WatchInterval is how often the file is checked for changes (defaults to 1 second)
+	WatchInterval time.Duration
+}
+
+// OverridesChangeHandler is called whenever overrides of a source change
+type OverridesChangeHandler func(source OverrideSource, featureKeys []FeatureKey)
+
+// OverridesManagerOptions contains options for creating an overrides manager
+type OverridesManagerOptions struct {
+	Logger   *Logger
+	OnChange OverridesChangeHandler
+}
+
+// OverridesManager keeps local overrides from all sources
+type OverridesManager struct {
+	layers   map[OverrideSource]EvaluatedFeatures
+	logger   *Logger
+	onChange OverridesChangeHandler
+	mu       sync.RWMutex
+
+	stopWatching chan struct{}
+	watchOnce    sync.Once
+}
+
+// NewOverridesManager creates a new overrides manager instance
+func NewOverridesManager(options OverridesManagerOptions) *OverridesManager {
+	return &OverridesManager{
+		layers:   make(map[OverrideSource]EvaluatedFeatures),
+		logger:   options.Logger,
+		onChange: options.OnChange,
+	}
+}
+
+// Get returns the override of a feature from the source with the highest precedence
+func (m *OverridesManager) Get(featureKey FeatureKey) (*EvaluatedFeature, OverrideSource, bool) {
+	if m == nil {
+		return nil, "", false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, source := range OverrideSources {
+		if feature, exists := m.layers[source][featureKey]; exists {
+			return &feature, source, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// GetAll returns the effective overrides of all features
+func (m *OverridesManager) GetAll() EvaluatedFeatures {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := EvaluatedFeatures{}
+	for i := len(OverrideSources) - 1; i >= 0; i-- {
+		for featureKey, feature := range m.layers[OverrideSources[i]] {
+			result[featureKey] = feature
+		}
+	}
+
+	return result
+}
+
+// Set overrides a single feature in the given source
+func (m *OverridesManager) Set(source OverrideSource, featureKey FeatureKey, feature EvaluatedFeature) {
+	m.mu.Lock()
+	if m.layers[source] == nil {
+		m.layers[source] = EvaluatedFeatures{}
+	}
+	m.layers[source][featureKey] = feature
+	m.mu.Unlock()
+
+	m.changed(source, []FeatureKey{featureKey})
+}
+
+// Remove removes the override of a single feature from the given source
+func (m *OverridesManager) Remove(source OverrideSource, featureKey FeatureKey) {
+	m.mu.Lock()
+	_, exists := m.layers[source][featureKey]
+	delete(m.layers[source], featureKey)
+	m.mu.Unlock()
+
+	if exists {
+		m.changed(source, []FeatureKey{featureKey})
+	}
+}
+
+// Replace replaces all overrides of the given source, notifying of the features whose overrides changed
+func (m *OverridesManager) Replace(source OverrideSource, features EvaluatedFeatures) {
+	// copied, so later changes to the caller's map do not apply
+	replacement := make(EvaluatedFeatures, len(features))
+	for featureKey, feature := range features {
+		replacement[featureKey] = feature
+	}
+
+	m.mu.Lock()
+	previous := m.layers[source]
+	m.layers[source] = replacement
+	m.mu.Unlock()
+
+	affected := map[FeatureKey]bool{}
+	for featureKey, feature := range previous {
+		if current, exists := replacement[featureKey]; !exists || !reflect.DeepEqual(feature, current) {
+			affected[featureKey] = true
+		}
+	}
+	for featureKey := range replacement {
+		if _, exists := previous[featureKey]; !exists {
+			affected[featureKey] = true
+		}
+	}
+
+	if len(affected) > 0 {
+		m.changed(source, sortedKeys(affected))
+	}
+}
+
+func (m *OverridesManager) changed(source OverrideSource, featureKeys []FeatureKey) {
+	if m.onChange != nil {
+		m.onChange(source, featureKeys)
+	}
+}
+
+// LoadFromEnv loads overrides from the FEATUREVISOR_OVERRIDES environment variable
+func (m *OverridesManager) LoadFromEnv() error {
+	value := os.Getenv(OverridesEnvVariable)
+	if value == "" {
+		return nil
+	}
+
+	features := EvaluatedFeatures{}
+	if err := json.Unmarshal([]byte(value), &features); err != nil {
+		return fmt.Errorf("invalid %s: %w", OverridesEnvVariable, err)
+	}
+
+	m.Replace(OverrideSourceEnv, features)
+
+	return nil
+}
+
+// LoadFromFile loads overrides from a JSON file, clearing file overrides if it does not exist
+func (m *OverridesManager) LoadFromFile(path string) error {
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		m.Replace(OverrideSourceFile, EvaluatedFeatures{})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read overrides file: %w", err)
+	}
+
+	features := EvaluatedFeatures{}
+	if len(bytes) > 0 {
+		if err := json.Unmarshal(bytes, &features); err != nil {
+			return fmt.Errorf("invalid overrides file: %w", err)
+		}
+	}
+
+	m.Replace(OverrideSourceFile, features)
+
+	return nil
+}
+
+// WatchFile reloads overrides from the file whenever its modification time changes, until Close is called
+func (m *OverridesManager) WatchFile(path string, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultOverridesWatchInterval
+	}
+
+	m.mu.Lock()
+	if m.stopWatching != nil {
+		m.mu.Unlock()
+		m.logger.Warn("overrides file is already being watched", LogDetails{"path": path})
+		return
+	}
+	stop := make(chan struct{})
+	m.stopWatching = stop
+	m.mu.Unlock()
+
+	lastModified := getOverridesFileModTime(path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				modified := getOverridesFileModTime(path)
+				if modified.Equal(lastModified) {
+					continue
+				}
+				lastModified = modified
+
+				if err := m.LoadFromFile(path); err != nil {
+					m.logger.Error("could not reload overrides file", LogDetails{
+						"path":  path,
+						"error": err,
+					})
+				}
+			}
+		}
+	}()
+}
+
+// Close stops watching the overrides file
+func (m *OverridesManager) Close() {
+	m.watchOnce.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if m.stopWatching != nil {
+			close(m.stopWatching)
+		}
+	})
+}
+
+func getOverridesFileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
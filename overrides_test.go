@@ -0,0 +1,174 @@
+package featurevisor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var overridesTestDatafile = testDatafile{
+	features: `{
+		"test": {
+			"bucketBy": "userId",
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"variablesSchema": {
+				"color": {"type": "string", "defaultValue": "red"}
+			},
+			"traffic": [
+				{
+					"key": "1",
+					"segments": "*",
+					"percentage": 100000,
+					"allocation": [{"variation": "control", "range": [0, 100000]}]
+				}
+			]
+		}
+	}`,
+}
+
+func TestOverridesPrecedence(t *testing.T) {
+	t.Setenv(OverridesEnvVariable, `{"test": {"enabled": true, "variation": "treatment", "variables": {"color": "green"}}}`)
+
+	path := filepath.Join(t.TempDir(), "overrides.json")
+	if err := os.WriteFile(path, []byte(`{"test": {"enabled": true, "variables": {"color": "blue"}}}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	instance := CreateInstance(Options{
+		Datafile: overridesTestDatafile.build(t),
+		Overrides: &OverridesOptions{
+			FromEnv:  true,
+			FilePath: path,
+		},
+	})
+	defer instance.Close()
+
+	// file takes precedence over env for the whole feature
+	evaluation := instance.EvaluateVariable("test", "color", Context{"userId": "123"}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonLocalOverride || evaluation.VariableValue != "blue" {
+		t.Fatalf("expected file override, got %s %v", evaluation.Reason, evaluation.VariableValue)
+	}
+
+	variation := instance.GetVariation("test", Context{"userId": "123"})
+	if variation == nil || *variation != "control" {
+		t.Fatalf("expected variation from datafile, got %v", variation)
+	}
+
+	// runtime takes precedence over file
+	instance.Override("test", EvaluatedFeature{Enabled: false})
+
+	if instance.IsEnabled("test", Context{"userId": "123"}) {
+		t.Fatalf("expected runtime override to disable feature")
+	}
+
+	instance.RemoveOverride("test")
+
+	if value := instance.GetVariable("test", "color", Context{"userId": "123"}); value != "blue" {
+		t.Fatalf("expected file override after removing runtime override, got %v", value)
+	}
+}
+
+func TestOverridesAboveSticky(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: overridesTestDatafile.build(t),
+		Sticky: &StickyFeatures{
+			"test": {Enabled: true, Variation: stringPtr("control")},
+		},
+	})
+
+	instance.Override("test", EvaluatedFeature{Enabled: true, Variation: stringPtr("treatment")})
+
+	evaluation := instance.EvaluateVariation("test", Context{}, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonLocalOverride || *evaluation.VariationValue != "treatment" {
+		t.Fatalf("expected local override to win over sticky, got %s", evaluation.Reason)
+	}
+
+	child := instance.Spawn()
+	if variation := child.GetVariation("test"); variation == nil || *variation != "treatment" {
+		t.Fatalf("expected child to use parent overrides, got %v", variation)
+	}
+}
+
+func TestOverridesSetEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "overrides.json")
+
+	instance := CreateInstance(Options{
+		Datafile: overridesTestDatafile.build(t),
+		Overrides: &OverridesOptions{
+			FilePath:      path,
+			WatchInterval: 10 * time.Millisecond,
+		},
+	})
+	defer instance.Close()
+
+	events := make(chan EventDetails, 10)
+	unsubscribe := instance.On(EventNameOverridesSet, func(details EventDetails) {
+		events <- details
+	})
+	defer unsubscribe()
+
+	instance.Override("test", EvaluatedFeature{Enabled: true})
+
+	details := <-events
+	if details["source"] != OverrideSourceRuntime {
+		t.Fatalf("expected runtime source, got %v", details["source"])
+	}
+
+	if err := os.WriteFile(path, []byte(`{"other": {"enabled": true}}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case details = <-events:
+		if details["source"] != OverrideSourceFile {
+			t.Fatalf("expected file source, got %v", details["source"])
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected overrides_set event after file change")
+	}
+
+	if overrides := instance.GetOverrides(); len(overrides) != 2 {
+		t.Fatalf("expected 2 overrides, got %d", len(overrides))
+	}
+}
+
+func TestOverridesReplace(t *testing.T) {
+	var changes [][]FeatureKey
+	manager := NewOverridesManager(OverridesManagerOptions{
+		OnChange: func(source OverrideSource, featureKeys []FeatureKey) {
+			changes = append(changes, featureKeys)
+		},
+	})
+
+	features := EvaluatedFeatures{
+		"a": {Enabled: true},
+		"b": {Enabled: true, Variables: map[VariableKey]VariableValue{"color": "red"}},
+	}
+	manager.Replace(OverrideSourceFile, features)
+
+	// later changes to the given map do not apply
+	features["c"] = EvaluatedFeature{Enabled: true}
+	if _, _, exists := manager.Get("c"); exists {
+		t.Error("expected overrides to be copied when replacing")
+	}
+
+	// same overrides
+	manager.Replace(OverrideSourceFile, EvaluatedFeatures{
+		"a": {Enabled: true},
+		"b": {Enabled: true, Variables: map[VariableKey]VariableValue{"color": "red"}},
+	})
+
+	// only changed ones
+	manager.Replace(OverrideSourceFile, EvaluatedFeatures{
+		"a": {Enabled: true},
+		"b": {Enabled: true, Variables: map[VariableKey]VariableValue{"color": "blue"}},
+		"d": {Enabled: false},
+	})
+
+	expected := [][]FeatureKey{{"a", "b"}, {"b", "d"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected changes %v, got %v", expected, changes)
+	}
+}
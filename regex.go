@@ -0,0 +1,116 @@
+package featurevisor
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultRegexCacheSize is the maximum number of compiled regular expressions kept per datafile
+const DefaultRegexCacheSize = 1000
+
+// CompileRegex compiles a regular expression with JavaScript style flags.
+//
+// Flags "i", "m" and "s" are translated to RE2 inline flags. "u" is accepted as
+// RE2 always matches UTF-8, and "g" is ignored as it has no effect on matching.
+func CompileRegex(regexString string, regexFlags string) (*regexp.Regexp, error) {
+	inlineFlags := ""
+
+	for _, flag := range regexFlags {
+		switch flag {
+		case 'i', 'm', 's':
+			if !strings.ContainsRune(inlineFlags, flag) {
+				inlineFlags += string(flag)
+			}
+		case 'u', 'g':
+			// no RE2 equivalent needed
+		default:
+			return nil, fmt.Errorf("unsupported regex flag %q", flag)
+		}
+	}
+
+	pattern := regexString
+	if inlineFlags != "" {
+		pattern = "(?" + inlineFlags + ")" + regexString
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %q: %w", regexString, err)
+	}
+
+	return regex, nil
+}
+
+// regexCacheEntry is a compiled regex, or the error compiling it
+type regexCacheEntry struct {
+	key   string
+	regex *regexp.Regexp
+	err   error
+}
+
+// regexCache is a concurrency-safe cache of compiled regular expressions, evicting the least recently used
+type regexCache struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	mu      sync.Mutex
+}
+
+func newRegexCache(size int) *regexCache {
+	if size <= 0 {
+		size = DefaultRegexCacheSize
+	}
+
+	return &regexCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns a compiled regex from cache, compiling it if needed
+func (c *regexCache) get(regexString string, regexFlags string) (*regexp.Regexp, error) {
+	key := regexFlags + "/" + regexString
+
+	c.mu.Lock()
+	if element, exists := c.entries[key]; exists {
+		c.order.MoveToFront(element)
+		entry := element.Value.(*regexCacheEntry)
+		c.mu.Unlock()
+		return entry.regex, entry.err
+	}
+	c.mu.Unlock()
+
+	regex, err := CompileRegex(regexString, regexFlags)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// compiled concurrently by another caller
+	if element, exists := c.entries[key]; exists {
+		c.order.MoveToFront(element)
+		entry := element.Value.(*regexCacheEntry)
+		return entry.regex, entry.err
+	}
+
+	c.entries[key] = c.order.PushFront(&regexCacheEntry{key: key, regex: regex, err: err})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*regexCacheEntry).key)
+	}
+
+	return regex, err
+}
+
+// len returns the number of cached entries
+func (c *regexCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,149 @@
+package featurevisor
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCompileRegexFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		regex      string
+		regexFlags string
+		value      string
+		expected   bool
+	}{
+		{"without flags", "^hello$", "", "HELLO", false},
+		{"case insensitive", "^hello$", "i", "HELLO", true},
+		{"multiline", "^world$", "m", "hello\nworld", true},
+		{"without multiline", "^world$", "", "hello\nworld", false},
+		{"dot matches newline", "^hello.world$", "s", "hello\nworld", true},
+		{"without dot matching newline", "^hello.world$", "", "hello\nworld", false},
+		{"unicode", "^.$", "u", "ü", true},
+		{"combined", "^WORLD$", "gim", "hello\nworld", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regex, err := CompileRegex(tt.regex, tt.regexFlags)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if regex.MatchString(tt.value) != tt.expected {
+				t.Fatalf("expected %v for %q with flags %q against %q", tt.expected, tt.regex, tt.regexFlags, tt.value)
+			}
+		})
+	}
+}
+
+func TestCompileRegexErrors(t *testing.T) {
+	if _, err := CompileRegex("^(?=foo)", ""); err == nil {
+		t.Fatalf("expected error for lookahead")
+	}
+
+	if _, err := CompileRegex("^foo$", "x"); err == nil {
+		t.Fatalf("expected error for unsupported flag")
+	}
+}
+
+func TestRegexCacheIsBounded(t *testing.T) {
+	cache := newRegexCache(2)
+
+	first, _ := cache.get("a", "")
+	cache.get("b", "")
+
+	// "a" is now most recently used, so "b" gets evicted
+	if again, _ := cache.get("a", ""); again != first {
+		t.Fatalf("expected cached regex to be reused")
+	}
+	cache.get("c", "")
+
+	if cache.len() != 2 {
+		t.Fatalf("expected 2 cached entries, got %d", cache.len())
+	}
+	if _, exists := cache.entries["/b"]; exists {
+		t.Fatalf("expected least recently used entry to be evicted")
+	}
+	if _, exists := cache.entries["/a"]; !exists {
+		t.Fatalf("expected recently used entry to be kept")
+	}
+}
+
+func TestRegexCacheConcurrency(t *testing.T) {
+	cache := newRegexCache(10)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := cache.get(fmt.Sprintf("^user-%d$", i%20), "i"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.len() != 10 {
+		t.Fatalf("expected cache to stay bounded, got %d", cache.len())
+	}
+}
+
+func TestInvalidRegexInDatafile(t *testing.T) {
+	datafileContent := `{
+		"schemaVersion": "2",
+		"revision": "1",
+		"segments": {
+			"caseInsensitive": {
+				"conditions": [{"attribute": "name", "operator": "matches", "value": "^hello$", "regexFlags": "i"}]
+			},
+			"lookahead": {
+				"conditions": [{"attribute": "name", "operator": "matches", "value": "^(?=hello)"}]
+			},
+			"notLookahead": {
+				"conditions": [{"attribute": "name", "operator": "notMatches", "value": "^(?=hello)"}]
+			}
+		},
+		"features": {
+			"caseInsensitive": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "caseInsensitive", "percentage": 100000}]
+			},
+			"lookahead": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "lookahead", "percentage": 100000}]
+			},
+			"notLookahead": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "notLookahead", "percentage": 100000}]
+			}
+		}
+	}`
+
+	instance := CreateInstance(Options{
+		Datafile: datafileContent,
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	result := instance.GetDatafileValidationResult()
+	if result.Valid || len(result.Issues) != 2 {
+		t.Fatalf("expected 2 issues, got %+v", result)
+	}
+	for i, segmentKey := range []SegmentKey{"lookahead", "notLookahead"} {
+		if result.Issues[i].Type != DatafileIssueTypeInvalidRegex || result.Issues[i].SegmentKey != segmentKey {
+			t.Fatalf("unexpected issue %+v", result.Issues[i])
+		}
+	}
+
+	context := Context{"userId": "123", "name": "HELLO"}
+
+	if !instance.IsEnabled("caseInsensitive", context) {
+		t.Fatalf("expected regexFlags to be applied")
+	}
+
+	if instance.IsEnabled("lookahead", context) || instance.IsEnabled("notLookahead", context) {
+		t.Fatalf("expected invalid regex conditions not to match")
+	}
+}
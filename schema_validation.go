@@ -0,0 +1,321 @@
+package featurevisor
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaViolation represents a constraint of a variable schema not satisfied by a value
+type SchemaViolation struct {
+	Path    string      `json:"path,omitempty"` // like "limits.daily" or "items[2]", empty for the value itself
+	Keyword string      `json:"keyword"`        // like "type", "minimum" or "enum"
+	Value   interface{} `json:"value,omitempty"`
+	Message string      `json:"message"`
+}
+
+// VariableSchemaError is the error of an evaluation whose variable value does not satisfy its schema
+type VariableSchemaError struct {
+	FeatureKey  FeatureKey        `json:"featureKey"`
+	VariableKey VariableKey       `json:"variableKey"`
+	Violations  []SchemaViolation `json:"violations"`
+}
+
+func (e *VariableSchemaError) Error() string {
+	messages := make([]string, 0, len(e.Violations))
+	for _, violation := range e.Violations {
+		messages = append(messages, violation.Message)
+	}
+
+	return fmt.Sprintf("invalid value of variable %q of feature %q: %s", e.VariableKey, e.FeatureKey, strings.Join(messages, "; "))
+}
+
+// ErrorCode returns the code of the error
+func (e *VariableSchemaError) ErrorCode() EvaluationErrorCode {
+	return EvaluationErrorCodeInvalidVariable
+}
+
+// variableSchemaToSchema returns the constraints of a variable schema
+func variableSchemaToSchema(variableSchema VariableSchema) Schema {
+	schema := Schema{
+		Schema:               variableSchema.Schema,
+		Properties:           variableSchema.Properties,
+		AdditionalProperties: variableSchema.AdditionalProperties,
+		Required:             variableSchema.Required,
+		Items:                variableSchema.Items,
+		OneOf:                variableSchema.OneOf,
+		Enum:                 variableSchema.Enum,
+		Const:                variableSchema.Const,
+		Minimum:              variableSchema.Minimum,
+		Maximum:              variableSchema.Maximum,
+		MinLength:            variableSchema.MinLength,
+		MaxLength:            variableSchema.MaxLength,
+		Pattern:              variableSchema.Pattern,
+		MinItems:             variableSchema.MinItems,
+		MaxItems:             variableSchema.MaxItems,
+		UniqueItems:          variableSchema.UniqueItems,
+	}
+
+	if variableSchema.Type != "" {
+		variableType := variableSchema.Type
+		schema.Type = &variableType
+	}
+
+	return schema
+}
+
+// ValidateVariableValue checks a value against the type and constraints of a variable schema,
+// returning the violations found, if any.
+//
+// Values of json variables are not checked.
+func (d *DatafileReader) ValidateVariableValue(value VariableValue, variableSchema VariableSchema) []SchemaViolation {
+	schema := d.resolveSchemaRef(variableSchemaToSchema(variableSchema))
+	if schema.Type != nil && *schema.Type == VariableTypeJSON {
+		return nil
+	}
+
+	return d.validateSchema("", value, schema)
+}
+
+// newSchemaViolation creates a violation of a schema keyword, prefixing the message with the path
+func newSchemaViolation(path string, keyword string, value interface{}, format string, args ...interface{}) SchemaViolation {
+	message := fmt.Sprintf(format, args...)
+	if path != "" {
+		message = path + ": " + message
+	}
+
+	return SchemaViolation{
+		Path:    path,
+		Keyword: keyword,
+		Value:   value,
+		Message: message,
+	}
+}
+
+// validateSchema checks a value against a schema, at the given path
+func (d *DatafileReader) validateSchema(path string, value interface{}, schema Schema) []SchemaViolation {
+	var violations []SchemaViolation
+
+	// recursive schemas are only resolved as deep as the value goes
+	schema = d.resolveSchemaRef(schema)
+
+	if schema.Type != nil && !valueIsOfVariableType(value, *schema.Type) {
+		// other constraints depend on the type
+		return []SchemaViolation{newSchemaViolation(path, "type", value, "expected %s, got %T", *schema.Type, value)}
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if valuesEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violations = append(violations, newSchemaViolation(path, "enum", value, "%v is not one of %v", value, schema.Enum))
+		}
+	}
+
+	if schema.Const != nil && !valuesEqual(value, schema.Const) {
+		violations = append(violations, newSchemaViolation(path, "const", value, "%v is not equal to %v", value, schema.Const))
+	}
+
+	// number
+	if number, ok := toNumber(value); ok {
+		if schema.Minimum != nil && number < *schema.Minimum {
+			violations = append(violations, newSchemaViolation(path, "minimum", value, "%v is less than minimum %v", value, *schema.Minimum))
+		}
+		if schema.Maximum != nil && number > *schema.Maximum {
+			violations = append(violations, newSchemaViolation(path, "maximum", value, "%v is greater than maximum %v", value, *schema.Maximum))
+		}
+	}
+
+	// string
+	if s, ok := value.(string); ok {
+		length := utf8.RuneCountInString(s)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			violations = append(violations, newSchemaViolation(path, "minLength", value, "length %d is less than minLength %d", length, *schema.MinLength))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			violations = append(violations, newSchemaViolation(path, "maxLength", value, "length %d is greater than maxLength %d", length, *schema.MaxLength))
+		}
+		if schema.Pattern != nil {
+			if regex := d.GetRegex(*schema.Pattern, ""); regex == nil {
+				violations = append(violations, newSchemaViolation(path, "pattern", value, "pattern %q is invalid", *schema.Pattern))
+			} else if !regex.MatchString(s) {
+				violations = append(violations, newSchemaViolation(path, "pattern", value, "%q does not match pattern %q", s, *schema.Pattern))
+			}
+		}
+	} else if items, ok := toSlice(value); ok {
+		violations = append(violations, d.validateItems(path, items, schema)...)
+	} else if object, ok := toMap(value); ok {
+		violations = append(violations, d.validateProperties(path, object, schema)...)
+	}
+
+	if len(schema.OneOf) > 0 {
+		matched := 0
+		for _, option := range schema.OneOf {
+			if len(d.validateSchema(path, value, option)) == 0 {
+				matched++
+			}
+		}
+		if matched != 1 {
+			violations = append(violations, newSchemaViolation(path, "oneOf", value, "expected to match exactly one schema of oneOf, matched %d", matched))
+		}
+	}
+
+	return violations
+}
+
+// validateItems checks items of an array against a schema
+func (d *DatafileReader) validateItems(path string, items []interface{}, schema Schema) []SchemaViolation {
+	var violations []SchemaViolation
+
+	if schema.MinItems != nil && len(items) < *schema.MinItems {
+		violations = append(violations, newSchemaViolation(path, "minItems", items, "%d items are less than minItems %d", len(items), *schema.MinItems))
+	}
+	if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+		violations = append(violations, newSchemaViolation(path, "maxItems", items, "%d items are more than maxItems %d", len(items), *schema.MaxItems))
+	}
+
+	if schema.UniqueItems != nil && *schema.UniqueItems {
+	unique:
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if valuesEqual(items[i], items[j]) {
+					violations = append(violations, newSchemaViolation(path, "uniqueItems", items, "items %d and %d are equal", i, j))
+					break unique
+				}
+			}
+		}
+	}
+
+	if schema.Items != nil {
+		for i, item := range items {
+			violations = append(violations, d.validateSchema(fmt.Sprintf("%s[%d]", path, i), item, *schema.Items)...)
+		}
+	}
+
+	return violations
+}
+
+// validateProperties checks properties of an object against a schema
+func (d *DatafileReader) validateProperties(path string, object map[string]interface{}, schema Schema) []SchemaViolation {
+	var violations []SchemaViolation
+
+	for _, key := range schema.Required {
+		if _, exists := object[key]; !exists {
+			violations = append(violations, newSchemaViolation(path, "required", object, "required property %q is missing", key))
+		}
+	}
+
+	for _, key := range sortedKeys(object) {
+		propertyPath := key
+		if path != "" {
+			propertyPath = path + "." + key
+		}
+
+		if propertySchema, exists := schema.Properties[key]; exists {
+			violations = append(violations, d.validateSchema(propertyPath, object[key], propertySchema)...)
+			continue
+		}
+
+		switch additional := schema.AdditionalProperties.(type) {
+		case nil:
+			// any property is allowed
+		case bool:
+			if !additional {
+				violations = append(violations, newSchemaViolation(propertyPath, "additionalProperties", object[key], "property is not allowed"))
+			}
+		default:
+			if additionalSchema, ok := convertToTypedValue[Schema](additional); ok {
+				violations = append(violations, d.validateSchema(propertyPath, object[key], additionalSchema)...)
+			}
+		}
+	}
+
+	return violations
+}
+
+// valueIsOfVariableType checks if a value is of a variable type, allowing any Go number for numeric types
+func valueIsOfVariableType(value interface{}, variableType VariableType) bool {
+	switch variableType {
+	case VariableTypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case VariableTypeString:
+		_, ok := value.(string)
+		return ok
+	case VariableTypeInteger:
+		n, ok := toNumber(value)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case VariableTypeDouble:
+		return isNumber(value)
+	case VariableTypeArray:
+		if _, isString := value.(string); isString {
+			return false
+		}
+		_, ok := toSlice(value)
+		return ok
+	case VariableTypeObject:
+		_, ok := toMap(value)
+		return ok
+	}
+
+	// json, and unknown types
+	return true
+}
+
+// validateVariableEvaluation falls back to the default value if the evaluated variable value does not satisfy its schema
+func validateVariableEvaluation(evaluation Evaluation, options EvaluateOptions) Evaluation {
+	if evaluation.Type != EvaluationTypeVariable || evaluation.VariableKey == nil || evaluation.VariableValue == nil {
+		return evaluation
+	}
+
+	switch evaluation.Reason {
+	case EvaluationReasonRule,
+		EvaluationReasonAllocated,
+		EvaluationReasonForced,
+		EvaluationReasonVariableOverride,
+		EvaluationReasonLocalOverride,
+		EvaluationReasonSticky:
+	default:
+		return evaluation
+	}
+
+	feature := options.DatafileReader.GetFeature(evaluation.FeatureKey)
+	if feature == nil {
+		return evaluation
+	}
+
+	variableSchema, exists := feature.VariablesSchema[*evaluation.VariableKey]
+	if !exists {
+		return evaluation
+	}
+
+	violations := options.DatafileReader.ValidateVariableValue(evaluation.VariableValue, variableSchema)
+	if len(violations) == 0 {
+		return evaluation
+	}
+
+	options.Logger.Warn("invalid variable value", LogDetails{
+		"featureKey":  evaluation.FeatureKey,
+		"variableKey": *evaluation.VariableKey,
+		"reason":      evaluation.Reason,
+		"value":       evaluation.VariableValue,
+		"violations":  violations,
+	})
+
+	evaluation.Reason = EvaluationReasonVariableInvalid
+	evaluation.VariableValue = variableSchema.DefaultValue
+	evaluation.VariableSchema = &variableSchema
+	evaluation.Error = &VariableSchemaError{
+		FeatureKey:  evaluation.FeatureKey,
+		VariableKey: *evaluation.VariableKey,
+		Violations:  violations,
+	}
+
+	return evaluation
+}
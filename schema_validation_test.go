@@ -0,0 +1,181 @@
+package featurevisor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+var schemaValidationTestDatafile = testDatafile{
+	segments: `{
+		"qa": {"conditions": [{"attribute": "qa", "operator": "equals", "value": true}]}
+	}`,
+	features: `{
+		"limits": {
+			"bucketBy": "userId",
+			"variablesSchema": {
+				"rate": {"type": "integer", "defaultValue": 10, "minimum": 1, "maximum": 100},
+				"plan": {"type": "string", "defaultValue": "free", "enum": ["free", "pro"]},
+				"tags": {"type": "array", "defaultValue": [], "uniqueItems": true, "items": {"type": "string", "minLength": 1}},
+				"quota": {
+					"type": "object",
+					"defaultValue": {"daily": 1},
+					"required": ["daily"],
+					"properties": {"daily": {"type": "integer", "minimum": 0}}
+				},
+				"raw": {"type": "json", "defaultValue": "{}"}
+			},
+			"force": [
+				{"segments": ["qa"], "enabled": true, "variables": {"rate": -5}}
+			],
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"variables": {"plan": "enterprise", "tags": ["a", "a"], "quota": {"daily": -1}}
+				}
+			]
+		}
+	}`,
+}
+
+func TestInvalidVariableValueFallsBackToDefault(t *testing.T) {
+	var logged []LogDetails
+	instance := newTestInstance(t, schemaValidationTestDatafile, Options{
+		Logger: newTestLogger(func(level LogLevel, message LogMessage, details LogDetails) {
+			if message == "invalid variable value" {
+				logged = append(logged, details)
+			}
+		}),
+	})
+
+	context := Context{"userId": "123"}
+
+	evaluation := instance.EvaluateVariable("limits", "plan", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonVariableInvalid || evaluation.VariableValue != "free" {
+		t.Fatalf("expected default value with reason variable_invalid, got %+v", evaluation)
+	}
+
+	var schemaError *VariableSchemaError
+	if !errors.As(evaluation.Error, &schemaError) || len(schemaError.Violations) != 1 || schemaError.Violations[0].Keyword != "enum" {
+		t.Fatalf("expected enum violation, got %v", evaluation.Error)
+	}
+	if GetEvaluationErrorCode(evaluation.Error) != EvaluationErrorCodeInvalidVariable {
+		t.Errorf("unexpected error code %q", GetEvaluationErrorCode(evaluation.Error))
+	}
+
+	if len(logged) != 1 || logged[0]["variableKey"] != "plan" {
+		t.Errorf("expected violation to be logged, got %v", logged)
+	}
+
+	if tags := instance.GetVariableArray("limits", "tags", context); len(tags) != 0 {
+		t.Errorf("expected default tags for duplicate items, got %v", tags)
+	}
+
+	quota := instance.GetVariableObject("limits", "quota", context)
+	if quota["daily"] != float64(1) {
+		t.Errorf("expected default quota for negative property, got %v", quota)
+	}
+
+	// forced
+	rate := instance.GetVariableInteger("limits", "rate", Context{"userId": "123", "qa": true})
+	if rate == nil || *rate != 10 {
+		t.Errorf("expected default rate for forced negative value, got %v", rate)
+	}
+
+	// default values are converted to their type
+	if rate, ok := instance.GetVariable("limits", "rate", Context{"userId": "123", "qa": true}).(int); !ok || rate != 10 {
+		t.Errorf("expected integer default rate, got %#v", instance.GetVariable("limits", "rate", Context{"userId": "123", "qa": true}))
+	}
+}
+
+func TestInvalidVariableValueFromOverridesAndSticky(t *testing.T) {
+	instance := newTestInstance(t, schemaValidationTestDatafile, Options{})
+	context := Context{"userId": "123"}
+
+	instance.Override("limits", EvaluatedFeature{
+		Enabled:   true,
+		Variables: map[VariableKey]VariableValue{"rate": 500, "plan": "pro"},
+	})
+
+	if evaluation := instance.EvaluateVariable("limits", "rate", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonVariableInvalid || evaluation.VariableValue != float64(10) {
+		t.Errorf("expected invalid override to fall back to default, got %+v", evaluation)
+	}
+	if evaluation := instance.EvaluateVariable("limits", "plan", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonLocalOverride || evaluation.VariableValue != "pro" {
+		t.Errorf("expected valid override to be used, got %+v", evaluation)
+	}
+
+	sticky := StickyFeatures{
+		"limits": {Enabled: true, Variables: map[VariableKey]VariableValue{"tags": []string{"a", ""}}},
+	}
+	evaluation := instance.EvaluateVariable("limits", "tags", context, OverrideOptions{Sticky: &sticky})
+	if evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Fatalf("expected invalid sticky value to fall back to default, got %+v", evaluation)
+	}
+	if !strings.Contains(evaluation.Error.Error(), "[1]: length 0 is less than minLength 1") {
+		t.Errorf("unexpected error message %q", evaluation.Error.Error())
+	}
+}
+
+func TestValidateVariableValue(t *testing.T) {
+	reader := NewDatafileReader(DatafileReaderOptions{
+		Datafile: DatafileContent{SchemaVersion: "2"},
+		Logger:   NewLogger(CreateLoggerOptions{Level: &[]LogLevel{LogLevelFatal}[0]}),
+	})
+
+	minimum := float64(0)
+	maxLength := 3
+	pattern := "^[a-z]+$"
+	stringType := VariableTypeString
+	integerType := VariableTypeInteger
+
+	tests := []struct {
+		name     string
+		value    VariableValue
+		schema   VariableSchema
+		keywords []string
+	}{
+		{"valid integer", 5, VariableSchema{Type: VariableTypeInteger, Minimum: &minimum}, nil},
+		{"fractional integer", 1.5, VariableSchema{Type: VariableTypeInteger}, []string{"type"}},
+		{"below minimum", -1, VariableSchema{Type: VariableTypeDouble, Minimum: &minimum}, []string{"minimum"}},
+		{"wrong type", "5", VariableSchema{Type: VariableTypeInteger}, []string{"type"}},
+		{"too long", "abcd", VariableSchema{Type: VariableTypeString, MaxLength: &maxLength}, []string{"maxLength"}},
+		{"pattern", "ABC", VariableSchema{Type: VariableTypeString, Pattern: &pattern}, []string{"pattern"}},
+		{"const", "a", VariableSchema{Type: VariableTypeString, Const: "b"}, []string{"const"}},
+		{
+			"additional properties",
+			map[string]interface{}{"a": 1, "b": 2},
+			VariableSchema{Type: VariableTypeObject, Properties: SchemaMap{"a": {Type: &integerType}}, AdditionalProperties: false},
+			[]string{"additionalProperties"},
+		},
+		{
+			"one of",
+			"x",
+			VariableSchema{OneOf: []Schema{{Type: &stringType}, {Type: &integerType}}},
+			nil,
+		},
+		{
+			"none of",
+			true,
+			VariableSchema{OneOf: []Schema{{Type: &stringType}, {Type: &integerType}}},
+			[]string{"oneOf"},
+		},
+		{"json is not checked", 123, VariableSchema{Type: VariableTypeJSON}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := reader.ValidateVariableValue(tt.value, tt.schema)
+
+			var keywords []string
+			for _, violation := range violations {
+				keywords = append(keywords, violation.Keyword)
+			}
+
+			if strings.Join(keywords, ",") != strings.Join(tt.keywords, ",") {
+				t.Errorf("expected violations %v, got %+v", tt.keywords, violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package featurevisor
+
+// GetSchema returns a reusable schema by key, or nil if not found
+func (d *DatafileReader) GetSchema(schemaKey SchemaKey) *Schema {
+	schema, exists := d.schemas[schemaKey]
+	if !exists {
+		return nil
+	}
+
+	return &schema
+}
+
+// GetSchemaKeys returns keys of all reusable schemas, sorted
+func (d *DatafileReader) GetSchemaKeys() []SchemaKey {
+	return sortedKeys(d.schemas)
+}
+
+// mergeSchema fills fields of a schema not set, from the schema it refers to
+func mergeSchema(schema Schema, referenced Schema) Schema {
+	if schema.Type == nil {
+		schema.Type = referenced.Type
+	}
+	if schema.Properties == nil {
+		schema.Properties = referenced.Properties
+	}
+	if schema.AdditionalProperties == nil {
+		schema.AdditionalProperties = referenced.AdditionalProperties
+	}
+	if schema.Required == nil {
+		schema.Required = referenced.Required
+	}
+	if schema.Items == nil {
+		schema.Items = referenced.Items
+	}
+	if schema.OneOf == nil {
+		schema.OneOf = referenced.OneOf
+	}
+	if schema.Enum == nil {
+		schema.Enum = referenced.Enum
+	}
+	if schema.Const == nil {
+		schema.Const = referenced.Const
+	}
+	if schema.Minimum == nil {
+		schema.Minimum = referenced.Minimum
+	}
+	if schema.Maximum == nil {
+		schema.Maximum = referenced.Maximum
+	}
+	if schema.MinLength == nil {
+		schema.MinLength = referenced.MinLength
+	}
+	if schema.MaxLength == nil {
+		schema.MaxLength = referenced.MaxLength
+	}
+	if schema.Pattern == nil {
+		schema.Pattern = referenced.Pattern
+	}
+	if schema.MinItems == nil {
+		schema.MinItems = referenced.MinItems
+	}
+	if schema.MaxItems == nil {
+		schema.MaxItems = referenced.MaxItems
+	}
+	if schema.UniqueItems == nil {
+		schema.UniqueItems = referenced.UniqueItems
+	}
+
+	return schema
+}
+
+// resolveSchemaRef fills fields of a schema from the reusable schema it refers to,
+// and from the ones that schema refers to in turn, until a schema is found twice
+func (d *DatafileReader) resolveSchemaRef(schema Schema) Schema {
+	var resolved []SchemaKey
+
+	for ref := schema.Schema; ref != nil; {
+		for _, schemaKey := range resolved {
+			if schemaKey == *ref {
+				return schema
+			}
+		}
+		resolved = append(resolved, *ref)
+
+		referenced, exists := d.schemas[*ref]
+		if !exists {
+			return schema
+		}
+
+		schema = mergeSchema(schema, referenced)
+		ref = referenced.Schema
+	}
+
+	return schema
+}
+
+// resolveSchema resolves references of a schema and of its nested schemas.
+//
+// References already being resolved are left as they are, so recursive schemas are resolved
+// as deep as they are referred to once, and further when validating values.
+func (d *DatafileReader) resolveSchema(schema Schema, resolving []SchemaKey) Schema {
+	if schema.Schema != nil {
+		for _, schemaKey := range resolving {
+			if schemaKey == *schema.Schema {
+				return schema
+			}
+		}
+
+		schema = d.resolveSchemaRef(schema)
+		resolving = append(resolving[:len(resolving):len(resolving)], *schema.Schema)
+	}
+
+	if schema.Items != nil {
+		items := d.resolveSchema(*schema.Items, resolving)
+		schema.Items = &items
+	}
+
+	if schema.Properties != nil {
+		properties := make(SchemaMap, len(schema.Properties))
+		for key, property := range schema.Properties {
+			properties[key] = d.resolveSchema(property, resolving)
+		}
+		schema.Properties = properties
+	}
+
+	if schema.OneOf != nil {
+		oneOf := make([]Schema, len(schema.OneOf))
+		for i, option := range schema.OneOf {
+			oneOf[i] = d.resolveSchema(option, resolving)
+		}
+		schema.OneOf = oneOf
+	}
+
+	if schema.AdditionalProperties != nil {
+		if _, isBool := schema.AdditionalProperties.(bool); !isBool {
+			if additional, ok := convertToTypedValue[Schema](schema.AdditionalProperties); ok {
+				schema.AdditionalProperties = d.resolveSchema(additional, resolving)
+			}
+		}
+	}
+
+	return schema
+}
+
+// resolveVariableSchema resolves the reference of a variable schema and of its nested schemas,
+// keeping the type and constraints set on the variable schema itself
+func (d *DatafileReader) resolveVariableSchema(variableSchema VariableSchema) VariableSchema {
+	schema := d.resolveSchema(variableSchemaToSchema(variableSchema), nil)
+
+	if schema.Type != nil {
+		variableSchema.Type = *schema.Type
+	}
+	variableSchema.Properties = schema.Properties
+	variableSchema.AdditionalProperties = schema.AdditionalProperties
+	variableSchema.Required = schema.Required
+	variableSchema.Items = schema.Items
+	variableSchema.OneOf = schema.OneOf
+	variableSchema.Enum = schema.Enum
+	variableSchema.Const = schema.Const
+	variableSchema.Minimum = schema.Minimum
+	variableSchema.Maximum = schema.Maximum
+	variableSchema.MinLength = schema.MinLength
+	variableSchema.MaxLength = schema.MaxLength
+	variableSchema.Pattern = schema.Pattern
+	variableSchema.MinItems = schema.MinItems
+	variableSchema.MaxItems = schema.MaxItems
+	variableSchema.UniqueItems = schema.UniqueItems
+
+	return variableSchema
+}
+
+// resolveFeatureSchemas returns features with references of their variable schemas resolved,
+// leaving the given features as they are
+func (d *DatafileReader) resolveFeatureSchemas(features map[FeatureKey]Feature) map[FeatureKey]Feature {
+	if len(d.schemas) == 0 {
+		return features
+	}
+
+	resolved := make(map[FeatureKey]Feature, len(features))
+	for featureKey, feature := range features {
+		if feature.VariablesSchema != nil {
+			variablesSchema := make(map[VariableKey]VariableSchema, len(feature.VariablesSchema))
+			for variableKey, variableSchema := range feature.VariablesSchema {
+				variablesSchema[variableKey] = d.resolveVariableSchema(variableSchema)
+			}
+			feature.VariablesSchema = variablesSchema
+		}
+
+		resolved[featureKey] = feature
+	}
+
+	return resolved
+}
+
+// getSchemaRefs returns keys of reusable schemas referred to by a schema and its nested schemas
+func getSchemaRefs(schema Schema) []SchemaKey {
+	var refs []SchemaKey
+
+	if schema.Schema != nil {
+		refs = append(refs, *schema.Schema)
+	}
+
+	if schema.Items != nil {
+		refs = append(refs, getSchemaRefs(*schema.Items)...)
+	}
+
+	for _, key := range sortedKeys(schema.Properties) {
+		refs = append(refs, getSchemaRefs(schema.Properties[key])...)
+	}
+
+	for _, option := range schema.OneOf {
+		refs = append(refs, getSchemaRefs(option)...)
+	}
+
+	if _, isBool := schema.AdditionalProperties.(bool); !isBool && schema.AdditionalProperties != nil {
+		if additional, ok := convertToTypedValue[Schema](schema.AdditionalProperties); ok {
+			refs = append(refs, getSchemaRefs(additional)...)
+		}
+	}
+
+	return refs
+}
@@ -0,0 +1,197 @@
+package featurevisor
+
+import (
+	"testing"
+)
+
+var schemasTestDatafile = testDatafile{
+	schemas: `{
+		"percentage": {"type": "integer", "minimum": 0, "maximum": 100},
+		"color": {"type": "string", "pattern": "^#[0-9a-f]{6}$"},
+		"theme": {
+			"type": "object",
+			"required": ["primary"],
+			"properties": {"primary": {"schema": "color"}, "opacity": {"schema": "percentage"}}
+		},
+		"node": {
+			"type": "object",
+			"properties": {"name": {"type": "string"}, "children": {"type": "array", "items": {"schema": "node"}}}
+		}
+	}`,
+	features: `{
+		"ui": {
+			"bucketBy": "userId",
+			"variablesSchema": {
+				"opacity": {"schema": "percentage", "defaultValue": 50},
+				"limit": {"schema": "percentage", "maximum": 10, "defaultValue": 5},
+				"palette": {"type": "array", "items": {"schema": "color"}, "defaultValue": []},
+				"theme": {"schema": "theme", "defaultValue": {"primary": "#000000"}},
+				"value": {"oneOf": [{"schema": "color"}, {"schema": "percentage"}], "defaultValue": 0},
+				"tree": {"schema": "node", "defaultValue": {"name": "root"}}
+			},
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"variables": {
+						"limit": 20,
+						"palette": ["#ffffff", "red"],
+						"theme": {"primary": "#ffffff", "opacity": 120},
+						"value": "#abcdef",
+						"tree": {"name": "root", "children": [{"name": "a", "children": [{"name": 1}]}]}
+					}
+				}
+			]
+		}
+	}`,
+}
+
+func TestVariableSchemaRefs(t *testing.T) {
+	datafile := schemasTestDatafile.build(t)
+	instance := CreateInstance(Options{
+		Datafile: datafile,
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	context := Context{"userId": "123"}
+
+	feature := instance.GetFeature("ui")
+	if feature.VariablesSchema["opacity"].Type != VariableTypeInteger {
+		t.Errorf("expected type from referenced schema, got %q", feature.VariablesSchema["opacity"].Type)
+	}
+	if datafile.Features["ui"].VariablesSchema["opacity"].Type != "" {
+		t.Error("expected datafile content to be left as it is")
+	}
+
+	// type conversion of default value
+	if value, ok := instance.GetVariable("ui", "opacity", context).(int); !ok || value != 50 {
+		t.Errorf("expected integer default value, got %#v", instance.GetVariable("ui", "opacity", context))
+	}
+
+	// fields of the variable schema take precedence
+	if evaluation := instance.EvaluateVariable("ui", "limit", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Errorf("expected maximum of variable schema to apply, got %+v", evaluation)
+	}
+
+	// nested items
+	if evaluation := instance.EvaluateVariable("ui", "palette", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Errorf("expected items to be validated against referenced schema, got %+v", evaluation)
+	}
+
+	// nested properties, with references in referenced schema
+	if evaluation := instance.EvaluateVariable("ui", "theme", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Errorf("expected properties to be validated against referenced schema, got %+v", evaluation)
+	}
+
+	// oneOf
+	if evaluation := instance.EvaluateVariable("ui", "value", context, OverrideOptions{}); evaluation.Reason != EvaluationReasonRule || evaluation.VariableValue != "#abcdef" {
+		t.Errorf("expected value to match one of referenced schemas, got %+v", evaluation)
+	}
+
+	// recursive schema, invalid deep down
+	evaluation := instance.EvaluateVariable("ui", "tree", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Fatalf("expected recursive schema to be validated, got %+v", evaluation)
+	}
+	if violations := evaluation.Error.(*VariableSchemaError).Violations; violations[0].Path != "children[0].children[0].name" {
+		t.Errorf("unexpected violations %+v", violations)
+	}
+
+	if !instance.GetDatafileValidationResult().Valid {
+		t.Errorf("unexpected datafile issues %+v", instance.GetDatafileValidationResult().Issues)
+	}
+}
+
+func TestVariableSchemaRefNotFound(t *testing.T) {
+	datafile := schemasTestDatafile.build(t)
+	datafile.Schemas = map[SchemaKey]Schema{
+		"percentage": datafile.Schemas["percentage"],
+	}
+
+	reader := NewDatafileReader(DatafileReaderOptions{
+		Datafile: datafile,
+		Logger:   NewLogger(CreateLoggerOptions{Level: &[]LogLevel{LogLevelFatal}[0]}),
+	})
+
+	result := reader.GetValidationResult()
+	if result.Valid {
+		t.Fatal("expected missing schemas to be reported")
+	}
+
+	for _, issue := range result.Issues {
+		if issue.Type != DatafileIssueTypeSchemaNotFound || issue.FeatureKey != "ui" {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+
+	expected := map[string]bool{
+		`schema "color" of variable "palette" is not found`: true,
+		`schema "theme" of variable "theme" is not found`:   true,
+		`schema "color" of variable "value" is not found`:   true,
+		`schema "node" of variable "tree" is not found`:     true,
+	}
+	if len(result.Issues) != len(expected) {
+		t.Fatalf("unexpected issues %+v", result.Issues)
+	}
+	for _, issue := range result.Issues {
+		if !expected[issue.Message] {
+			t.Errorf("unexpected issue message %q", issue.Message)
+		}
+	}
+}
+
+func TestVariableSchemaChainedRefs(t *testing.T) {
+	instance := newTestInstance(t, testDatafile{
+		schemas: `{
+			"Base": {"type": "integer", "minimum": 0},
+			"Limit": {"schema": "Base", "maximum": 10},
+			"Loop": {"schema": "Loop"}
+		}`,
+		features: `{
+			"limits": {
+				"bucketBy": "userId",
+				"variablesSchema": {
+					"limit": {"schema": "Limit", "defaultValue": 5},
+					"other": {"schema": "Limit", "defaultValue": 5},
+					"loop": {"schema": "Loop", "type": "string", "defaultValue": "a"}
+				},
+				"traffic": [
+					{
+						"key": "everyone",
+						"segments": "*",
+						"percentage": 100000,
+						"variables": {"limit": -3, "other": 7, "loop": "b"}
+					}
+				]
+			}
+		}`,
+	}, Options{})
+	context := Context{"userId": "123"}
+
+	if variableType := instance.GetFeature("limits").VariablesSchema["limit"].Type; variableType != VariableTypeInteger {
+		t.Errorf("expected type from schema referred to by the referenced schema, got %q", variableType)
+	}
+
+	// minimum of Base, via Limit
+	evaluation := instance.EvaluateVariable("limits", "limit", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonVariableInvalid {
+		t.Fatalf("expected minimum of chained schema to apply, got %+v", evaluation)
+	}
+	if value := instance.GetVariableInteger("limits", "limit", context); value == nil || *value != 5 {
+		t.Errorf("expected default value, got %v", value)
+	}
+
+	if value := instance.GetVariableInteger("limits", "other", context); value == nil || *value != 7 {
+		t.Errorf("expected valid value to be used, got %v", value)
+	}
+
+	// schema referring to itself
+	if value := instance.GetVariableString("limits", "loop", context); value == nil || *value != "b" {
+		t.Errorf("expected value of schema referring to itself, got %v", value)
+	}
+
+	if !instance.GetDatafileValidationResult().Valid {
+		t.Errorf("unexpected datafile issues %+v", instance.GetDatafileValidationResult().Issues)
+	}
+}
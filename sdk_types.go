@@ -92,6 +92,7 @@ const (
 	OperatorSemverGreaterThanOrEquals Operator = "semverGreaterThanOrEquals"
 	OperatorSemverLessThan            Operator = "semverLessThan"
 	OperatorSemverLessThanOrEquals    Operator = "semverLessThanOrEquals"
+	OperatorSemverSatisfies           Operator = "semverSatisfies"
 	OperatorBefore                    Operator = "before"
 	OperatorAfter                     Operator = "after"
 	OperatorIncludes                  Operator = "includes"
@@ -143,6 +144,7 @@ type DatafileContent struct {
 	Revision      string                 `json:"revision"`
 	Segments      map[SegmentKey]Segment `json:"segments"`
 	Features      map[FeatureKey]Feature `json:"features"`
+	Schemas       map[SchemaKey]Schema   `json:"schemas,omitempty"` // reusable schemas, referred to by variable schemas
 }
 
 // FromJSON parses a JSON string and returns a DatafileContent
@@ -379,22 +381,23 @@ type SchemaKey = string
 
 // Schema represents JSON schema-like validations used by variable schema.
 type Schema struct {
+	Schema               *SchemaKey    `json:"schema,omitempty"` // reusable schema, whose fields are used where not set here
 	Type                 *VariableType `json:"type,omitempty"`
-	Properties           SchemaMap      `json:"properties,omitempty"`
-	AdditionalProperties interface{}    `json:"additionalProperties,omitempty"` // bool | Schema
-	Required             []string       `json:"required,omitempty"`
-	Items                *Schema        `json:"items,omitempty"`
-	OneOf                []Schema       `json:"oneOf,omitempty"`
-	Enum                 []Value        `json:"enum,omitempty"`
-	Const                VariableValue  `json:"const,omitempty"`
-	Minimum              *float64       `json:"minimum,omitempty"`
-	Maximum              *float64       `json:"maximum,omitempty"`
-	MinLength            *int           `json:"minLength,omitempty"`
-	MaxLength            *int           `json:"maxLength,omitempty"`
-	Pattern              *string        `json:"pattern,omitempty"`
-	MinItems             *int           `json:"minItems,omitempty"`
-	MaxItems             *int           `json:"maxItems,omitempty"`
-	UniqueItems          *bool          `json:"uniqueItems,omitempty"`
+	Properties           SchemaMap     `json:"properties,omitempty"`
+	AdditionalProperties interface{}   `json:"additionalProperties,omitempty"` // bool | Schema
+	Required             []string      `json:"required,omitempty"`
+	Items                *Schema       `json:"items,omitempty"`
+	OneOf                []Schema      `json:"oneOf,omitempty"`
+	Enum                 []Value       `json:"enum,omitempty"`
+	Const                VariableValue `json:"const,omitempty"`
+	Minimum              *float64      `json:"minimum,omitempty"`
+	Maximum              *float64      `json:"maximum,omitempty"`
+	MinLength            *int          `json:"minLength,omitempty"`
+	MaxLength            *int          `json:"maxLength,omitempty"`
+	Pattern              *string       `json:"pattern,omitempty"`
+	MinItems             *int          `json:"minItems,omitempty"`
+	MaxItems             *int          `json:"maxItems,omitempty"`
+	UniqueItems          *bool         `json:"uniqueItems,omitempty"`
 }
 
 // SchemaMap represents schema object properties map.
@@ -0,0 +1,181 @@
+package featurevisor
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// SegmentEvaluation represents the result of matching segments against context
+type SegmentEvaluation struct {
+	// segment key, or expression, that was evaluated
+	Segments interface{} `json:"segments"`
+
+	Matched bool             `json:"matched"`
+	Reason  EvaluationReason `json:"reason"` // segment_matched, no_match or segment_not_found
+
+	// segments referred to but not found in datafile
+	NotFound []SegmentKey `json:"notFound,omitempty"`
+}
+
+// GetSegmentKeys returns all segment keys, sorted
+func (d *DatafileReader) GetSegmentKeys() []SegmentKey {
+	return sortedKeys(d.segments)
+}
+
+// getUnknownSegmentKeys returns segment keys referred to in a segments expression which are not in datafile
+func (d *DatafileReader) getUnknownSegmentKeys(groupSegments interface{}) []SegmentKey {
+	var unknown []SegmentKey
+	for _, segmentKey := range getSegmentKeys(groupSegments) {
+		if d.GetSegment(segmentKey) == nil {
+			unknown = append(unknown, segmentKey)
+		}
+	}
+
+	return unknown
+}
+
+// getSegmentKeys returns the keys of segments referenced in group segments, in order of appearance
+func getSegmentKeys(groupSegments interface{}) []SegmentKey {
+	keys := []SegmentKey{}
+	seen := map[SegmentKey]bool{}
+
+	var collect func(groupSegments interface{})
+	collect = func(groupSegments interface{}) {
+		switch g := groupSegments.(type) {
+		case string:
+			if g == "*" || g == "" {
+				return
+			}
+
+			// stringified groups
+			if strings.HasPrefix(g, "{") || strings.HasPrefix(g, "[") {
+				var parsed interface{}
+				if err := json.Unmarshal([]byte(g), &parsed); err == nil {
+					collect(parsed)
+				}
+				return
+			}
+
+			if !seen[g] {
+				seen[g] = true
+				keys = append(keys, g)
+			}
+		case []interface{}:
+			for _, item := range g {
+				collect(item)
+			}
+		case []GroupSegment:
+			for _, item := range g {
+				collect(item)
+			}
+		case []string:
+			for _, item := range g {
+				collect(item)
+			}
+		case AndGroupSegment:
+			collect(g.And)
+		case OrGroupSegment:
+			collect(g.Or)
+		case NotGroupSegment:
+			collect(g.Not)
+		case map[string]interface{}:
+			for _, operator := range []string{"and", "or", "not"} {
+				if value, exists := g[operator]; exists {
+					collect(value)
+				}
+			}
+		}
+	}
+
+	collect(groupSegments)
+
+	return keys
+}
+
+// EvaluateSegments matches a segment key, or an expression of segments grouped with and, or and not, against context.
+//
+// Expressions referring to unknown segments are never matched, and have the reason segment_not_found.
+func (d *DatafileReader) EvaluateSegments(groupSegments interface{}, context Context) SegmentEvaluation {
+	groupSegments = d.parseSegmentsIfStringified(groupSegments)
+	if keys, ok := groupSegments.([]SegmentKey); ok {
+		groupSegments, _ = toSlice(keys)
+	}
+
+	evaluation := SegmentEvaluation{
+		Segments: groupSegments,
+		Reason:   EvaluationReasonNoMatch,
+	}
+
+	if notFound := d.getUnknownSegmentKeys(groupSegments); len(notFound) > 0 {
+		evaluation.Reason = EvaluationReasonSegmentNotFound
+		evaluation.NotFound = notFound
+
+		d.logger.Warn("segment not found", LogDetails{
+			"segments": notFound,
+		})
+
+		return evaluation
+	}
+
+	if d.AllSegmentsAreMatched(groupSegments, context) {
+		evaluation.Matched = true
+		evaluation.Reason = EvaluationReasonSegmentMatched
+	}
+
+	return evaluation
+}
+
+// GetMatchingSegments returns keys of all segments matched by context, sorted
+func (d *DatafileReader) GetMatchingSegments(context Context) []SegmentKey {
+	matching := []SegmentKey{}
+
+	for _, segmentKey := range d.GetSegmentKeys() {
+		if d.SegmentIsMatched(d.GetSegment(segmentKey), context) {
+			matching = append(matching, segmentKey)
+		}
+	}
+
+	return matching
+}
+
+/**
+ * Instance
+ */
+
+// EvaluateSegments matches a segment key, or an expression of segments grouped with and, or and not, against context
+func (i *Featurevisor) EvaluateSegments(segments interface{}, context interface{}) SegmentEvaluation {
+	return i.datafileReader.EvaluateSegments(segments, i.GetContext(i.validateContext(toContext(context, i.logger))))
+}
+
+// IsInSegment checks if context matches a segment, or an expression like {"and": ["mobile", {"not": "germany"}]}
+func (i *Featurevisor) IsInSegment(segments interface{}, args ...interface{}) bool {
+	context, _, _ := parseArgs(args, i.logger)
+	return i.EvaluateSegments(segments, context).Matched
+}
+
+// GetMatchingSegments returns keys of all segments matched by context, sorted
+func (i *Featurevisor) GetMatchingSegments(args ...interface{}) []SegmentKey {
+	context, _, _ := parseArgs(args, i.logger)
+	return i.datafileReader.GetMatchingSegments(i.GetContext(i.validateContext(context)))
+}
+
+/**
+ * Child
+ */
+
+// EvaluateSegments matches a segment key, or an expression of segments grouped with and, or and not, against context
+func (c *FeaturevisorChild) EvaluateSegments(segments interface{}, context interface{}) SegmentEvaluation {
+	return c.parent.datafileReader.EvaluateSegments(segments, c.GetContext(c.parent.validateContext(toContext(context, c.parent.logger))))
+}
+
+// IsInSegment checks if context matches a segment, or an expression like {"and": ["mobile", {"not": "germany"}]}
+func (c *FeaturevisorChild) IsInSegment(segments interface{}, args ...interface{}) bool {
+	context, _, _ := parseArgs(args, c.parent.logger)
+	return c.EvaluateSegments(segments, context).Matched
+}
+
+// GetMatchingSegments returns keys of all segments matched by context, sorted
+func (c *FeaturevisorChild) GetMatchingSegments(args ...interface{}) []SegmentKey {
+	context, _, _ := parseArgs(args, c.parent.logger)
+	return c.parent.datafileReader.GetMatchingSegments(c.GetContext(c.parent.validateContext(context)))
+}
@@ -0,0 +1,81 @@
+package featurevisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+var segmentsTestDatafile = testDatafile{
+	segments: `{
+		"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]},
+		"germany": {"conditions": [{"attribute": "country", "operator": "equals", "value": "de"}]},
+		"mobile": {"conditions": [{"attribute": "device", "operator": "in", "value": ["ios", "android"]}]}
+	}`,
+}
+
+func TestIsInSegment(t *testing.T) {
+	instance := newTestInstance(t, segmentsTestDatafile, Options{})
+	context := Context{"country": "nl", "device": "ios"}
+
+	tests := []struct {
+		name     string
+		segments interface{}
+		expected bool
+	}{
+		{"single segment", "netherlands", true},
+		{"single unmatched segment", "germany", false},
+		{"everyone", "*", true},
+		{"and", AndGroupSegment{And: []GroupSegment{"netherlands", "mobile"}}, true},
+		{"or", OrGroupSegment{Or: []GroupSegment{"germany", "mobile"}}, true},
+		{"not", NotGroupSegment{Not: "germany"}, true},
+		{"stringified expression", `{"and": ["mobile", {"not": "netherlands"}]}`, false},
+		{"list", []string{"netherlands", "mobile"}, true},
+		{"unknown segment", "unknown", false},
+		{"unknown segment inside not", NotGroupSegment{Not: "unknown"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instance.IsInSegment(tt.segments, context); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvaluateSegments(t *testing.T) {
+	instance := newTestInstance(t, segmentsTestDatafile, Options{})
+	instance.SetContext(Context{"country": "nl"})
+
+	evaluation := instance.EvaluateSegments("netherlands", nil)
+	if !evaluation.Matched || evaluation.Reason != EvaluationReasonSegmentMatched {
+		t.Errorf("expected match with instance context, got %+v", evaluation)
+	}
+
+	evaluation = instance.EvaluateSegments("mobile", nil)
+	if evaluation.Matched || evaluation.Reason != EvaluationReasonNoMatch {
+		t.Errorf("expected no match, got %+v", evaluation)
+	}
+
+	evaluation = instance.EvaluateSegments(OrGroupSegment{Or: []GroupSegment{"netherlands", "unknown"}}, nil)
+	if evaluation.Matched || evaluation.Reason != EvaluationReasonSegmentNotFound || !reflect.DeepEqual(evaluation.NotFound, []SegmentKey{"unknown"}) {
+		t.Errorf("expected segment not found, got %+v", evaluation)
+	}
+}
+
+func TestGetMatchingSegments(t *testing.T) {
+	instance := newTestInstance(t, segmentsTestDatafile, Options{})
+
+	matching := instance.GetMatchingSegments(Context{"country": "nl", "device": "android"})
+	if !reflect.DeepEqual(matching, []SegmentKey{"mobile", "netherlands"}) {
+		t.Errorf("unexpected matching segments %v", matching)
+	}
+
+	child := instance.Spawn(Context{"country": "de"})
+	if !child.IsInSegment("germany") || child.IsInSegment("netherlands") {
+		t.Error("expected child context to be used")
+	}
+	if matching := child.GetMatchingSegments(); !reflect.DeepEqual(matching, []SegmentKey{"germany"}) {
+		t.Errorf("unexpected matching segments for child %v", matching)
+	}
+}
@@ -0,0 +1,482 @@
+package featurevisor
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semverStrictRegex matches a full SemVer 2.0 version, with an optional "v" prefix
+var semverStrictRegex = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
+
+// semverPartialRegex matches a possibly partial version as used in ranges, like "1", "1.2", "1.x" or "*"
+var semverPartialRegex = regexp.MustCompile(`^v?(\d+|[xX*])(?:\.(\d+|[xX*])(?:\.(\d+|[xX*])(?:-([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?)?)?$`)
+
+// SemverVersion represents a parsed SemVer 2.0 version
+type SemverVersion struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease []string
+	Build      []string
+}
+
+// ParseSemver parses a version strictly following SemVer 2.0
+func ParseSemver(version string) (*SemverVersion, error) {
+	matches := semverStrictRegex.FindStringSubmatch(strings.TrimSpace(version))
+	if matches == nil {
+		return nil, fmt.Errorf("invalid semver %q", version)
+	}
+
+	return newSemverVersion(matches[1], matches[2], matches[3], matches[4], matches[5])
+}
+
+// parseSemverLoose parses a version, filling in missing minor and patch numbers with zeros
+func parseSemverLoose(version string) (*SemverVersion, error) {
+	partial, err := parsePartialSemver(version)
+	if err != nil {
+		return nil, err
+	}
+	if partial.major < 0 {
+		return nil, fmt.Errorf("invalid semver %q", version)
+	}
+
+	return partial.floor(), nil
+}
+
+func newSemverVersion(major, minor, patch, prerelease, build string) (*SemverVersion, error) {
+	v := &SemverVersion{}
+
+	var err error
+	if v.Major, err = strconv.ParseUint(major, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid major version %q", major)
+	}
+	if v.Minor, err = strconv.ParseUint(minor, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid minor version %q", minor)
+	}
+	if v.Patch, err = strconv.ParseUint(patch, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid patch version %q", patch)
+	}
+
+	if prerelease != "" {
+		v.Prerelease = strings.Split(prerelease, ".")
+	}
+	if build != "" {
+		v.Build = strings.Split(build, ".")
+	}
+
+	return v, nil
+}
+
+// String returns the version in its canonical form
+func (v SemverVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Prerelease) > 0 {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+	if len(v.Build) > 0 {
+		s += "+" + strings.Join(v.Build, ".")
+	}
+	return s
+}
+
+// Compare compares two versions by SemVer 2.0 precedence, ignoring build metadata
+func (v SemverVersion) Compare(other SemverVersion) int {
+	if result := compareUint(v.Major, other.Major); result != 0 {
+		return result
+	}
+	if result := compareUint(v.Minor, other.Minor); result != 0 {
+		return result
+	}
+	if result := compareUint(v.Patch, other.Patch); result != 0 {
+		return result
+	}
+
+	// a version without pre-release has higher precedence
+	switch {
+	case len(v.Prerelease) == 0 && len(other.Prerelease) == 0:
+		return 0
+	case len(v.Prerelease) == 0:
+		return 1
+	case len(other.Prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if result := comparePrereleaseIdentifiers(v.Prerelease[i], other.Prerelease[i]); result != 0 {
+			return result
+		}
+	}
+
+	// a larger set of pre-release identifiers has higher precedence
+	return compareUint(uint64(len(v.Prerelease)), uint64(len(other.Prerelease)))
+}
+
+// comparePrereleaseIdentifiers compares numeric identifiers numerically, and lower than alphanumeric ones
+func comparePrereleaseIdentifiers(a, b string) int {
+	aNum, aErr := strconv.ParseUint(a, 10, 64)
+	bNum, bErr := strconv.ParseUint(b, 10, 64)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return compareUint(aNum, bNum)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// CompareVersionsStrict compares two versions by SemVer 2.0 precedence
+// Returns:
+//
+//	-1 if v1 < v2
+//	 0 if v1 == v2
+//	 1 if v1 > v2
+func CompareVersionsStrict(v1, v2 string) (int, error) {
+	version1, err := ParseSemver(v1)
+	if err != nil {
+		return 0, fmt.Errorf("invalid first version: %w", err)
+	}
+
+	version2, err := ParseSemver(v2)
+	if err != nil {
+		return 0, fmt.Errorf("invalid second version: %w", err)
+	}
+
+	return version1.Compare(*version2), nil
+}
+
+/**
+ * Ranges
+ */
+
+// partialSemver is a version in a range where missing or wildcard parts are -1
+type partialSemver struct {
+	major      int64
+	minor      int64
+	patch      int64
+	prerelease []string
+}
+
+func parsePartialSemver(version string) (*partialSemver, error) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return &partialSemver{major: -1, minor: -1, patch: -1}, nil
+	}
+
+	matches := semverPartialRegex.FindStringSubmatch(version)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+
+	parsePart := func(part string) (int64, error) {
+		if part == "" || IsWildcard(part) {
+			return -1, nil
+		}
+		return strconv.ParseInt(part, 10, 64)
+	}
+
+	p := &partialSemver{}
+
+	var err error
+	if p.major, err = parsePart(matches[1]); err != nil {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	if p.minor, err = parsePart(matches[2]); err != nil {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+	if p.patch, err = parsePart(matches[3]); err != nil {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+
+	// anything after a wildcard is a wildcard too
+	if p.major < 0 {
+		p.minor = -1
+	}
+	if p.minor < 0 {
+		p.patch = -1
+	}
+
+	if matches[4] != "" && p.patch >= 0 {
+		p.prerelease = strings.Split(matches[4], ".")
+	}
+
+	return p, nil
+}
+
+// floor returns the lowest version matched by the partial version
+func (p *partialSemver) floor() *SemverVersion {
+	v := &SemverVersion{Prerelease: p.prerelease}
+	if p.major > 0 {
+		v.Major = uint64(p.major)
+	}
+	if p.minor > 0 {
+		v.Minor = uint64(p.minor)
+	}
+	if p.patch > 0 {
+		v.Patch = uint64(p.patch)
+	}
+	return v
+}
+
+// ceiling returns the lowest version not matched by the partial version anymore, or nil if unbounded
+func (p *partialSemver) ceiling() *SemverVersion {
+	switch {
+	case p.major < 0:
+		return nil
+	case p.minor < 0:
+		return &SemverVersion{Major: uint64(p.major) + 1, Prerelease: []string{"0"}}
+	case p.patch < 0:
+		return &SemverVersion{Major: uint64(p.major), Minor: uint64(p.minor) + 1, Prerelease: []string{"0"}}
+	}
+	return nil
+}
+
+// semverComparator compares a version against a bound
+type semverComparator struct {
+	operator string // one of ">", ">=", "<", "<=", "="
+	version  SemverVersion
+}
+
+func (c semverComparator) matches(version SemverVersion) bool {
+	result := version.Compare(c.version)
+
+	switch c.operator {
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	}
+
+	return result == 0
+}
+
+// SemverRange represents an npm style range of versions, like "^1.2", "~3.4.1" or ">=1.0 <2.0 || 3.x"
+type SemverRange struct {
+	raw  string
+	sets [][]semverComparator // any set matching with all of its comparators
+}
+
+var semverHyphenRegex = regexp.MustCompile(`^\s*(\S*)\s+-\s+(\S*)\s*$`)
+
+// semverOperatorSpaceRegex removes spaces between operators and versions, like in ">= 1.2"
+var semverOperatorSpaceRegex = regexp.MustCompile(`(~>|~|\^|>=|<=|>|<|=)\s+`)
+
+// ParseSemverRange parses an npm style range of versions
+func ParseSemverRange(rangeString string) (*SemverRange, error) {
+	r := &SemverRange{raw: rangeString}
+
+	for _, setString := range strings.Split(rangeString, "||") {
+		set, err := parseSemverComparatorSet(setString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid semver range %q: %w", rangeString, err)
+		}
+		r.sets = append(r.sets, set)
+	}
+
+	return r, nil
+}
+
+func parseSemverComparatorSet(setString string) ([]semverComparator, error) {
+	// hyphen ranges: "1.2.3 - 2.3.4"
+	if matches := semverHyphenRegex.FindStringSubmatch(setString); matches != nil {
+		from, err := parsePartialSemver(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		to, err := parsePartialSemver(matches[2])
+		if err != nil {
+			return nil, err
+		}
+
+		set := []semverComparator{{operator: ">=", version: *from.floor()}}
+		if ceiling := to.ceiling(); ceiling != nil {
+			set = append(set, semverComparator{operator: "<", version: *ceiling})
+		} else if to.major >= 0 {
+			set = append(set, semverComparator{operator: "<=", version: *to.floor()})
+		}
+		return set, nil
+	}
+
+	set := []semverComparator{}
+	for _, part := range strings.Fields(semverOperatorSpaceRegex.ReplaceAllString(setString, "$1")) {
+		comparators, err := parseSemverComparator(part)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, comparators...)
+	}
+
+	return set, nil
+}
+
+// parseSemverComparator turns a single comparator into primitive ones
+func parseSemverComparator(comparator string) ([]semverComparator, error) {
+	operator := ""
+	for _, candidate := range []string{"~>", ">=", "<=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(comparator, candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	partial, err := parsePartialSemver(strings.TrimPrefix(comparator, operator))
+	if err != nil {
+		return nil, err
+	}
+
+	floor := *partial.floor()
+	ceiling := partial.ceiling()
+
+	// matches any version
+	if partial.major < 0 {
+		if operator == "<" || operator == ">" {
+			// nothing is lower or higher than any version
+			return []semverComparator{{operator: "<", version: SemverVersion{Prerelease: []string{"0"}}}}, nil
+		}
+		return nil, nil
+	}
+
+	switch operator {
+	case "~", "~>":
+		// allows patch level changes, or minor level if minor is missing
+		upper := &SemverVersion{Major: floor.Major, Minor: floor.Minor + 1, Prerelease: []string{"0"}}
+		if partial.minor < 0 {
+			upper = &SemverVersion{Major: floor.Major + 1, Prerelease: []string{"0"}}
+		}
+		return []semverComparator{{">=", floor}, {"<", *upper}}, nil
+
+	case "^":
+		// allows changes not modifying the left-most non-zero part
+		var upper SemverVersion
+		switch {
+		case floor.Major > 0 || partial.minor < 0:
+			upper = SemverVersion{Major: floor.Major + 1, Prerelease: []string{"0"}}
+		case floor.Minor > 0 || partial.patch < 0:
+			upper = SemverVersion{Minor: floor.Minor + 1, Prerelease: []string{"0"}}
+		default:
+			upper = SemverVersion{Minor: floor.Minor, Patch: floor.Patch + 1, Prerelease: []string{"0"}}
+		}
+		return []semverComparator{{">=", floor}, {"<", upper}}, nil
+
+	case ">":
+		if ceiling != nil {
+			// lowest release of the next version, like ">1.2" being ">=1.3.0"
+			return []semverComparator{{">=", SemverVersion{Major: ceiling.Major, Minor: ceiling.Minor, Patch: ceiling.Patch}}}, nil
+		}
+		return []semverComparator{{">", floor}}, nil
+
+	case ">=":
+		return []semverComparator{{">=", floor}}, nil
+
+	case "<":
+		return []semverComparator{{"<", floor}}, nil
+
+	case "<=":
+		if ceiling != nil {
+			return []semverComparator{{"<", *ceiling}}, nil
+		}
+		return []semverComparator{{"<=", floor}}, nil
+	}
+
+	// exact or x-range
+	if ceiling != nil {
+		return []semverComparator{{">=", floor}, {"<", *ceiling}}, nil
+	}
+	return []semverComparator{{"=", floor}}, nil
+}
+
+// String returns the range as it was parsed
+func (r *SemverRange) String() string {
+	return r.raw
+}
+
+// Satisfies tells whether a version is within the range.
+//
+// Like npm, pre-release versions only satisfy a range if a comparator in the
+// matching set has a pre-release on the same major, minor and patch version.
+func (r *SemverRange) Satisfies(version SemverVersion) bool {
+	for _, set := range r.sets {
+		if semverSetSatisfies(set, version) {
+			return true
+		}
+	}
+	return false
+}
+
+func semverSetSatisfies(set []semverComparator, version SemverVersion) bool {
+	for _, comparator := range set {
+		if !comparator.matches(version) {
+			return false
+		}
+	}
+
+	if len(version.Prerelease) == 0 {
+		return true
+	}
+
+	for _, comparator := range set {
+		bound := comparator.version
+		if len(bound.Prerelease) > 0 &&
+			bound.Major == version.Major && bound.Minor == version.Minor && bound.Patch == version.Patch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SemverSatisfies tells whether a version is within an npm style range.
+// Versions with missing minor or patch numbers are filled in with zeros.
+// Unlike CompareVersions, versions with more than three numbers, like "1.2.3.4", are invalid.
+func SemverSatisfies(version string, rangeString string) (bool, error) {
+	v, err := parseSemverLoose(version)
+	if err != nil {
+		return false, err
+	}
+
+	r, err := ParseSemverRange(rangeString)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Satisfies(*v), nil
+}
+
+// semverSatisfies tells whether a version is within a range, parsing the version strictly if needed
+func semverSatisfies(version string, rangeString string, strict bool) bool {
+	parse := parseSemverLoose
+	if strict {
+		parse = ParseSemver
+	}
+
+	v, err := parse(version)
+	if err != nil {
+		return false
+	}
+
+	r, err := ParseSemverRange(rangeString)
+	if err != nil {
+		return false
+	}
+
+	return r.Satisfies(*v)
+}
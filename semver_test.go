@@ -0,0 +1,272 @@
+package featurevisor
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	valid := []string{
+		"0.0.0",
+		"1.2.3",
+		"v1.2.3",
+		"1.2.3-alpha",
+		"1.2.3-alpha.1",
+		"1.2.3-0.3.7",
+		"1.2.3-x.7.z.92",
+		"1.2.3+build.1",
+		"1.2.3-beta+exp.sha.5114f85",
+	}
+	for _, version := range valid {
+		if _, err := ParseSemver(version); err != nil {
+			t.Errorf("expected %q to be valid, got %v", version, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"1",
+		"1.2",
+		"1.2.3.4",
+		"01.2.3",
+		"1.02.3",
+		"1.2.3-01",
+		"1.2.3-",
+		"1.2.3+",
+		"1.2.x",
+		"not-a-version",
+	}
+	for _, version := range invalid {
+		if _, err := ParseSemver(version); err == nil {
+			t.Errorf("expected %q to be invalid", version)
+		}
+	}
+
+	v, _ := ParseSemver("1.2.3-beta.2+build.7")
+	if v.Major != 1 || v.Minor != 2 || v.Patch != 3 || len(v.Prerelease) != 2 || len(v.Build) != 2 {
+		t.Fatalf("unexpected parsed version %+v", v)
+	}
+	if v.String() != "1.2.3-beta.2+build.7" {
+		t.Fatalf("unexpected string %q", v.String())
+	}
+}
+
+func TestSemverPrecedence(t *testing.T) {
+	// ordered by SemVer 2.0 precedence, from the specification
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+		"10.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		result, err := CompareVersionsStrict(ordered[i], ordered[i+1])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result != -1 {
+			t.Errorf("expected %s < %s", ordered[i], ordered[i+1])
+		}
+	}
+
+	versions := make([]SemverVersion, 0, len(ordered))
+	for i := len(ordered) - 1; i >= 0; i-- {
+		v, _ := ParseSemver(ordered[i])
+		versions = append(versions, *v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Compare(versions[j]) < 0 })
+	for i, v := range versions {
+		if v.String() != ordered[i] {
+			t.Fatalf("expected %s at %d, got %s", ordered[i], i, v.String())
+		}
+	}
+
+	// build metadata is ignored
+	if result, _ := CompareVersionsStrict("1.0.0+build.1", "1.0.0+build.2"); result != 0 {
+		t.Errorf("expected build metadata to be ignored")
+	}
+}
+
+func TestSemverSatisfies(t *testing.T) {
+	tests := []struct {
+		rangeString string
+		version     string
+		expected    bool
+	}{
+		// caret
+		{"^1.2.3", "1.2.3", true},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^1.2.3", "1.2.2", false},
+		{"^1.2", "1.2.0", true},
+		{"^1.2", "1.99.99", true},
+		{"^1", "1.0.0", true},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.3", true},
+		{"^0.0.3", "0.0.4", false},
+		{"^0.0", "0.0.9", true},
+		{"^0.0", "0.1.0", false},
+		{"^0.x", "0.9.0", true},
+
+		// tilde
+		{"~3.4.1", "3.4.1", true},
+		{"~3.4.1", "3.4.9", true},
+		{"~3.4.1", "3.5.0", false},
+		{"~3.4", "3.4.0", true},
+		{"~3", "3.9.0", true},
+		{"~3", "4.0.0", false},
+		{"~>3.4.1", "3.4.5", true},
+
+		// comparators and unions
+		{">=1.0 <2.0 || 3.x", "1.5.0", true},
+		{">=1.0 <2.0 || 3.x", "2.5.0", false},
+		{">=1.0 <2.0 || 3.x", "3.1.4", true},
+		{">= 1.0 < 2.0", "1.0.0", true},
+		{">1.2", "1.2.9", false},
+		{">1.2", "1.3.0", true},
+		{"<=1.2", "1.2.9", true},
+		{"<=1.2", "1.3.0", false},
+		{"<1.2", "1.1.9", true},
+		{"<1.2", "1.2.0", false},
+		{"=1.2.3", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+
+		// x-ranges
+		{"*", "99.0.0", true},
+		{"", "1.0.0", true},
+		{"1.x", "1.9.9", true},
+		{"1.2.x", "1.3.0", false},
+		{"1.2.*", "1.2.7", true},
+
+		// hyphen ranges
+		{"1.2.3 - 2.3.4", "2.3.4", true},
+		{"1.2.3 - 2.3.4", "2.3.5", false},
+		{"1.2 - 2.3", "2.3.9", true},
+		{"1.2 - 2.3", "2.4.0", false},
+
+		// pre-releases
+		{"^1.2.3-beta.2", "1.2.3-beta.4", true},
+		{"^1.2.3-beta.2", "1.2.4-beta.1", false},
+		{"^1.2.3", "1.5.0-beta", false},
+		{"*", "1.0.0-beta", false},
+		{">=1.0.0-rc.1", "1.0.0-rc.2", true},
+
+		// loose versions
+		{"^1.2", "1.2", true},
+		{"^1.2", "v1.3", true},
+	}
+
+	for _, tt := range tests {
+		result, err := SemverSatisfies(tt.version, tt.rangeString)
+		if err != nil {
+			t.Errorf("unexpected error for %q in %q: %v", tt.version, tt.rangeString, err)
+			continue
+		}
+		if result != tt.expected {
+			t.Errorf("expected %q in %q to be %v", tt.version, tt.rangeString, tt.expected)
+		}
+	}
+}
+
+func TestSemverFourPartVersions(t *testing.T) {
+	// comparison operators accept more than three numbers
+	if result, err := CompareVersions("1.2.3.4", "1.2.3"); err != nil || result != 1 {
+		t.Errorf("expected 1.2.3.4 to be greater than 1.2.3, got %d, %v", result, err)
+	}
+
+	// ranges follow npm, with versions of three numbers
+	if _, err := SemverSatisfies("1.2.3.4", "^1.2"); err == nil {
+		t.Error("expected 1.2.3.4 to be invalid in ranges")
+	}
+	if semverSatisfies("1.2.3.4", "^1.2", false) {
+		t.Error("expected 1.2.3.4 not to satisfy any range")
+	}
+}
+
+func TestParseSemverRangeErrors(t *testing.T) {
+	for _, rangeString := range []string{"^abc", ">=1.0 <two", "1.2.3 - x.y"} {
+		if _, err := ParseSemverRange(rangeString); err == nil {
+			t.Errorf("expected %q to be invalid", rangeString)
+		}
+	}
+}
+
+func TestSemverOperatorsInDatafile(t *testing.T) {
+	datafileContent := `{
+		"schemaVersion": "2",
+		"revision": "1",
+		"segments": {
+			"modernApp": {
+				"conditions": [{"attribute": "version", "operator": "semverSatisfies", "value": "^2.1 || >=3.0.0-rc.1"}]
+			},
+			"beta": {
+				"conditions": [{"attribute": "version", "operator": "semverEquals", "value": "1.0.0-beta"}]
+			},
+			"broken": {
+				"conditions": [{"attribute": "version", "operator": "semverSatisfies", "value": "^x.y.z"}]
+			}
+		},
+		"features": {
+			"modernApp": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "modernApp", "percentage": 100000}]
+			},
+			"beta": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "beta", "percentage": 100000}]
+			}
+		}
+	}`
+
+	instance := CreateInstance(Options{
+		Datafile: datafileContent,
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	result := instance.GetDatafileValidationResult()
+	if len(result.Issues) != 1 || result.Issues[0].Type != DatafileIssueTypeInvalidSemverRange || result.Issues[0].SegmentKey != "broken" {
+		t.Fatalf("expected invalid range issue, got %+v", result.Issues)
+	}
+
+	for version, expected := range map[string]bool{
+		"2.1.0":      true,
+		"2.9":        true,
+		"3.0.0-rc.2": true,
+		"2.0.9":      false,
+		"1.9.0":      false,
+	} {
+		if instance.IsEnabled("modernApp", Context{"userId": "123", "version": version}) != expected {
+			t.Errorf("expected version %s to be %v", version, expected)
+		}
+	}
+
+	// loose comparison pads missing pre-release identifiers with zeros
+	if !instance.IsEnabled("beta", Context{"userId": "123", "version": "1.0.0-beta.0"}) {
+		t.Errorf("expected loose comparison to treat beta.0 equal to beta")
+	}
+
+	strict := CreateInstance(Options{
+		Datafile:     datafileContent,
+		LogLevel:     &[]LogLevel{LogLevelFatal}[0],
+		StrictSemver: true,
+	})
+
+	if strict.IsEnabled("beta", Context{"userId": "123", "version": "1.0.0-beta.0"}) {
+		t.Errorf("expected strict comparison to treat beta.0 higher than beta")
+	}
+
+	// strict mode does not fill in missing parts
+	if strict.IsEnabled("modernApp", Context{"userId": "123", "version": "2.9"}) {
+		t.Errorf("expected partial version not to match in strict mode")
+	}
+}
@@ -0,0 +1,458 @@
+package featurevisor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStickyStoreAttribute is the context attribute used as the store key when none is configured
+const DefaultStickyStoreAttribute = "userId"
+
+// DefaultStickyCookieName is the cookie name used by CookieStickyStore when none is configured
+const DefaultStickyCookieName = "featurevisor_sticky"
+
+// StickyRecord represents a persisted evaluation of a single feature
+type StickyRecord struct {
+	Feature   EvaluatedFeature `json:"feature"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+// StickyStore persists evaluated features across instance lifetimes
+type StickyStore interface {
+	// Get returns the record for the given key and feature, or nil if there is none
+	Get(key string, featureKey FeatureKey) (*StickyRecord, error)
+
+	// Set stores the record for the given key and feature
+	Set(key string, featureKey FeatureKey, record StickyRecord) error
+}
+
+// StickyStoreOptions contains options for using a sticky store during evaluation
+type StickyStoreOptions struct {
+	Store StickyStore
+
+	// Attribute is the context attribute used as the store key (defaults to "userId")
+	Attribute AttributeKey
+
+	// TTL is how long records stay valid after they are created (zero means forever)
+	TTL time.Duration
+
+	// Features lists the feature keys opted in to persistence
+	Features []FeatureKey
+}
+
+func (o *StickyStoreOptions) isEnabledFor(featureKey FeatureKey) bool {
+	if o == nil || o.Store == nil {
+		return false
+	}
+
+	for _, key := range o.Features {
+		if key == featureKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *StickyStoreOptions) getKey(context Context) (string, bool) {
+	attribute := o.Attribute
+	if attribute == "" {
+		attribute = DefaultStickyStoreAttribute
+	}
+
+	value := GetValueFromContext(context, attribute)
+	if value == nil {
+		return "", false
+	}
+
+	key := toString(value)
+	if key == "" {
+		return "", false
+	}
+
+	return key, true
+}
+
+func (o *StickyStoreOptions) isExpired(record *StickyRecord, now time.Time) bool {
+	if o.TTL <= 0 {
+		return false
+	}
+
+	return now.Sub(record.CreatedAt) > o.TTL
+}
+
+// getStickyStoreRecord reads a non-expired record from the sticky store for the evaluated feature
+func getStickyStoreRecord(options EvaluateOptions) *StickyRecord {
+	stickyStore := options.StickyStore
+	if !stickyStore.isEnabledFor(options.FeatureKey) {
+		return nil
+	}
+
+	key, ok := stickyStore.getKey(options.Context)
+	if !ok {
+		return nil
+	}
+
+	record, err := stickyStore.Store.Get(key, options.FeatureKey)
+	if err != nil {
+		options.Logger.Warn("could not read from sticky store", LogDetails{
+			"featureKey": options.FeatureKey,
+			"error":      err,
+		})
+		return nil
+	}
+
+	if record == nil || stickyStore.isExpired(record, time.Now()) {
+		return nil
+	}
+
+	return record
+}
+
+// setStickyStoreRecord writes rule and allocated outcomes of flag and variation evaluations to the sticky store
+func setStickyStoreRecord(options EvaluateOptions, evaluation Evaluation) {
+	if evaluation.Reason != EvaluationReasonRule && evaluation.Reason != EvaluationReasonAllocated {
+		return
+	}
+
+	stickyStore := options.StickyStore
+	if !stickyStore.isEnabledFor(options.FeatureKey) {
+		return
+	}
+
+	key, ok := stickyStore.getKey(options.Context)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	record := StickyRecord{CreatedAt: now}
+
+	existingRecord, err := stickyStore.Store.Get(key, options.FeatureKey)
+	if err == nil && existingRecord != nil && !stickyStore.isExpired(existingRecord, now) {
+		record = *existingRecord
+	}
+
+	switch evaluation.Type {
+	case EvaluationTypeFlag:
+		if evaluation.Enabled == nil {
+			return
+		}
+		record.Feature.Enabled = *evaluation.Enabled
+	case EvaluationTypeVariation:
+		if evaluation.VariationValue == nil {
+			return
+		}
+		variationValue := *evaluation.VariationValue
+		record.Feature.Enabled = true
+		record.Feature.Variation = &variationValue
+	default:
+		return
+	}
+
+	if err := stickyStore.Store.Set(key, options.FeatureKey, record); err != nil {
+		options.Logger.Warn("could not write to sticky store", LogDetails{
+			"featureKey": options.FeatureKey,
+			"error":      err,
+		})
+		return
+	}
+
+	options.Logger.Debug("sticky store record written", LogDetails{
+		"featureKey": options.FeatureKey,
+		"record":     record,
+	})
+}
+
+// stickyRecords represents records grouped by store key and feature key
+type stickyRecords map[string]map[FeatureKey]StickyRecord
+
+func (r stickyRecords) get(key string, featureKey FeatureKey) *StickyRecord {
+	features, exists := r[key]
+	if !exists {
+		return nil
+	}
+
+	record, exists := features[featureKey]
+	if !exists {
+		return nil
+	}
+
+	return &record
+}
+
+func (r stickyRecords) set(key string, featureKey FeatureKey, record StickyRecord) {
+	if r[key] == nil {
+		r[key] = make(map[FeatureKey]StickyRecord)
+	}
+	r[key][featureKey] = record
+}
+
+/**
+ * Memory
+ */
+
+// MemoryStickyStore keeps sticky records in memory
+type MemoryStickyStore struct {
+	records stickyRecords
+	mu      sync.RWMutex
+}
+
+// NewMemoryStickyStore creates a new in-memory sticky store
+func NewMemoryStickyStore() *MemoryStickyStore {
+	return &MemoryStickyStore{
+		records: make(stickyRecords),
+	}
+}
+
+// Get returns the record for the given key and feature
+func (s *MemoryStickyStore) Get(key string, featureKey FeatureKey) (*StickyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.records.get(key, featureKey), nil
+}
+
+// Set stores the record for the given key and feature
+func (s *MemoryStickyStore) Set(key string, featureKey FeatureKey, record StickyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records.set(key, featureKey, record)
+
+	return nil
+}
+
+/**
+ * File
+ */
+
+// FileStickyStore keeps sticky records in a JSON file
+type FileStickyStore struct {
+	path    string
+	records stickyRecords
+	mu      sync.Mutex
+}
+
+// NewFileStickyStore creates a new sticky store backed by the JSON file at path, loading existing records if any
+func NewFileStickyStore(path string) (*FileStickyStore, error) {
+	records := make(stickyRecords)
+
+	bytes, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read sticky store file: %w", err)
+	}
+
+	if len(bytes) > 0 {
+		if err := json.Unmarshal(bytes, &records); err != nil {
+			return nil, fmt.Errorf("invalid sticky store file: %w", err)
+		}
+	}
+
+	return &FileStickyStore{
+		path:    path,
+		records: records,
+	}, nil
+}
+
+// Get returns the record for the given key and feature
+func (s *FileStickyStore) Get(key string, featureKey FeatureKey) (*StickyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records.get(key, featureKey), nil
+}
+
+// Set stores the record for the given key and feature, and writes all records to the file
+func (s *FileStickyStore) Set(key string, featureKey FeatureKey, record StickyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records.set(key, featureKey, record)
+
+	bytes, err := json.Marshal(s.records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sticky records: %w", err)
+	}
+
+	// write to a temporary file first, so that readers never see a partially written file
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create sticky store file: %w", err)
+	}
+
+	if _, err := tmpFile.Write(bytes); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("failed to write sticky store file: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("failed to write sticky store file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("failed to write sticky store file: %w", err)
+	}
+
+	return nil
+}
+
+/**
+ * Cookie
+ */
+
+// CookieStickyStoreOptions contains options for creating a cookie sticky store
+type CookieStickyStoreOptions struct {
+	Secret  []byte
+	Request *http.Request
+	Writer  http.ResponseWriter
+
+	Name     string // defaults to "featurevisor_sticky"
+	Path     string
+	Domain   string
+	MaxAge   int
+	Secure   bool
+	HTTPOnly bool
+}
+
+// CookieStickyStore keeps sticky records in a signed cookie, scoped to a single HTTP request
+type CookieStickyStore struct {
+	options CookieStickyStoreOptions
+	records stickyRecords
+	mu      sync.Mutex
+}
+
+// NewCookieStickyStore creates a new sticky store reading records from the request cookie and writing them to the response
+func NewCookieStickyStore(options CookieStickyStoreOptions) (*CookieStickyStore, error) {
+	if len(options.Secret) == 0 {
+		return nil, fmt.Errorf("cookie sticky store requires a secret")
+	}
+
+	if options.Name == "" {
+		options.Name = DefaultStickyCookieName
+	}
+
+	records := make(stickyRecords)
+
+	if options.Request != nil {
+		if cookie, err := options.Request.Cookie(options.Name); err == nil {
+			// cookies with invalid signatures are ignored, and get replaced on next write
+			if decoded, err := decodeStickyCookie(cookie.Value, options.Secret); err == nil {
+				records = decoded
+			}
+		}
+	}
+
+	return &CookieStickyStore{
+		options: options,
+		records: records,
+	}, nil
+}
+
+// Get returns the record for the given key and feature
+func (s *CookieStickyStore) Get(key string, featureKey FeatureKey) (*StickyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.records.get(key, featureKey), nil
+}
+
+// Set stores the record for the given key and feature, and sets the signed cookie on the response
+func (s *CookieStickyStore) Set(key string, featureKey FeatureKey, record StickyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records.set(key, featureKey, record)
+
+	if s.options.Writer == nil {
+		return nil
+	}
+
+	value, err := encodeStickyCookie(s.records, s.options.Secret)
+	if err != nil {
+		return err
+	}
+
+	// replace any cookie with the same name set earlier in this response
+	header := s.options.Writer.Header()
+	setCookies := header.Values("Set-Cookie")
+	header.Del("Set-Cookie")
+	for _, setCookie := range setCookies {
+		if !strings.HasPrefix(setCookie, s.options.Name+"=") {
+			header.Add("Set-Cookie", setCookie)
+		}
+	}
+
+	http.SetCookie(s.options.Writer, &http.Cookie{
+		Name:     s.options.Name,
+		Value:    value,
+		Path:     s.options.Path,
+		Domain:   s.options.Domain,
+		MaxAge:   s.options.MaxAge,
+		Secure:   s.options.Secure,
+		HttpOnly: s.options.HTTPOnly,
+	})
+
+	return nil
+}
+
+// Cookie returns the signed cookie value for the current records
+func (s *CookieStickyStore) Cookie() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return encodeStickyCookie(s.records, s.options.Secret)
+}
+
+func signStickyCookie(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func encodeStickyCookie(records stickyRecords, secret []byte) (string, error) {
+	bytes, err := json.Marshal(records)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal sticky records: %w", err)
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(bytes)
+
+	return payload + "." + signStickyCookie(payload, secret), nil
+}
+
+func decodeStickyCookie(value string, secret []byte) (stickyRecords, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("malformed sticky cookie")
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(signStickyCookie(parts[0], secret))) {
+		return nil, fmt.Errorf("invalid sticky cookie signature")
+	}
+
+	bytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("malformed sticky cookie: %w", err)
+	}
+
+	records := make(stickyRecords)
+	if err := json.Unmarshal(bytes, &records); err != nil {
+		return nil, fmt.Errorf("malformed sticky cookie: %w", err)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,191 @@
+package featurevisor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stickyStoreTestFeatures has the end of the control range to be formatted in
+const stickyStoreTestFeatures = `{
+	"test": {
+		"bucketBy": "userId",
+		"variations": [{"value": "control"}, {"value": "treatment"}],
+		"traffic": [
+			{
+				"key": "1",
+				"segments": "*",
+				"percentage": 100000,
+				"allocation": [
+					{"variation": "control", "range": [0, %[1]d]},
+					{"variation": "treatment", "range": [%[1]d, 100000]}
+				]
+			}
+		]
+	}
+}`
+
+func TestStickyStoreKeepsVariationAcrossDatafileChanges(t *testing.T) {
+	store := NewMemoryStickyStore()
+
+	instance := CreateInstance(Options{
+		Datafile: testDatafile{features: fmt.Sprintf(stickyStoreTestFeatures, 100000)}.build(t),
+		StickyStore: &StickyStoreOptions{
+			Store:    store,
+			Features: []FeatureKey{"test"},
+		},
+	})
+
+	context := Context{"userId": "123"}
+
+	variation := instance.GetVariation("test", context)
+	if variation == nil || *variation != "control" {
+		t.Fatalf("expected control variation, got %v", variation)
+	}
+
+	record, _ := store.Get("123", "test")
+	if record == nil || record.Feature.Variation == nil || *record.Feature.Variation != "control" {
+		t.Fatalf("expected control variation to be persisted, got %+v", record)
+	}
+
+	// change weights so that everyone lands in treatment
+	instance.SetDatafile(testDatafile{features: fmt.Sprintf(stickyStoreTestFeatures, 0)}.build(t))
+
+	evaluation := instance.EvaluateVariation("test", context, OverrideOptions{})
+	if evaluation.Reason != EvaluationReasonSticky {
+		t.Fatalf("expected sticky reason, got %s", evaluation.Reason)
+	}
+	if evaluation.VariationValue == nil || *evaluation.VariationValue != "control" {
+		t.Fatalf("expected persisted control variation, got %v", evaluation.VariationValue)
+	}
+
+	// other users are bucketed against the new datafile
+	variation = instance.GetVariation("test", Context{"userId": "456"})
+	if variation == nil || *variation != "treatment" {
+		t.Fatalf("expected treatment variation for new user, got %v", variation)
+	}
+}
+
+func TestStickyStoreOptInAndAttribute(t *testing.T) {
+	store := NewMemoryStickyStore()
+
+	instance := CreateInstance(Options{
+		Datafile: testDatafile{features: fmt.Sprintf(stickyStoreTestFeatures, 100000)}.build(t),
+		StickyStore: &StickyStoreOptions{
+			Store:     store,
+			Attribute: "deviceId",
+			Features:  []FeatureKey{"other"},
+		},
+	})
+
+	instance.GetVariation("test", Context{"userId": "123", "deviceId": "d1"})
+
+	if record, _ := store.Get("d1", "test"); record != nil {
+		t.Fatalf("expected no record for feature that is not opted in, got %+v", record)
+	}
+
+	child := instance.Spawn(Context{"userId": "123", "deviceId": "d1"}, OverrideOptions{
+		StickyStore: &StickyStoreOptions{
+			Store:     store,
+			Attribute: "deviceId",
+			Features:  []FeatureKey{"test"},
+		},
+	})
+	child.GetVariation("test")
+
+	if record, _ := store.Get("d1", "test"); record == nil {
+		t.Fatalf("expected record keyed by deviceId")
+	}
+}
+
+func TestStickyStoreTTL(t *testing.T) {
+	store := NewMemoryStickyStore()
+	store.Set("123", "test", StickyRecord{
+		Feature:   EvaluatedFeature{Enabled: true, Variation: stringPtr("treatment")},
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	})
+
+	instance := CreateInstance(Options{
+		Datafile: testDatafile{features: fmt.Sprintf(stickyStoreTestFeatures, 100000)}.build(t),
+		StickyStore: &StickyStoreOptions{
+			Store:    store,
+			TTL:      time.Hour,
+			Features: []FeatureKey{"test"},
+		},
+	})
+
+	variation := instance.GetVariation("test", Context{"userId": "123"})
+	if variation == nil || *variation != "control" {
+		t.Fatalf("expected expired record to be ignored, got %v", variation)
+	}
+
+	record, _ := store.Get("123", "test")
+	if record == nil || *record.Feature.Variation != "control" || time.Since(record.CreatedAt) > time.Minute {
+		t.Fatalf("expected expired record to be replaced, got %+v", record)
+	}
+}
+
+func TestFileStickyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sticky.json")
+
+	store, err := NewFileStickyStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := store.Set("123", "test", StickyRecord{Feature: EvaluatedFeature{Enabled: true}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	reloaded, err := NewFileStickyStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	record, _ := reloaded.Get("123", "test")
+	if record == nil || !record.Feature.Enabled {
+		t.Fatalf("expected record to be loaded from file, got %+v", record)
+	}
+}
+
+func TestCookieStickyStore(t *testing.T) {
+	secret := []byte("secret")
+
+	if _, err := NewCookieStickyStore(CookieStickyStoreOptions{}); err == nil {
+		t.Fatalf("expected error without secret")
+	}
+
+	recorder := httptest.NewRecorder()
+	store, _ := NewCookieStickyStore(CookieStickyStoreOptions{
+		Secret:  secret,
+		Request: httptest.NewRequest(http.MethodGet, "/", nil),
+		Writer:  recorder,
+	})
+
+	store.Set("123", "a", StickyRecord{Feature: EvaluatedFeature{Enabled: true}})
+	store.Set("123", "b", StickyRecord{Feature: EvaluatedFeature{Enabled: false}})
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultStickyCookieName {
+		t.Fatalf("expected a single sticky cookie, got %v", cookies)
+	}
+
+	// valid cookie is read back
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.AddCookie(cookies[0])
+	store, _ = NewCookieStickyStore(CookieStickyStoreOptions{Secret: secret, Request: request})
+
+	if record, _ := store.Get("123", "a"); record == nil || !record.Feature.Enabled {
+		t.Fatalf("expected record from cookie, got %+v", record)
+	}
+
+	// cookie signed with another secret is ignored
+	store, _ = NewCookieStickyStore(CookieStickyStoreOptions{Secret: []byte("other"), Request: request})
+
+	if record, _ := store.Get("123", "a"); record != nil {
+		t.Fatalf("expected tampered cookie to be ignored, got %+v", record)
+	}
+}
@@ -0,0 +1,235 @@
+package featurevisor
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structTagName is the struct tag naming context attributes, like `featurevisor:"country"`
+const structTagName = "featurevisor"
+
+// structField describes a tagged struct field
+type structField struct {
+	attribute string
+	index     []int
+	omitEmpty bool
+}
+
+// structFieldsCache maps struct types to their tagged fields
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ContextFrom converts a value to Context, accepting Context, any map with string keys,
+// and structs (or pointers to them) with attributes tagged like `featurevisor:"country"`.
+//
+// Nested structs become nested contexts, so their attributes can be used via dot paths
+// like "device.os". time.Time fields are kept as they are, and can be used with date operators.
+// Nil pointers and untagged fields are left out, and so are zero values of fields tagged with "omitempty".
+func ContextFrom(value interface{}) (Context, error) {
+	switch v := value.(type) {
+	case nil:
+		return Context{}, nil
+	case Context:
+		return v, nil
+	case map[string]interface{}:
+		return Context(v), nil
+	}
+
+	if m, ok := toMap(value); ok {
+		return Context(m), nil
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return Context{}, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct || v.Type() == timeType {
+		return nil, fmt.Errorf("unsupported context type %T", value)
+	}
+
+	return structToContext(v), nil
+}
+
+// parseArgs finds context and override options in variadic arguments, with context given as Context,
+// a map or a tagged struct. Context that can not be converted is logged and returned as an error.
+func parseArgs(args []interface{}, logger *Logger) (Context, OverrideOptions, error) {
+	contextValue := Context{}
+	optionsValue := OverrideOptions{}
+	var err error
+
+	for _, arg := range args {
+		if options, ok := arg.(OverrideOptions); ok {
+			optionsValue = options
+			continue
+		}
+
+		context, contextErr := ContextFrom(arg)
+		if contextErr != nil {
+			err = contextErr
+			continue
+		}
+
+		contextValue = context
+	}
+
+	if err != nil {
+		logger.Warn("invalid context", LogDetails{"error": err})
+	}
+
+	return contextValue, optionsValue, err
+}
+
+// isContextArg tells whether an argument is context rather than an output pointer:
+// a map with string keys, a struct, or a pointer to a struct with tagged fields
+func isContextArg(arg interface{}) bool {
+	if arg == nil {
+		return false
+	}
+	if _, ok := toMap(arg); ok {
+		return true
+	}
+
+	t := reflect.TypeOf(arg)
+	if t.Kind() == reflect.Struct {
+		return t != timeType
+	}
+
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Elem() != timeType && len(getStructFields(t.Elem())) > 0
+}
+
+// toContext converts context given to evaluation methods, logging context that can not be converted
+func toContext(value interface{}, logger *Logger) Context {
+	context, err := ContextFrom(value)
+	if err != nil {
+		logger.Warn("invalid context", LogDetails{"error": err})
+		return Context{}
+	}
+
+	return context
+}
+
+// structToContext reads tagged fields of a struct
+func structToContext(v reflect.Value) Context {
+	fields := getStructFields(v.Type())
+	context := make(Context, len(fields))
+
+	for _, field := range fields {
+		fieldValue, ok := fieldByIndex(v, field.index)
+		if !ok {
+			continue
+		}
+
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+
+		if value, ok := structFieldValue(fieldValue); ok {
+			context[field.attribute] = value
+		}
+	}
+
+	return context
+}
+
+// structFieldValue converts a struct field to a context value, with ok being false for nil pointers
+func structFieldValue(v reflect.Value) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		return structToContext(v), true
+	}
+
+	if !v.CanInterface() {
+		return nil, false
+	}
+
+	return v.Interface(), true
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, with ok being false if an embedded pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, position := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(position)
+	}
+
+	return v, true
+}
+
+// getStructFields returns tagged fields of a struct type, including those of embedded structs, cached per type
+func getStructFields(t reflect.Type) []structField {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]structField)
+	}
+
+	fields := collectStructFields(t, nil, map[reflect.Type]bool{})
+	cached, _ := structFieldsCache.LoadOrStore(t, fields)
+
+	return cached.([]structField)
+}
+
+func collectStructFields(t reflect.Type, index []int, visited map[reflect.Type]bool) []structField {
+	if visited[t] {
+		return nil
+	}
+	visited[t] = true
+
+	var fields []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		tag, hasTag := f.Tag.Lookup(structTagName)
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		// untagged embedded structs have their fields promoted
+		if f.Anonymous && name == "" {
+			embeddedType := f.Type
+			if embeddedType.Kind() == reflect.Ptr {
+				embeddedType = embeddedType.Elem()
+			}
+			if embeddedType.Kind() == reflect.Struct {
+				fields = append(fields, collectStructFields(embeddedType, fieldIndex, visited)...)
+				continue
+			}
+		}
+
+		if !hasTag || name == "" || !f.IsExported() {
+			continue
+		}
+
+		fields = append(fields, structField{
+			attribute: name,
+			index:     fieldIndex,
+			omitEmpty: options == "omitempty",
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,262 @@
+package featurevisor
+
+import (
+	"testing"
+	"time"
+)
+
+type structContextDevice struct {
+	OS      string `featurevisor:"os"`
+	Version string `featurevisor:"version,omitempty"`
+}
+
+type structContextBase struct {
+	UserID string `featurevisor:"userId"`
+}
+
+type structContextUser struct {
+	structContextBase
+	Country    string               `featurevisor:"country"`
+	Age        int                  `featurevisor:"age"`
+	Device     structContextDevice  `featurevisor:"device"`
+	Tablet     *structContextDevice `featurevisor:"tablet"`
+	SignedUpAt time.Time            `featurevisor:"signedUpAt"`
+	Email      string               `featurevisor:"-"`
+	Internal   string
+}
+
+func TestContextFrom(t *testing.T) {
+	signedUpAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	user := structContextUser{
+		structContextBase: structContextBase{UserID: "123"},
+		Country:           "nl",
+		Age:               30,
+		Device:            structContextDevice{OS: "ios"},
+		SignedUpAt:        signedUpAt,
+		Email:             "user@example.com",
+		Internal:          "secret",
+	}
+
+	for _, value := range []interface{}{user, &user} {
+		context, err := ContextFrom(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := map[string]interface{}{
+			"userId":         "123",
+			"country":        "nl",
+			"age":            30,
+			"device.os":      "ios",
+			"signedUpAt":     signedUpAt,
+			"tablet":         nil,
+			"email":          nil,
+			"Internal":       nil,
+			"Email":          nil,
+			"device.Version": nil,
+		}
+		for path, value := range expected {
+			if got := GetValueFromContext(context, path); got != value {
+				t.Errorf("expected %s to be %v, got %v", path, value, got)
+			}
+		}
+
+		if PathExists(context, "tablet") {
+			t.Error("expected nil pointer to be left out")
+		}
+		if PathExists(context, "device.version") {
+			t.Error("expected empty omitempty field to be left out")
+		}
+	}
+
+	if _, err := ContextFrom(42); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+
+	if context, err := ContextFrom(map[string]string{"country": "de"}); err != nil || context["country"] != "de" {
+		t.Errorf("expected map to be converted, got %v, %v", context, err)
+	}
+}
+
+func TestStructContextEvaluation(t *testing.T) {
+	instance := CreateInstance(Options{
+		Datafile: DatafileContent{
+			SchemaVersion: "2",
+			Revision:      "1",
+			Segments: map[SegmentKey]Segment{
+				"iosUsersInNetherlands": {Conditions: `[
+					{"attribute":"country","operator":"equals","value":"nl"},
+					{"attribute":"device.os","operator":"equals","value":"ios"},
+					{"attribute":"signedUpAt","operator":"after","value":"2023-12-31T00:00:00Z"}
+				]`},
+			},
+			Features: map[FeatureKey]Feature{
+				"test": {
+					BucketBy: "userId",
+					Traffic: []Traffic{
+						{Key: "1", Segments: "iosUsersInNetherlands", Percentage: 100000},
+					},
+				},
+			},
+		},
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	user := &structContextUser{
+		structContextBase: structContextBase{UserID: "123"},
+		Country:           "nl",
+		Device:            structContextDevice{OS: "ios"},
+		SignedUpAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if !instance.IsEnabled("test", user) {
+		t.Error("expected feature to be enabled for struct context")
+	}
+
+	other := *user
+	other.Country = "de"
+	if instance.IsEnabled("test", other) {
+		t.Error("expected feature to be disabled for other country")
+	}
+
+	instance.SetContext(user)
+	if !instance.IsEnabled("test") {
+		t.Error("expected feature to be enabled after setting struct context")
+	}
+
+	type countryContext struct {
+		Country string `featurevisor:"country"`
+	}
+
+	child := instance.Spawn(countryContext{Country: "de"})
+	if child.IsEnabled("test") {
+		t.Error("expected feature to be disabled for child with other country")
+	}
+
+	child.SetContext(&countryContext{Country: "nl"})
+	if !child.IsEnabled("test") {
+		t.Error("expected feature to be enabled after setting child struct context")
+	}
+}
+
+func TestStructContextInEvaluationMethods(t *testing.T) {
+	var logged []LogDetails
+	instance := newTestInstance(t, testDatafile{
+		segments: `{
+			"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]}
+		}`,
+		features: `{
+			"test": {
+				"bucketBy": "userId",
+				"traffic": [{"key": "1", "segments": "netherlands", "percentage": 100000}]
+			}
+		}`,
+	}, Options{
+		Logger: newTestLogger(func(level LogLevel, message LogMessage, details LogDetails) {
+			if message == "invalid context" {
+				logged = append(logged, details)
+			}
+		}),
+	})
+
+	user := structContextUser{structContextBase: structContextBase{UserID: "123"}, Country: "nl"}
+
+	if evaluation := instance.EvaluateFlag("test", user, OverrideOptions{}); evaluation.Enabled == nil || !*evaluation.Enabled {
+		t.Errorf("expected flag evaluation with struct context to be enabled, got %+v", evaluation)
+	}
+	if evaluations := instance.GetAllEvaluations(&user, nil, OverrideOptions{}); !evaluations["test"].Enabled {
+		t.Errorf("expected all evaluations with struct context to be enabled, got %+v", evaluations)
+	}
+	if !instance.EvaluateSegments("netherlands", user).Matched {
+		t.Error("expected segments to match struct context")
+	}
+	if evaluation := instance.Spawn().EvaluateFlag("test", user, OverrideOptions{}); evaluation.Enabled == nil || !*evaluation.Enabled {
+		t.Errorf("expected child flag evaluation with struct context to be enabled, got %+v", evaluation)
+	}
+	if len(logged) != 0 {
+		t.Fatalf("did not expect logs, got %v", logged)
+	}
+
+	// context that can not be converted is logged, and returned by methods with errors
+	if enabled, err := instance.IsEnabledE("test", 123); enabled || err == nil {
+		t.Errorf("expected error for invalid context, got %v, %v", enabled, err)
+	}
+	if _, err := instance.Spawn().GetVariationE("test", "invalid"); err == nil {
+		t.Error("expected error for invalid context from child")
+	}
+	if instance.IsInSegment("netherlands", 123) {
+		t.Error("did not expect invalid context to match")
+	}
+	if len(logged) != 3 {
+		t.Errorf("expected invalid context to be logged 3 times, got %v", logged)
+	}
+}
+
+func TestStructContextInVariableIntoMethods(t *testing.T) {
+	instance := newTestInstance(t, testDatafile{
+		segments: `{
+			"netherlands": {"conditions": [{"attribute": "country", "operator": "equals", "value": "nl"}]}
+		}`,
+		features: `{
+			"test": {
+				"bucketBy": "userId",
+				"variablesSchema": {
+					"items": {"type": "array", "defaultValue": []},
+					"config": {"type": "object", "defaultValue": {}}
+				},
+				"traffic": [
+					{
+						"key": "1",
+						"segments": "netherlands",
+						"percentage": 100000,
+						"variables": {"items": ["a", "b"], "config": {"color": "red"}}
+					},
+					{"key": "2", "segments": "*", "percentage": 100000}
+				]
+			}
+		}`,
+	}, Options{})
+
+	type config struct {
+		Color string `json:"color"`
+	}
+
+	user := structContextUser{structContextBase: structContextBase{UserID: "123"}, Country: "nl"}
+
+	for _, parent := range []bool{true, false} {
+		var getArrayInto, getObjectInto func(string, string, ...interface{}) error
+		if parent {
+			getArrayInto, getObjectInto = instance.GetVariableArrayInto, instance.GetVariableObjectInto
+		} else {
+			child := instance.Spawn()
+			getArrayInto, getObjectInto = child.GetVariableArrayInto, child.GetVariableObjectInto
+		}
+
+		var items []string
+		if err := getArrayInto("test", "items", user, &items); err != nil || len(items) != 2 || items[0] != "a" {
+			t.Errorf("expected items for struct context, got %v, %v", items, err)
+		}
+
+		var objectOut config
+		if err := getObjectInto("test", "config", &objectOut, &user); err != nil || objectOut.Color != "red" {
+			t.Errorf("expected config for struct pointer context given after output, got %+v, %v", objectOut, err)
+		}
+
+		objectOut = config{}
+		if err := getObjectInto("test", "config", user, OverrideOptions{}, &objectOut); err != nil || objectOut.Color != "red" {
+			t.Errorf("expected config for struct context with options, got %+v, %v", objectOut, err)
+		}
+
+		other := user
+		other.Country = "de"
+		var mapOut map[string]interface{}
+		if err := getObjectInto("test", "config", other, &mapOut); err != nil || len(mapOut) != 0 {
+			t.Errorf("expected default config for other country, got %v, %v", mapOut, err)
+		}
+
+		if err := getObjectInto("test", "config", user); err == nil {
+			t.Error("expected error for missing output pointer")
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package featurevisor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// allowSignup is taken as it is from a datafile built by Featurevisor (see TestProductionDatafileFeatures),
+// with a rule having both variationWeights and the allocation built from them
+const variationWeightsDatafile = `{
+	"schemaVersion": "2",
+	"revision": "1",
+	"segments": {
+		"everyone": {
+			"conditions": "*"
+		},
+		"countries/netherlands": {
+			"conditions": "[{\"attribute\":\"country\",\"operator\":\"equals\",\"value\":\"nl\"}]"
+		},
+		"countries/switzerland": {
+			"conditions": "{\"and\":[{\"attribute\":\"country\",\"operator\":\"equals\",\"value\":\"ch\"}]}"
+		}
+	},
+	"features": {
+		"allowSignup": {
+			"bucketBy": "deviceId",
+			"variations": [
+				{
+					"value": "control",
+					"weight": 50
+				},
+				{
+					"value": "treatment",
+					"weight": 50,
+					"variables": {
+						"allowGoogleSignUp": true,
+						"allowGitHubSignUp": true
+					}
+				}
+			],
+			"traffic": [
+				{
+					"key": "nl",
+					"segments": "[\"countries/netherlands\"]",
+					"percentage": 100000,
+					"allocation": [
+						{
+							"variation": "control",
+							"range": [
+								0,
+								50000
+							]
+						},
+						{
+							"variation": "treatment",
+							"range": [
+								50000,
+								100000
+							]
+						}
+					],
+					"variation": "treatment"
+				},
+				{
+					"key": "ch",
+					"segments": "[\"countries/switzerland\"]",
+					"percentage": 100000,
+					"allocation": [
+						{
+							"variation": "control",
+							"range": [
+								0,
+								10000
+							]
+						},
+						{
+							"variation": "treatment",
+							"range": [
+								10000,
+								100000
+							]
+						}
+					],
+					"variationWeights": {
+						"control": 10,
+						"treatment": 90
+					}
+				},
+				{
+					"key": "everyone",
+					"segments": "everyone",
+					"percentage": 100000,
+					"allocation": [
+						{
+							"variation": "control",
+							"range": [
+								0,
+								50000
+							]
+						},
+						{
+							"variation": "treatment",
+							"range": [
+								50000,
+								100000
+							]
+						}
+					]
+				}
+			],
+			"variablesSchema": {
+				"allowRegularSignUp": {
+					"type": "boolean",
+					"defaultValue": true
+				},
+				"allowGoogleSignUp": {
+					"type": "boolean",
+					"defaultValue": false
+				},
+				"allowGitHubSignUp": {
+					"type": "boolean",
+					"defaultValue": false
+				}
+			},
+			"hash": "8ZwSp88Vqf"
+		}
+	}
+}`
+
+func TestVariationWeightsUseAllocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		allocation  []Allocation
+		bucketValue int
+		expected    string
+	}{
+		// as built
+		{"start", nil, 0, "control"},
+		{"end of control", nil, 10000, "control"},
+		{"start of treatment", nil, 10001, "treatment"},
+		{"end", nil, 100000, "treatment"},
+
+		// ranges kept from an earlier revision, not contiguous per variation like the weights would make them
+		{"kept", []Allocation{
+			{Variation: "treatment", Range: Range{0, 40000}},
+			{Variation: "control", Range: Range{40000, 50000}},
+			{Variation: "treatment", Range: Range{50000, 100000}},
+		}, 5000, "treatment"},
+		{"kept control", []Allocation{
+			{Variation: "treatment", Range: Range{0, 40000}},
+			{Variation: "control", Range: Range{40000, 50000}},
+			{Variation: "treatment", Range: Range{50000, 100000}},
+		}, 45000, "control"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var datafile DatafileContent
+			if err := datafile.FromJSON(variationWeightsDatafile); err != nil {
+				t.Fatalf("failed to parse datafile: %v", err)
+			}
+
+			if tt.allocation != nil {
+				feature := datafile.Features["allowSignup"]
+				feature.Traffic[1].Allocation = tt.allocation
+				datafile.Features["allowSignup"] = feature
+			}
+
+			instance := CreateInstance(Options{
+				Datafile: datafile,
+				Hooks: []*Hook{
+					{
+						Name: "bucket-value",
+						BucketValue: func(options ConfigureBucketValueOptions) int {
+							return tt.bucketValue
+						},
+					},
+				},
+				LogLevel: &[]LogLevel{LogLevelFatal}[0],
+			})
+
+			variation := instance.GetVariation("allowSignup", Context{"deviceId": "123", "country": "ch"})
+			if variation == nil || *variation != tt.expected {
+				t.Errorf("bucket value %d: expected %s, got %v", tt.bucketValue, tt.expected, variationOrNil(variation))
+			}
+		})
+	}
+}
+
+func TestVariationWeightsWithoutAllocation(t *testing.T) {
+	var datafile DatafileContent
+	if err := datafile.FromJSON(variationWeightsDatafile); err != nil {
+		t.Fatalf("failed to parse datafile: %v", err)
+	}
+
+	// only weights are left to allocate from
+	feature := datafile.Features["allowSignup"]
+	feature.Traffic[1].Allocation = nil
+	feature.Traffic[1].VariationWeights = map[string]Weight{"control": 33.34, "treatment": 66.66}
+	datafile.Features["allowSignup"] = feature
+
+	bucketValue := 0
+	instance := CreateInstance(Options{
+		Datafile: datafile,
+		Hooks: []*Hook{
+			{
+				Name: "bucket-value",
+				BucketValue: func(options ConfigureBucketValueOptions) int {
+					return bucketValue
+				},
+			},
+		},
+		LogLevel: &[]LogLevel{LogLevelFatal}[0],
+	})
+
+	for value, expected := range map[int]string{33340: "control", 33341: "treatment"} {
+		bucketValue = value
+
+		variation := instance.GetVariation("allowSignup", Context{"deviceId": "123", "country": "ch"})
+		if variation == nil || *variation != expected {
+			t.Errorf("bucket value %d: expected %s, got %v", value, expected, variationOrNil(variation))
+		}
+	}
+}
+
+// fractionalVariationWeightsDatafile has fractional variation weights, with the allocation ranges
+// datafiles carry for them, which other SDKs evaluate as they are
+const fractionalVariationWeightsDatafile = `{
+	"schemaVersion": "2",
+	"revision": "1",
+	"segments": {},
+	"features": {
+		"thirds": {
+			"bucketBy": "userId",
+			"variations": [{"value": "a"}, {"value": "b"}, {"value": "c"}],
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"variationWeights": {"a": 33.34, "b": 33.33, "c": 33.33},
+					"allocation": [
+						{"variation": "a", "range": [0, 33340]},
+						{"variation": "b", "range": [33340, 66670]},
+						{"variation": "c", "range": [66670, 100000]}
+					]
+				}
+			]
+		},
+		"small": {
+			"bucketBy": "userId",
+			"variations": [{"value": "rare"}, {"value": "common"}],
+			"traffic": [
+				{
+					"key": "everyone",
+					"segments": "*",
+					"percentage": 100000,
+					"variationWeights": {"rare": 0.25, "common": 99.75},
+					"allocation": [
+						{"variation": "rare", "range": [0, 250]},
+						{"variation": "common", "range": [250, 100000]}
+					]
+				}
+			]
+		},
+		"partial": {
+			"bucketBy": "userId",
+			"variations": [{"value": "control"}, {"value": "treatment"}],
+			"traffic": [
+				{
+					"key": "half",
+					"segments": "*",
+					"percentage": 50000,
+					"variationWeights": {"control": 12.5, "treatment": 87.5},
+					"allocation": [
+						{"variation": "control", "range": [0, 6250]},
+						{"variation": "treatment", "range": [6250, 50000]}
+					]
+				}
+			]
+		}
+	}
+}`
+
+func TestFractionalVariationWeightsFixture(t *testing.T) {
+	var datafile DatafileContent
+	if err := datafile.FromJSON(fractionalVariationWeightsDatafile); err != nil {
+		t.Fatalf("failed to parse datafile: %v", err)
+	}
+
+	// only weights are left to allocate from
+	weightedDatafile := DatafileContent{SchemaVersion: "2", Revision: "1", Features: map[FeatureKey]Feature{}}
+	for featureKey, feature := range datafile.Features {
+		traffic := make([]Traffic, len(feature.Traffic))
+		copy(traffic, feature.Traffic)
+		for i := range traffic {
+			traffic[i].Allocation = nil
+		}
+		feature.Traffic = traffic
+		weightedDatafile.Features[featureKey] = feature
+	}
+
+	bucketValue := 0
+	hooks := []*Hook{
+		{
+			Name: "bucket-value",
+			BucketValue: func(options ConfigureBucketValueOptions) int {
+				return bucketValue
+			},
+		},
+	}
+	allocated := CreateInstance(Options{Datafile: datafile, Hooks: hooks, LogLevel: &[]LogLevel{LogLevelFatal}[0]})
+	weighted := CreateInstance(Options{Datafile: weightedDatafile, Hooks: hooks, LogLevel: &[]LogLevel{LogLevelFatal}[0]})
+
+	for _, featureKey := range []FeatureKey{"thirds", "small", "partial"} {
+		feature := datafile.Features[featureKey]
+		rule := feature.Traffic[0]
+
+		// weights give the exact ranges of the fixture
+		allocation := GetAllocationFromWeights(feature.Variations, rule.VariationWeights, rule.Percentage)
+		if !reflect.DeepEqual(allocation, rule.Allocation) {
+			t.Errorf("%s: expected allocation %v, got %v", featureKey, rule.Allocation, allocation)
+		}
+
+		// and the same variations at every range boundary
+		for _, expected := range rule.Allocation {
+			for _, value := range []int{expected.Range[0], expected.Range[0] + 1, expected.Range[1] - 1, expected.Range[1]} {
+				bucketValue = value
+
+				// ranges include their end, and the first matching one is used
+				expectedVariation := ""
+				for _, a := range rule.Allocation {
+					if value >= a.Range[0] && value <= a.Range[1] {
+						expectedVariation = a.Variation
+						break
+					}
+				}
+
+				for name, instance := range map[string]*Featurevisor{"allocation": allocated, "weights": weighted} {
+					variation := instance.GetVariation(string(featureKey), Context{"userId": "123"})
+					if variation == nil || *variation != expectedVariation {
+						t.Errorf("%s: bucket value %d: expected %s from %s, got %v", featureKey, value, expectedVariation, name, variationOrNil(variation))
+					}
+				}
+			}
+		}
+	}
+}
+
+func variationOrNil(value *string) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}